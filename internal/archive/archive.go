@@ -0,0 +1,54 @@
+// Package archive packs an export directory into a single compressed archive and
+// reads exports straight from such archives.
+package archive
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// Format is a supported archive format
+type Format string
+
+const (
+	FormatTarZstd Format = "tar.zst"
+	FormatZip     Format = "zip"
+)
+
+// Archive is a read-only view of an export inside an archive. Paths use forward
+// slashes and are relative to the export directory.
+type Archive interface {
+	fs.ReadDirFS
+	fs.ReadFileFS
+	io.Closer
+}
+
+// FormatFromPath selects the archive format from the file extension of path
+func FormatFromPath(path string) (Format, error) {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".tar.zst"), strings.HasSuffix(lower, ".tzst"):
+		return FormatTarZstd, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return FormatZip, nil
+	default:
+		return "", fmt.Errorf("unsupported archive %q, use a .tar.zst or .zip file name", path)
+	}
+}
+
+// Open opens an export archive for reading
+func Open(path string) (Archive, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatZip:
+		return openZip(path)
+	default:
+		return openTarZstd(path)
+	}
+}
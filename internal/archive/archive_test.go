@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	large := bytes.Repeat([]byte("0123456789abcdef"), 1<<17)
+	contents := map[string][]byte{
+		"manifest.json":                 []byte(`{"files":{}}`),
+		"tickets/ticket-1.json":         []byte(`{"schema_version":2,"id":1}`),
+		"tickets/attachments/1/big.bin": large,
+		"milestones/milestone-1.json":   []byte(`{"schema_version":2,"name":"1.0"}`),
+	}
+
+	var files []string
+	for rel, data := range contents {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", rel, err)
+		}
+		files = append(files, rel)
+	}
+
+	for _, name := range []string{"export.tar.zst", "export.zip"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := Create(dir, path, files); err != nil {
+				t.Fatalf("Create returned error: %v", err)
+			}
+
+			a, err := Open(path)
+			if err != nil {
+				t.Fatalf("Open returned error: %v", err)
+			}
+			defer func() {
+				_ = a.Close()
+			}()
+
+			for rel, want := range contents {
+				got, err := a.ReadFile(rel)
+				if err != nil {
+					t.Fatalf("ReadFile(%s) returned error: %v", rel, err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("ReadFile(%s) returned %d bytes, want %d", rel, len(got), len(want))
+				}
+			}
+
+			entries, err := a.ReadDir("tickets")
+			if err != nil {
+				t.Fatalf("ReadDir returned error: %v", err)
+			}
+			if len(entries) != 2 || !entries[0].IsDir() || entries[1].Name() != "ticket-1.json" {
+				t.Errorf("unexpected entries of tickets: %v", entries)
+			}
+
+			if _, err := a.ReadFile("wiki/WikiStart.wiki"); !os.IsNotExist(err) {
+				t.Errorf("expected not exist error, got %v", err)
+			}
+
+			if err := fstest.TestFS(a, "manifest.json", "tickets/ticket-1.json", "tickets/attachments/1/big.bin", "milestones/milestone-1.json"); err != nil {
+				t.Errorf("archive is not a valid fs.FS: %v", err)
+			}
+
+			// A tar.zst archive is extracted once and removed on close
+			if tz, ok := a.(*tarZstdArchive); ok {
+				tempDir := tz.dir
+				if data, err := os.ReadFile(filepath.Join(tempDir, "tickets", "ticket-1.json")); err != nil || !bytes.Equal(data, contents["tickets/ticket-1.json"]) {
+					t.Errorf("expected the small file to be extracted to disk, got %q: %v", data, err)
+				}
+				if err := tz.Close(); err != nil {
+					t.Fatalf("Close returned error: %v", err)
+				}
+				if _, err := os.Stat(tempDir); !os.IsNotExist(err) {
+					t.Errorf("expected %s to be removed, got %v", tempDir, err)
+				}
+			}
+		})
+	}
+}
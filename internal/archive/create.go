@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bnidev/trac2gitlab/internal/utils"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// entryWriter adds a single file to an archive
+type entryWriter func(rel string, info os.FileInfo, content io.Reader) error
+
+// Create streams the given files of an export directory into a new archive at path.
+// Files are relative to the export directory. The archive only appears at path once complete.
+func Create(exportDir, path string, files []string) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	out, err := utils.CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
+
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+
+	switch format {
+	case FormatZip:
+		err = writeZip(out, exportDir, sorted)
+	default:
+		err = writeTarZstd(out, exportDir, sorted)
+	}
+	if err != nil {
+		return err
+	}
+
+	return out.Commit()
+}
+
+// writeTarZstd writes a zstd compressed tar stream
+func writeTarZstd(w io.Writer, exportDir string, files []string) error {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return fmt.Errorf("failed to create zstd writer: %w", err)
+	}
+	tw := tar.NewWriter(zw)
+
+	err = addFiles(exportDir, files, func(rel string, info os.FileInfo, content io.Reader) error {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		header.Format = tar.FormatPAX
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, content)
+		return err
+	})
+	if err != nil {
+		_ = tw.Close()
+		_ = zw.Close()
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		_ = zw.Close()
+		return fmt.Errorf("failed to finish tar stream: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish zstd stream: %w", err)
+	}
+	return nil
+}
+
+// writeZip writes a deflate compressed zip archive
+func writeZip(w io.Writer, exportDir string, files []string) error {
+	zw := zip.NewWriter(w)
+
+	err := addFiles(exportDir, files, func(rel string, info os.FileInfo, content io.Reader) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		header.Method = zip.Deflate
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entry, content)
+		return err
+	})
+	if err != nil {
+		_ = zw.Close()
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish zip archive: %w", err)
+	}
+	return nil
+}
+
+// addFiles streams every file of the export directory to add
+func addFiles(exportDir string, files []string, add entryWriter) error {
+	for _, rel := range files {
+		if err := addFile(exportDir, rel, add); err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+// addFile streams a single file to add
+func addFile(exportDir, rel string, add entryWriter) error {
+	f, err := os.Open(filepath.Join(exportDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return add(filepath.ToSlash(rel), info, f)
+}
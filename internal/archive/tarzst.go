@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// tarZstdArchive reads an export from a zstd compressed tar stream. A tar stream has
+// no index to seek to a file, so it is unpacked once into a temporary directory on
+// open and read from disk, memory use does not grow with the size of the export.
+type tarZstdArchive struct {
+	dir  string
+	fsys fs.FS
+}
+
+// openTarZstd unpacks a tar.zst export archive into a temporary directory
+func openTarZstd(archivePath string) (*tarZstdArchive, error) {
+	dir, err := os.MkdirTemp("", "trac2gitlab-archive-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	a := &tarZstdArchive{dir: dir, fsys: os.DirFS(dir)}
+
+	if err := a.unpack(archivePath); err != nil {
+		_ = a.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// unpack extracts the directories and regular files of the archive, other entries
+// and paths leaving the export are skipped
+func (a *tarZstdArchive) unpack(archivePath string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open tar.zst archive: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read zstd stream: %w", err)
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar.zst archive: %w", err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if !fs.ValidPath(name) || name == "." {
+			continue
+		}
+		target := filepath.Join(a.dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = extractFile(target, tr, header)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to extract %s from archive: %w", name, err)
+		}
+	}
+}
+
+// extractFile writes the content of a regular file entry to target
+func extractFile(target string, r io.Reader, header *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
+
+func (a *tarZstdArchive) Open(name string) (fs.File, error) {
+	return a.fsys.Open(name)
+}
+
+func (a *tarZstdArchive) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(a.fsys, name)
+}
+
+func (a *tarZstdArchive) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(a.fsys, name)
+}
+
+func (a *tarZstdArchive) Close() error {
+	if a.dir == "" {
+		return nil
+	}
+	dir := a.dir
+	a.dir = ""
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove extracted files: %w", err)
+	}
+	return nil
+}
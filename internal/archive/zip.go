@@ -0,0 +1,37 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+)
+
+// zipArchive reads an export from a zip file, which supports random access
+type zipArchive struct {
+	rc *zip.ReadCloser
+}
+
+// openZip opens a zip export archive
+func openZip(path string) (*zipArchive, error) {
+	rc, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	return &zipArchive{rc: rc}, nil
+}
+
+func (a *zipArchive) Open(name string) (fs.File, error) {
+	return a.rc.Open(name)
+}
+
+func (a *zipArchive) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(&a.rc.Reader, name)
+}
+
+func (a *zipArchive) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(&a.rc.Reader, name)
+}
+
+func (a *zipArchive) Close() error {
+	return a.rc.Close()
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/bnidev/trac2gitlab/internal/app"
+	"github.com/bnidev/trac2gitlab/internal/converter"
+
+	"github.com/spf13/cobra"
+)
+
+func convertCmd(ctx *app.AppContext) *cobra.Command {
+	var outDir string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Render the raw Trac markup of an existing export as Markdown (offline)",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := *ctx.Config
+
+			if outDir == "" {
+				outDir = filepath.Join(cfg.ExportOptions.ExportDir, "converted")
+			}
+
+			conv, err := converter.New(&cfg, os.DirFS(cfg.ExportOptions.ExportDir))
+			if err != nil {
+				slog.Error("Failed to create converter", "errorMsg", err)
+				return
+			}
+
+			slog.Info("Converting export...", "exportDir", cfg.ExportOptions.ExportDir, "output", outDir)
+
+			if err := converter.ConvertExport(conv, cfg.ExportOptions.ExportDir, outDir, dryRun); err != nil {
+				slog.Error("Conversion failed", "errorMsg", err)
+				return
+			}
+
+			if dryRun {
+				for _, hit := range conv.Report().RuleHits() {
+					slog.Info("Rule fired", "rule", hit.Rule, "document", hit.Document.String(), "matches", hit.Matches)
+				}
+				for _, entry := range conv.Report().Entries() {
+					slog.Warn("Unresolved reference", "document", entry.Document.String(), "reference", entry.Reference, "reason", entry.Reason)
+				}
+				return
+			}
+
+			if unresolved := conv.Report().Entries(); len(unresolved) > 0 {
+				reportFile := filepath.Join(outDir, "conversion-report.txt")
+				if err := conv.Report().WriteFile(reportFile); err != nil {
+					slog.Warn("Failed to write conversion report", "error", err)
+				} else {
+					slog.Warn("Some references could not be converted", "count", len(unresolved), "report", reportFile)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "convert without writing files and show which rewrite rules fired on which documents")
+	cmd.Flags().StringVarP(&outDir, "output", "o", "", "output directory for the Markdown files (default: <export_dir>/converted)")
+
+	return cmd
+}
@@ -2,9 +2,13 @@ package cli
 
 import (
 	"log/slog"
+	"os"
 	"time"
 
 	"github.com/bnidev/trac2gitlab/internal/app"
+	"github.com/bnidev/trac2gitlab/internal/archive"
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/exportdb"
 	"github.com/bnidev/trac2gitlab/internal/exporter"
 	"github.com/bnidev/trac2gitlab/pkg/trac"
 
@@ -12,61 +16,185 @@ import (
 )
 
 func exportCmd(ctx *app.AppContext) *cobra.Command {
-	return &cobra.Command{
+	var fresh bool
+	var archivePath string
+	var sqlitePath string
+	var sqliteOnly bool
+	var showCapabilities bool
+	var recordPath, replayPath string
+
+	cmd := &cobra.Command{
 		Use:   "export",
 		Short: "Export tickets, wiki, users, and attachments from Trac",
 		Run: func(cmd *cobra.Command, args []string) {
 
 			cfg := *ctx.Config
+			runCtx := cmd.Context()
 
-			client, err := trac.NewTracClient(&cfg)
+			// The JSON files are only an intermediate step towards the database
+			if sqliteOnly {
+				if sqlitePath == "" {
+					slog.Error("Exporting only the database needs its path, set --sqlite")
+					return
+				}
+				tmpDir, err := os.MkdirTemp("", "trac2gitlab-export-")
+				if err != nil {
+					slog.Error("Failed to create temporary export directory", "errorMsg", err)
+					return
+				}
+				defer func() {
+					if err := os.RemoveAll(tmpDir); err != nil {
+						slog.Warn("Failed to remove temporary export directory", "path", tmpDir, "error", err)
+					}
+				}()
+				cfg.ExportOptions.ExportDir = tmpDir
+			}
+
+			sched := exporter.NewScheduler(&cfg)
+
+			if (recordPath != "" || replayPath != "") && cfg.Trac.SourceKind() == config.TracSourceEnv {
+				slog.Warn("Cassettes only apply to XML-RPC and web sources, the Trac environment is read directly")
+			}
+
+			base, closeCassette, err := cassetteTransport(recordPath, replayPath)
+			if err != nil {
+				slog.Error("Failed to open cassette", "errorMsg", err)
+				return
+			}
+			defer closeCassette()
+
+			client, closeSource, err := newTracSource(&cfg, sched.Transport(base))
 			if err != nil {
-				slog.Error("Failed to create Trac client", "errorMsg", err)
+				slog.Error("Failed to open Trac source", "errorMsg", err)
 				return
 			}
+			defer closeSource()
+
+			if rpc, ok := client.(*trac.Client); ok {
+				slog.Debug("Detecting capabilities of Trac client...")
 
-			slog.Debug("Checking compatibility of Trac client...")
+				caps, err := rpc.DetectCapabilities(runCtx)
+				if err != nil {
+					slog.Error("Failed to detect Trac capabilities", "errorMsg", err)
+					return
+				}
+				if !caps.VersionAtLeast(minPluginVersion...) {
+					slog.Warn("Trac XML-RPC plugin is older than tested, some data may be missing", "version", caps.VersionString())
+				}
+			}
 
-			if validateErr := client.ValidateExpectedMethods(); validateErr != nil {
-				slog.Error("Trac client validation failed", "errorMsg", validateErr)
+			if showCapabilities {
+				printCapabilities(cmd.OutOrStdout(), cfg.Trac.SourceKind(), exporter.SourceCapabilities(client))
 				return
 			}
 
-			if validateVersionErr := client.ValidatePluginVersion(); validateVersionErr != nil {
-				slog.Error("Trac plugin version validation failed", "errorMsg", validateVersionErr)
+			cp, err := exporter.OpenCheckpoint(cfg.ExportOptions.ExportDir, fresh)
+			if err != nil {
+				slog.Error("Failed to open export checkpoint", "errorMsg", err)
 				return
 			}
+			defer func() {
+				if err := cp.Close(); err != nil {
+					slog.Warn("Failed to close export checkpoint", "error", err)
+				}
+			}()
 
 			start := time.Now()
 
+			interrupted := func() bool {
+				if runCtx.Err() == nil {
+					return false
+				}
+				slog.Warn("Export interrupted, run export again to resume from the checkpoint", "duration", time.Since(start))
+				return true
+			}
+
 			if cfg.ExportOptions.IncludeTicketFields {
-				if err := exporter.ExportTicketFields(client, &cfg); err != nil {
+				if err := exporter.ExportTicketFields(runCtx, client, &cfg); err != nil {
 					slog.Error("Ticket fields export failed", "errorMsg", err)
 				}
+				if err := exporter.ExportComponents(runCtx, client, &cfg); err != nil {
+					slog.Error("Component export failed", "errorMsg", err)
+				}
+				if err := exporter.ExportWorkflow(runCtx, client, &cfg); err != nil {
+					slog.Error("Workflow export failed", "errorMsg", err)
+				}
+			}
+			if interrupted() {
+				return
 			}
 
-			if err := exporter.ExportTickets(client, &cfg); err != nil {
+			if err := exporter.ExportTickets(runCtx, client, &cfg, sched, cp); err != nil {
 				slog.Error("Ticket export failed", "errorMsg", err)
 			}
+			if interrupted() {
+				return
+			}
 
-			if err := exporter.ExportMilestones(client, &cfg); err != nil {
+			if err := exporter.ExportMilestones(runCtx, client, &cfg, sched); err != nil {
 				slog.Error("Milestone export failed", "errorMsg", err)
 			}
+			if interrupted() {
+				return
+			}
 
 			if cfg.ExportOptions.IncludeWiki {
-				if err := exporter.ExportWiki(client, &cfg); err != nil {
+				if err := exporter.ExportWiki(runCtx, client, &cfg, sched, cp); err != nil {
 					slog.Error("Wiki export failed", "errorMsg", err)
 				}
 			}
+			if interrupted() {
+				return
+			}
 
 			if cfg.ExportOptions.IncludeUsers {
-				if err := exporter.ExportUsers(client, &cfg); err != nil {
+				if err := exporter.ExportUsers(runCtx, client, &cfg); err != nil {
 					slog.Error("User export failed", "errorMsg", err)
 				}
 			}
+			if interrupted() {
+				return
+			}
+
+			manifest, err := exporter.WriteManifest(runCtx, client, &cfg)
+			if err != nil {
+				slog.Error("Failed to write export manifest", "errorMsg", err)
+			} else {
+				slog.Info("Export manifest written", "files", len(manifest.Files), "counts", manifest.Counts)
+
+				if sqlitePath != "" {
+					counts, err := exportdb.Build(runCtx, os.DirFS(cfg.ExportOptions.ExportDir), sqlitePath)
+					if err != nil {
+						slog.Error("Failed to write export database", "errorMsg", err)
+					} else {
+						slog.Info("Export database written", "path", sqlitePath, "rows", counts)
+					}
+				}
+
+				if archivePath != "" {
+					files := append(manifestFiles(manifest), exporter.ManifestFileName)
+					if err := archive.Create(cfg.ExportOptions.ExportDir, archivePath, files); err != nil {
+						slog.Error("Failed to write export archive", "errorMsg", err)
+					} else {
+						slog.Info("Export archive written", "path", archivePath, "files", len(files))
+					}
+				}
+			}
 
 			elapsed := time.Since(start)
 			slog.Info("Export completed successfully", "duration", elapsed)
 		},
 	}
+
+	cmd.Flags().StringVar(&sqlitePath, "sqlite", "", "also write the export into a normalized SQLite database")
+	cmd.Flags().BoolVar(&sqliteOnly, "sqlite-only", false, "write only the SQLite database given by --sqlite, without JSON files or attachment contents")
+	cmd.Flags().StringVar(&archivePath, "archive", "", "also package the export into a single .tar.zst or .zip archive")
+	cmd.Flags().BoolVar(&fresh, "fresh", false, "ignore the checkpoint of a previous export and export everything again")
+	cmd.Flags().StringVar(&recordPath, "record", "", "record all requests to Trac and their responses to a cassette file")
+	cmd.Flags().StringVar(&replayPath, "replay", "", "answer requests to Trac from a recorded cassette file instead of the network")
+	cmd.MarkFlagsMutuallyExclusive("record", "replay")
+	cmd.MarkFlagsMutuallyExclusive("sqlite-only", "archive")
+	cmd.Flags().BoolVar(&showCapabilities, "capabilities", false, "print which Trac features the source supports and exit")
+
+	return cmd
 }
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/app"
+	"github.com/bnidev/trac2gitlab/pkg/fakegitlab"
+
+	"github.com/spf13/cobra"
+)
+
+// saveInterval is how often fake-gitlab saves a changed state to its file
+const saveInterval = 2 * time.Second
+
+func fakeGitLabCmd(ctx *app.AppContext) *cobra.Command {
+	var listen string
+	var statePath string
+	var adminToken string
+	var projectID int
+	var projectPath string
+
+	cmd := &cobra.Command{
+		Use:          "fake-gitlab",
+		Short:        "Emulate the GitLab API used by migrate, for rehearsing a migration without GitLab",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state := fakegitlab.NewState()
+			if statePath != "" {
+				var err error
+				if state, err = fakegitlab.LoadState(statePath); err != nil {
+					return err
+				}
+			}
+
+			if projectID == 0 {
+				projectID = ctx.Config.GitLab.ProjectID
+			}
+			if projectID == 0 {
+				projectID = 1
+			}
+			project := state.EnsureProject(projectID, projectPath)
+
+			listener, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", listen, err)
+			}
+
+			handler := fakegitlab.NewServer(state, fakegitlab.Options{AdminToken: adminToken, StatePath: statePath})
+			server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+			// The state is saved periodically and once the server has shut down
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				ticker := time.NewTicker(saveInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ticker.C:
+						if err := handler.Save(); err != nil {
+							slog.Error("Failed to save state", "error", err)
+						}
+					case <-cmd.Context().Done():
+						shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+						defer cancel()
+						_ = server.Shutdown(shutdownCtx)
+						return
+					}
+				}
+			}()
+
+			baseURL := "http://" + listener.Addr().String()
+			slog.Info("Serving fake GitLab", "base_url", baseURL, "api_path", "/api/v4", "project_id", project.ID, "state", baseURL+"/_fake/state")
+			if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("fake GitLab server failed: %w", errors.Join(err, handler.Save()))
+			}
+			<-stopped
+			return handler.Save()
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", "127.0.0.1:8080", "Address to listen on")
+	cmd.Flags().StringVar(&statePath, "state", "", "JSON file the state is loaded from and saved to every few seconds and on exit (in memory only if empty)")
+	cmd.Flags().StringVar(&adminToken, "token", "", "Token of the administrator (any token is accepted if empty)")
+	cmd.Flags().IntVar(&projectID, "project-id", 0, "ID of the project to create (defaults to gitlab.project_id)")
+	cmd.Flags().StringVar(&projectPath, "project-path", "trac", "Path of the project to create")
+
+	return cmd
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/app"
+	"github.com/bnidev/trac2gitlab/pkg/faketrac"
+
+	"github.com/spf13/cobra"
+)
+
+func fakeTracCmd(ctx *app.AppContext) *cobra.Command {
+	var fixturesDir string
+	var listen string
+
+	cmd := &cobra.Command{
+		Use:          "fake-trac",
+		Short:        "Serve the Trac XML-RPC API from fixture files, for trying the export without Trac",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backend, err := faketrac.LoadFixtures(fixturesDir)
+			if err != nil {
+				return fmt.Errorf("failed to load fixtures: %w", err)
+			}
+
+			listener, err := net.Listen("tcp", listen)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", listen, err)
+			}
+
+			server := &http.Server{Handler: faketrac.NewServer(backend), ReadHeaderTimeout: 10 * time.Second}
+			go func() {
+				<-cmd.Context().Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = server.Shutdown(shutdownCtx)
+			}()
+
+			slog.Info("Serving fake Trac", "fixtures", fixturesDir, "base_url", "http://"+listener.Addr().String(), "rpc_path", "/login/rpc")
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("fake Trac server failed: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&fixturesDir, "fixtures", "", "Directory with the fixture JSON files and attachments")
+	cmd.Flags().StringVar(&listen, "listen", "127.0.0.1:8000", "Address to listen on")
+	_ = cmd.MarkFlagRequired("fixtures")
+
+	return cmd
+}
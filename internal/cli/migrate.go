@@ -1,9 +1,13 @@
 package cli
 
 import (
+	"io/fs"
 	"log/slog"
+	"os"
 
 	"github.com/bnidev/trac2gitlab/internal/app"
+	"github.com/bnidev/trac2gitlab/internal/archive"
+	"github.com/bnidev/trac2gitlab/internal/exportdb"
 	"github.com/bnidev/trac2gitlab/internal/importer"
 	"github.com/bnidev/trac2gitlab/pkg/gitlab"
 
@@ -11,13 +15,17 @@ import (
 )
 
 func migrateCmd(ctx *app.AppContext) *cobra.Command {
-	return &cobra.Command{
+	var archivePath string
+	var sqlitePath string
+
+	cmd := &cobra.Command{
 		Use:   "migrate",
 		Short: "Import exported data into GitLab",
 		Run: func(cmd *cobra.Command, args []string) {
 			slog.Info("Starting migration to GitLab...")
 
 			cfg := *ctx.Config
+			runCtx := cmd.Context()
 
 			client, err := gitlab.NewGitLabClient(&cfg)
 			if err != nil {
@@ -25,24 +33,80 @@ func migrateCmd(ctx *app.AppContext) *cobra.Command {
 				return
 			}
 
-			if err = client.ValidateGitLab(); err != nil {
+			if err = client.ValidateGitLab(runCtx); err != nil {
 				slog.Error("GitLab validation failed", "errorMsg", err)
 				return
 			}
 
+			var export fs.FS = os.DirFS(cfg.ExportOptions.ExportDir)
+			if archivePath != "" {
+				exportArchive, err := archive.Open(archivePath)
+				if err != nil {
+					slog.Error("Failed to open export archive", "errorMsg", err)
+					return
+				}
+				defer func() {
+					if err := exportArchive.Close(); err != nil {
+						slog.Warn("Failed to close export archive", "error", err)
+					}
+				}()
+				export = exportArchive
+			}
+
+			// Tickets and milestones come from the database, attachments from the export
+			if sqlitePath != "" {
+				db, err := exportdb.Open(sqlitePath)
+				if err != nil {
+					slog.Error("Failed to open export database", "errorMsg", err)
+					return
+				}
+				defer func() {
+					if err := db.Close(); err != nil {
+						slog.Warn("Failed to close export database", "error", err)
+					}
+				}()
+				export = db.FS(export)
+			}
+
 			if cfg.ImportOptions.ImportMilestones {
-				if err = importer.ImportMilestones(client, &cfg); err != nil {
+				if err = importer.ImportMilestones(runCtx, client, &cfg, export); err != nil {
 					slog.Error("Milestone import failed", "errorMsg", err)
 					return
 				}
 			}
 
+			if cfg.ImportOptions.Components.Labels {
+				if err = importer.ImportComponents(runCtx, client, &cfg, export); err != nil {
+					slog.Error("Component import failed", "errorMsg", err)
+					return
+				}
+			}
+
+			if cfg.ImportOptions.Workflow.StatusLabels() {
+				if err = importer.ImportWorkflow(runCtx, client, &cfg, export); err != nil {
+					slog.Error("Workflow import failed", "errorMsg", err)
+					return
+				}
+			}
+
+			if cfg.ImportOptions.Resolutions.Labels {
+				if err = importer.ImportResolutions(runCtx, client, &cfg, export); err != nil {
+					slog.Error("Resolution import failed", "errorMsg", err)
+					return
+				}
+			}
+
 			if cfg.ImportOptions.ImportIssues {
-				if err = importer.ImportIssues(client, &cfg); err != nil {
+				if err = importer.ImportIssues(runCtx, client, &cfg, export); err != nil {
 					slog.Error("Issue import failed", "errorMsg", err)
 					return
 				}
 			}
 		},
 	}
+
+	cmd.Flags().StringVar(&sqlitePath, "sqlite", "", "read tickets and milestones from an export database written by export --sqlite")
+	cmd.Flags().StringVar(&archivePath, "archive", "", "read the export from a .tar.zst or .zip archive instead of the export directory")
+
+	return cmd
 }
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/bnidev/trac2gitlab/internal/app"
+	"github.com/bnidev/trac2gitlab/internal/converter"
+	"github.com/bnidev/trac2gitlab/internal/preview"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+func previewCmd(ctx *app.AppContext) *cobra.Command {
+	var htmlFile string
+	var width int
+	var version int64
+
+	show := func(build func(conv *converter.Converter) (*preview.Preview, error)) {
+		cfg := *ctx.Config
+
+		conv, err := converter.New(&cfg, os.DirFS(cfg.ExportOptions.ExportDir))
+		if err != nil {
+			slog.Error("Failed to create converter", "errorMsg", err)
+			return
+		}
+
+		p, err := build(conv)
+		if err != nil {
+			slog.Error("Failed to build preview", "errorMsg", err)
+			return
+		}
+
+		if width <= 0 {
+			width = 160
+			if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+				width = w
+			}
+		}
+		fmt.Print(p.Terminal(width))
+
+		if htmlFile != "" {
+			if err := p.WriteHTML(htmlFile); err != nil {
+				slog.Error("Failed to write HTML preview", "errorMsg", err)
+				return
+			}
+			slog.Info("HTML preview written", "file", htmlFile)
+		}
+	}
+
+	cmd := &cobra.Command{
+		Use:   "preview",
+		Short: "Preview the Markdown conversion of an exported ticket or wiki page",
+	}
+
+	ticketCmd := &cobra.Command{
+		Use:   "ticket <id>",
+		Short: "Preview the conversion of a ticket and its comments",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				slog.Error("Invalid ticket ID", "id", args[0])
+				return
+			}
+			show(func(conv *converter.Converter) (*preview.Preview, error) {
+				return preview.Ticket(conv, ctx.Config.ExportOptions.ExportDir, id)
+			})
+		},
+	}
+
+	wikiCmd := &cobra.Command{
+		Use:   "wiki <page>",
+		Short: "Preview the conversion of a wiki page",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			show(func(conv *converter.Converter) (*preview.Preview, error) {
+				return preview.Wiki(conv, ctx.Config.ExportOptions.ExportDir, args[0], version)
+			})
+		},
+	}
+	wikiCmd.Flags().Int64Var(&version, "version", 0, "wiki page version to preview (default: latest)")
+
+	cmd.PersistentFlags().StringVar(&htmlFile, "html", "", "also write an HTML preview to this file")
+	cmd.PersistentFlags().IntVar(&width, "width", 0, "terminal width (default: detected)")
+	cmd.AddCommand(ticketCmd, wikiCmd)
+
+	return cmd
+}
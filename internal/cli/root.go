@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/bnidev/trac2gitlab/internal/app"
 	"github.com/spf13/cobra"
@@ -18,16 +21,47 @@ var rootCmd = &cobra.Command{
 func Execute(ctx *app.AppContext) {
 	SetupCommands(ctx)
 
-	if err := rootCmd.Execute(); err != nil {
+	runCtx, cancel := signalContext()
+	defer cancel()
+
+	if err := rootCmd.ExecuteContext(runCtx); err != nil {
 		slog.Error("Command execution failed", "errorMsg", err)
 		os.Exit(1)
 	}
 }
 
+// signalContext returns a context that is cancelled on SIGINT or SIGTERM so commands can
+// stop cleanly. A second signal terminates the process immediately.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			slog.Warn("Received signal, finishing current work and cleaning up (repeat to force quit)", "signal", sig.String())
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(signals)
+		}
+	}()
+
+	return ctx, cancel
+}
+
 // AddCommand functions can be called here once other commands are defined
 func SetupCommands(ctx *app.AppContext) {
+	rootCmd.AddCommand(convertCmd(ctx))
 	rootCmd.AddCommand(exportCmd(ctx))
+	rootCmd.AddCommand(fakeGitLabCmd(ctx))
+	rootCmd.AddCommand(fakeTracCmd(ctx))
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(migrateCmd(ctx))
+	rootCmd.AddCommand(previewCmd(ctx))
+	rootCmd.AddCommand(validateCmd(ctx))
+	rootCmd.AddCommand(verifyExportCmd(ctx))
 	rootCmd.AddCommand(versionCmd)
 }
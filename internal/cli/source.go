@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/exporter"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+	"github.com/bnidev/trac2gitlab/pkg/xmlrpc"
+)
+
+// newTracSource creates the configured Trac source and a function releasing it.
+// A nil transport uses http.DefaultTransport.
+func newTracSource(cfg *config.Config, transport http.RoundTripper) (exporter.Source, func(), error) {
+	switch kind := cfg.Trac.SourceKind(); kind {
+	case config.TracSourceXMLRPC:
+		client, err := trac.NewTracClient(cfg, transport)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create Trac client: %w", err)
+		}
+		return client, func() {}, nil
+	case config.TracSourceEnv:
+		env, err := trac.OpenEnv(cfg.Trac.EnvDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.Info("Reading Trac environment directly", "envDir", cfg.Trac.EnvDir)
+		return env, func() {
+			if err := env.Close(); err != nil {
+				slog.Warn("Failed to close Trac environment", "error", err)
+			}
+		}, nil
+	case config.TracSourceWeb:
+		slog.Info("Reading Trac through its web pages, comments are exported as plain text", "url", cfg.Trac.BaseURL)
+		return trac.NewWebClient(cfg.Trac.BaseURL, transport), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown Trac source %q, expected %s, %s or %s", kind, config.TracSourceXMLRPC, config.TracSourceEnv, config.TracSourceWeb)
+	}
+}
+
+// minPluginVersion is the oldest XML-RPC plugin version the export was tested with
+var minPluginVersion = []int64{1, 1, 9}
+
+// printCapabilities writes the capability matrix of a Trac source
+func printCapabilities(w io.Writer, kind string, caps *trac.Capabilities) {
+	_, _ = fmt.Fprintf(w, "Trac source: %s", kind)
+	if kind == config.TracSourceXMLRPC {
+		_, _ = fmt.Fprintf(w, " (plugin version %s)", caps.VersionString())
+	}
+	_, _ = fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CAPABILITY\tSUPPORTED\tUSED FOR\tMISSING METHODS")
+	for _, info := range caps.List() {
+		supported := "yes"
+		if !info.Supported {
+			supported = "no"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", info.Capability, supported, info.Description, strings.Join(info.Missing, ", "))
+	}
+	_ = tw.Flush()
+}
+
+// cassetteTransport returns the transport recording to or replaying from a cassette,
+// nil if neither is requested, and a function closing the cassette
+func cassetteTransport(recordPath, replayPath string) (http.RoundTripper, func(), error) {
+	switch {
+	case recordPath != "":
+		recorder, err := xmlrpc.NewRecorder(recordPath, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.Info("Recording Trac traffic", "cassette", recordPath)
+		return recorder, func() {
+			if err := recorder.Close(); err != nil {
+				slog.Warn("Failed to close cassette", "error", err)
+			}
+		}, nil
+	case replayPath != "":
+		player, err := xmlrpc.LoadCassette(replayPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		slog.Info("Replaying Trac traffic, no requests are sent", "cassette", replayPath)
+		return player, func() {}, nil
+	default:
+		return nil, func() {}, nil
+	}
+}
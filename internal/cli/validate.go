@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bnidev/trac2gitlab/internal/app"
+	"github.com/bnidev/trac2gitlab/internal/exporter"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+
+	"github.com/spf13/cobra"
+)
+
+func validateCmd(ctx *app.AppContext) *cobra.Command {
+	var upgrade bool
+
+	cmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Validate the exported tickets and milestones against the export schema before import",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exportDir := ctx.Config.ExportOptions.ExportDir
+
+			slog.Info("Validating export...", "exportDir", exportDir, "schemaVersion", schema.Version)
+
+			result, err := schema.CheckExport(exportDir, upgrade)
+			if err != nil {
+				return err
+			}
+
+			if len(result.Upgraded) > 0 {
+				slog.Info("Upgraded files to the current schema version", "count", len(result.Upgraded))
+				if err := exporter.RefreshManifest(exportDir, result.Upgraded); err != nil {
+					return fmt.Errorf("failed to update manifest after upgrade: %w", err)
+				}
+			}
+
+			for _, rel := range result.Outdated {
+				slog.Warn("File uses an older schema version", "file", rel)
+			}
+			for _, invalid := range result.Invalid {
+				slog.Error("File does not match the export schema", "file", invalid.File, "errorMsg", invalid.Err)
+			}
+
+			if len(result.Outdated) > 0 {
+				slog.Warn("Run validate --upgrade to rewrite outdated files, the importer upgrades them on the fly", "count", len(result.Outdated))
+			}
+			if len(result.Stale) > 0 {
+				slog.Warn("Tickets were exported without their status changes, export them again to import who closed them", "count", len(result.Stale))
+			}
+			if len(result.Invalid) > 0 {
+				return fmt.Errorf("%d of %d file(s) are invalid", len(result.Invalid), result.Checked)
+			}
+
+			slog.Info("Export is valid", "files", result.Checked)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&upgrade, "upgrade", false, "rewrite files written by older versions in the current schema version")
+
+	return cmd
+}
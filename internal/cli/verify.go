@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/bnidev/trac2gitlab/internal/app"
+	"github.com/bnidev/trac2gitlab/internal/exporter"
+
+	"github.com/spf13/cobra"
+)
+
+func verifyExportCmd(ctx *app.AppContext) *cobra.Command {
+	var offline bool
+
+	cmd := &cobra.Command{
+		Use:          "verify-export",
+		Short:        "Check an export directory against its manifest and the live Trac instance",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := *ctx.Config
+			exportDir := cfg.ExportOptions.ExportDir
+
+			manifest, err := exporter.LoadManifest(exportDir)
+			if err != nil {
+				return err
+			}
+
+			slog.Info("Verifying export...", "exportDir", exportDir, "files", len(manifest.Files), "exportedAt", manifest.CreatedAt)
+
+			result, err := exporter.VerifyExport(exportDir, manifest)
+			if err != nil {
+				return err
+			}
+
+			for _, rel := range result.Missing {
+				slog.Error("Missing file", "file", rel)
+			}
+			for _, rel := range result.Corrupt {
+				slog.Error("Corrupt file", "file", rel)
+			}
+			for _, rel := range result.Extra {
+				slog.Warn("File not in manifest", "file", rel)
+			}
+			for _, kind := range sortedKeys(result.Counts) {
+				slog.Error("Entity count mismatch", "kind", kind, "detail", result.Counts[kind])
+			}
+
+			problems := len(result.Missing) + len(result.Corrupt) + len(result.Extra) + len(result.Counts)
+
+			if !offline {
+				client, closeSource, err := newTracSource(&cfg, nil)
+				if err != nil {
+					return err
+				}
+				defer closeSource()
+
+				live, err := exporter.TracCounts(cmd.Context(), client, &cfg)
+				if err != nil {
+					return err
+				}
+
+				exported := exporter.CountExportedEntities(manifestFiles(manifest))
+				for _, kind := range sortedKeys(live) {
+					if live[kind] != exported[kind] {
+						slog.Warn("Trac differs from export", "kind", kind, "trac", live[kind], "export", exported[kind])
+						problems++
+					}
+				}
+			}
+
+			if problems > 0 {
+				return fmt.Errorf("export verification found %d problem(s)", problems)
+			}
+
+			slog.Info("Export verified successfully", "files", len(manifest.Files))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&offline, "offline", false, "only check the files against the manifest, without querying Trac")
+
+	return cmd
+}
+
+// manifestFiles returns the paths recorded in a manifest
+func manifestFiles(manifest *exporter.Manifest) []string {
+	files := make([]string, 0, len(manifest.Files))
+	for rel := range manifest.Files {
+		files = append(files, rel)
+	}
+	return files
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
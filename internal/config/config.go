@@ -15,6 +15,8 @@ type Config struct {
 	GitLab        GitLabConfig  `yaml:"gitlab"`
 	ExportOptions ExportOptions `yaml:"export_options"`
 	ImportOptions ImportOptions `yaml:"import_options"`
+	Conversion    Conversion    `yaml:"conversion"`
+	UserMapping   UserMapping   `yaml:"user_mapping"`
 }
 
 // Ceneral holds general configuration options
@@ -26,6 +28,29 @@ type General struct {
 type TracConfig struct {
 	BaseURL string `yaml:"base_url"`
 	RPCPath string `yaml:"rpc_path"`
+	// EnvDir reads a Trac environment directory instead of calling XML-RPC
+	EnvDir string `yaml:"env_dir"`
+	// Source selects how Trac is read, defaults to "env" if EnvDir is set and "xmlrpc" otherwise
+	Source string `yaml:"source"`
+}
+
+// Ways of reading Trac
+const (
+	TracSourceXMLRPC = "xmlrpc"
+	TracSourceEnv    = "env"
+	TracSourceWeb    = "web"
+)
+
+// SourceKind returns the configured way of reading Trac
+func (t TracConfig) SourceKind() string {
+	switch {
+	case t.Source != "":
+		return t.Source
+	case t.EnvDir != "":
+		return TracSourceEnv
+	default:
+		return TracSourceXMLRPC
+	}
 }
 
 // GitLabConfig holds the configuration for the GitLab instance
@@ -38,21 +63,227 @@ type GitLabConfig struct {
 
 // ExportOptions holds the options for exporting data from Trac
 type ExportOptions struct {
-	IncludeWiki            bool     `yaml:"include_wiki"`
-	IncludeAttachments     bool     `yaml:"include_attachments"`
-	IncludeTicketHistory   bool     `yaml:"include_ticket_history"`
-	IncludeClosedTickets   bool     `yaml:"include_closed_tickets"`
-	IncludeTicketFields    bool     `yaml:"include_ticket_fields"`
-	AdditionalTicketFields []string `yaml:"additional_ticket_fields"`
-	IncludeUsers           bool     `yaml:"include_users"`
-	ExportDir              string   `yaml:"export_dir"`
+	IncludeWiki            bool              `yaml:"include_wiki"`
+	IncludeAttachments     bool              `yaml:"include_attachments"`
+	IncludeTicketHistory   bool              `yaml:"include_ticket_history"`
+	IncludeClosedTickets   bool              `yaml:"include_closed_tickets"`
+	IncludeTicketFields    bool              `yaml:"include_ticket_fields"`
+	AdditionalTicketFields []string          `yaml:"additional_ticket_fields"`
+	IncludeUsers           bool              `yaml:"include_users"`
+	ExportDir              string            `yaml:"export_dir"`
+	Concurrency            ExportConcurrency `yaml:"concurrency"`
+	AttachmentLimits       AttachmentLimits  `yaml:"attachment_limits"`
+}
+
+// ExportConcurrency limits the load the export puts on Trac. Zero values use the defaults.
+type ExportConcurrency struct {
+	MaxRequests       int     `yaml:"max_requests"`
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Tickets           int     `yaml:"tickets"`
+	Attachments       int     `yaml:"attachments"`
+	WikiPages         int     `yaml:"wiki_pages"`
+	Milestones        int     `yaml:"milestones"`
+}
+
+// Policies for attachments over the size limit or with a size different from the one Trac reports
+const (
+	AttachmentPolicySkip = "skip"
+	AttachmentPolicyFail = "fail"
+	AttachmentPolicyKeep = "keep"
+)
+
+// AttachmentLimits selects which attachments are downloaded. Skipped attachments stay
+// listed in the export without their content.
+type AttachmentLimits struct {
+	MaxSizeMB      int64    `yaml:"max_size_mb"`
+	SkipPatterns   []string `yaml:"skip_patterns"`
+	OnOversize     string   `yaml:"on_oversize"`
+	OnSizeMismatch string   `yaml:"on_size_mismatch"`
 }
 
 // ImportOptions holds the options for importing data into GitLab
 type ImportOptions struct {
-	ImportIssues     bool `yaml:"import_issues"`
-	ImportMilestones bool `yaml:"import_milestones"`
-	CreateUsers      bool `yaml:"create_users"`
+	ImportIssues     bool              `yaml:"import_issues"`
+	ImportMilestones bool              `yaml:"import_milestones"`
+	CreateUsers      bool              `yaml:"create_users"`
+	Components       ComponentOptions  `yaml:"components"`
+	Workflow         WorkflowOptions   `yaml:"workflow"`
+	Resolutions      ResolutionOptions `yaml:"resolutions"`
+}
+
+// ComponentOptions selects how Trac components are imported. Labels creates a label per
+// component, named "<scope>::<component>" if Scope is set. AssignOwner assigns issues
+// without owner to the owner of their component. Renames maps former component names
+// to their current name, so tickets of renamed components get the current label.
+type ComponentOptions struct {
+	Labels      bool              `yaml:"labels"`
+	Scope       string            `yaml:"scope"`
+	AssignOwner bool              `yaml:"assign_owner"`
+	Renames     map[string]string `yaml:"renames"`
+}
+
+// Name returns the current name of a component, following renames
+func (c ComponentOptions) Name(component string) string {
+	// Bounded, so rename cycles cannot loop forever
+	for range len(c.Renames) {
+		renamed, ok := c.Renames[component]
+		if !ok || renamed == component {
+			break
+		}
+		component = renamed
+	}
+	return component
+}
+
+// Label returns the name of the label of a component
+func (c ComponentOptions) Label(component string) string {
+	return c.scoped(c.Name(component))
+}
+
+// FormerLabels returns the labels of renamed components under their former names
+func (c ComponentOptions) FormerLabels() []string {
+	labels := make([]string, 0, len(c.Renames))
+	for former := range c.Renames {
+		labels = append(labels, c.scoped(former))
+	}
+	return labels
+}
+
+// scoped returns the label of a component name
+func (c ComponentOptions) scoped(name string) string {
+	if c.Scope == "" {
+		return name
+	}
+	return c.Scope + "::" + name
+}
+
+// WorkflowOptions maps Trac ticket statuses to scoped labels, "<scope>::<status>" with
+// the scope "status" by default. Board names an issue board that gets a list per status
+// in workflow order and implies the labels. Statuses sets the workflow order, by
+// default it is taken from the exported workflow or ticket fields.
+type WorkflowOptions struct {
+	Labels   bool     `yaml:"labels"`
+	Scope    string   `yaml:"scope"`
+	Board    string   `yaml:"board"`
+	Statuses []string `yaml:"statuses"`
+}
+
+// StatusLabels reports whether issues are labeled with their status
+func (w WorkflowOptions) StatusLabels() bool {
+	return w.Labels || w.Board != ""
+}
+
+// LabelScope returns the scope of the status labels
+func (w WorkflowOptions) LabelScope() string {
+	if w.Scope == "" {
+		return "status"
+	}
+	return w.Scope
+}
+
+// Label returns the name of the label of a status
+func (w WorkflowOptions) Label(status string) string {
+	return w.LabelScope() + "::" + status
+}
+
+// ResolutionOptions maps the resolution of closed tickets. Labels adds a scoped label
+// "<scope>::<resolution>", with the scope "resolution" by default. ClosingNote posts a
+// note by the user who closed the ticket at the time it was closed. Duplicates marks
+// issues closed as duplicate as duplicates of the ticket referenced in their comments.
+type ResolutionOptions struct {
+	Labels      bool   `yaml:"labels"`
+	Scope       string `yaml:"scope"`
+	ClosingNote bool   `yaml:"closing_note"`
+	Duplicates  bool   `yaml:"duplicates"`
+}
+
+// LabelScope returns the scope of the resolution labels
+func (r ResolutionOptions) LabelScope() string {
+	if r.Scope == "" {
+		return "resolution"
+	}
+	return r.Scope
+}
+
+// Label returns the name of the label of a resolution
+func (r ResolutionOptions) Label(resolution string) string {
+	return r.LabelScope() + "::" + resolution
+}
+
+// Conversion holds the options for converting Trac wiki markup to GitLab Markdown
+type Conversion struct {
+	Repositories []RepositoryConfig `yaml:"repositories"`
+	Mentions     MentionsConfig     `yaml:"mentions"`
+	Rules        []RewriteRule      `yaml:"rules"`
+	InterTrac    []InterTracConfig  `yaml:"intertrac"`
+	InterWiki    map[string]string  `yaml:"interwiki"`
+	LoadInterMap bool               `yaml:"load_intermap"`
+}
+
+// InterTracConfig maps an InterTrac prefix to another Trac environment.
+// If GitLabProject is set (e.g. "group/project"), links resolve to that GitLab
+// project, otherwise they point to the original Trac environment.
+type InterTracConfig struct {
+	Prefix        string `yaml:"prefix"`
+	URL           string `yaml:"url"`
+	GitLabProject string `yaml:"gitlab_project"`
+}
+
+// RewriteRule is a user defined replacement applied before (on Trac markup) or
+// after (on Markdown) the built-in conversion. Scopes limits the rule to
+// ticket, comment, wiki or milestone texts, an empty list applies it everywhere.
+type RewriteRule struct {
+	Name    string   `yaml:"name"`
+	Stage   string   `yaml:"stage"`
+	Scopes  []string `yaml:"scopes"`
+	Pattern string   `yaml:"pattern"`
+	Replace string   `yaml:"replace"`
+	Literal bool     `yaml:"literal"`
+}
+
+// RepositoryConfig maps a Trac (SVN) repository to a GitLab project.
+// The repository with an empty name is the default repository.
+type RepositoryConfig struct {
+	Name        string            `yaml:"name"`
+	ProjectURL  string            `yaml:"project_url"`
+	GitSVNClone string            `yaml:"git_svn_clone"`
+	RevisionMap string            `yaml:"revision_map"`
+	Branches    map[string]string `yaml:"branches"`
+}
+
+// MentionsConfig holds the options for turning Trac identities in text into GitLab mentions
+type MentionsConfig struct {
+	Enabled          bool `yaml:"enabled"`
+	NotificationSafe bool `yaml:"notification_safe"`
+	ObfuscateEmails  bool `yaml:"obfuscate_emails"`
+}
+
+// UserMapping maps Trac usernames and email addresses to GitLab usernames.
+// Entries in Users take precedence over entries loaded from File.
+type UserMapping struct {
+	File  string            `yaml:"file"`
+	Users map[string]string `yaml:"users"`
+}
+
+// Load returns the combined user mapping from the mapping file and the inline entries
+func (m UserMapping) Load() (map[string]string, error) {
+	users := make(map[string]string)
+
+	if m.File != "" {
+		data, err := os.ReadFile(m.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user mapping file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &users); err != nil {
+			return nil, fmt.Errorf("failed to parse user mapping file: %w", err)
+		}
+	}
+
+	for tracUser, gitlabUser := range m.Users {
+		users[tracUser] = gitlabUser
+	}
+
+	return users, nil
 }
 
 // LoadConfig reads the configuration from config.yaml
@@ -79,7 +310,7 @@ func LoadConfig() (Config, error) {
 		return cfg, fmt.Errorf("failed to parse config.yaml: %w", err)
 	}
 
-	if cfg.Trac.BaseURL == "" || cfg.Trac.RPCPath == "" {
+	if cfg.Trac.SourceKind() == TracSourceXMLRPC && (cfg.Trac.BaseURL == "" || cfg.Trac.RPCPath == "") {
 		slog.Warn("Trac configuration is incomplete. Please check your config.yaml file.")
 	}
 
@@ -0,0 +1,302 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+)
+
+// changesetPattern matches Trac references to the version control system:
+//
+//	[changeset:1234 label], [source:trunk/foo.c@12 label], [log:trunk@1:5]
+//	[1234], [1234/repo], [100:200]
+//	changeset:1234/repo, source:trunk/foo.c@1234#L10, log:trunk@100:200
+//	r1234, r100:200
+var changesetPattern = regexp.MustCompile(
+	`\[(changeset|source|browser|log):("[^"]*"|[^\s\]]+)(?:\s+([^\]]+))?\]` +
+		`|\[(\d+)(?::(\d+))?(?:/([\w.-]+))?\]` +
+		`|\b(changeset|source|browser|log):("[^"]*"|[^\s\[\]()<>,;"']+)` +
+		`|\br(\d+)(?::(\d+))?\b`,
+)
+
+// repository holds everything needed to turn SVN references into GitLab URLs
+type repository struct {
+	projectURL string
+	revisions  RevisionMap
+	branches   map[string]string
+}
+
+// newRepository loads the revision map for a configured repository
+func newRepository(repoCfg config.RepositoryConfig) (*repository, error) {
+	if repoCfg.ProjectURL == "" {
+		return nil, fmt.Errorf("project_url is required")
+	}
+
+	repo := &repository{
+		projectURL: strings.TrimSuffix(repoCfg.ProjectURL, "/"),
+		revisions:  make(RevisionMap),
+		branches:   repoCfg.Branches,
+	}
+
+	if repoCfg.RevisionMap != "" {
+		revisions, err := LoadRevisionMap(repoCfg.RevisionMap)
+		if err != nil {
+			return nil, err
+		}
+		for rev, sha := range revisions {
+			repo.revisions[rev] = sha
+		}
+	}
+
+	if repoCfg.GitSVNClone != "" {
+		revisions, err := ScanGitSVN(repoCfg.GitSVNClone)
+		if err != nil {
+			return nil, err
+		}
+		for rev, sha := range revisions {
+			if _, exists := repo.revisions[rev]; !exists {
+				repo.revisions[rev] = sha
+			}
+		}
+	}
+
+	return repo, nil
+}
+
+// commit returns the git SHA for an SVN revision
+func (r *repository) commit(rev string) (string, error) {
+	num, err := strconv.ParseInt(rev, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("unsupported revision %q", rev)
+	}
+	sha, ok := r.revisions[num]
+	if !ok {
+		return "", fmt.Errorf("revision %d not found in revision map", num)
+	}
+	return sha, nil
+}
+
+// branch maps an SVN path to a git branch and the path inside that branch.
+// Configured prefixes win, otherwise trunk maps to main and branches/<name>
+// and tags/<name> map to <name>.
+func (r *repository) branch(path string) (string, string, error) {
+	path = strings.Trim(path, "/")
+
+	bestPrefix := ""
+	bestBranch := ""
+	for prefix, branch := range r.branches {
+		prefix = strings.Trim(prefix, "/")
+		if (path == prefix || strings.HasPrefix(path, prefix+"/")) && len(prefix) > len(bestPrefix) {
+			bestPrefix, bestBranch = prefix, branch
+		}
+	}
+	if bestBranch != "" {
+		return bestBranch, strings.TrimPrefix(strings.TrimPrefix(path, bestPrefix), "/"), nil
+	}
+
+	parts := strings.SplitN(path, "/", 3)
+	switch {
+	case parts[0] == "trunk":
+		return "main", strings.TrimPrefix(strings.TrimPrefix(path, "trunk"), "/"), nil
+	case (parts[0] == "branches" || parts[0] == "tags") && len(parts) > 1:
+		rest := ""
+		if len(parts) == 3 {
+			rest = parts[2]
+		}
+		return parts[1], rest, nil
+	}
+
+	return "", "", fmt.Errorf("no branch mapping for path %q", path)
+}
+
+// convertChangesets rewrites changeset, log and source references into GitLab links
+func (c *Converter) convertChangesets(doc Document, text string) string {
+	return replaceOutsideCode(text, func(prose string) string {
+		var out strings.Builder
+		last := 0
+
+		for _, m := range changesetPattern.FindAllStringSubmatchIndex(prose, -1) {
+			start, end := m[0], m[1]
+			if start < last || insideURL(prose, start) {
+				continue
+			}
+
+			group := func(i int) string {
+				if m[2*i] < 0 {
+					return ""
+				}
+				return prose[m[2*i]:m[2*i+1]]
+			}
+
+			var kind, target, label string
+			switch {
+			case group(1) != "":
+				kind, target, label = group(1), strings.Trim(group(2), `"`), group(3)
+				if label == "" {
+					label = target
+				}
+			case group(4) != "":
+				// Skip Markdown links and reference definitions like [1234](...) or [1234]: ...
+				if start > 0 && prose[start-1] == ']' {
+					continue
+				}
+				if end < len(prose) && strings.ContainsRune("([:", rune(prose[end])) {
+					continue
+				}
+				kind, target, label = revisionTarget(group(4), group(5), group(6))
+			case group(7) != "":
+				kind = group(7)
+				trimmed := strings.TrimRight(group(8), ".,!?:")
+				end -= len(group(8)) - len(trimmed)
+				target = strings.Trim(trimmed, `"`)
+				label = prose[start:end]
+			default:
+				kind, target, label = revisionTarget(group(9), group(10), "")
+			}
+
+			url, err := c.resolveReference(kind, target)
+			if err != nil {
+				c.report.Add(doc, prose[start:end], err.Error())
+				continue
+			}
+
+			out.WriteString(prose[last:start])
+			fmt.Fprintf(&out, "[%s](%s)", label, url)
+			last = end
+		}
+
+		out.WriteString(prose[last:])
+		return out.String()
+	})
+}
+
+// revisionTarget turns the parts of a r1234, r1:2 or [1234/repo] reference into a typed target
+func revisionTarget(rev, toRev, repo string) (string, string, string) {
+	if toRev != "" {
+		target := "@" + rev + ":" + toRev
+		if repo != "" {
+			target = repo + target
+		}
+		return "log", target, "r" + rev + ":" + toRev
+	}
+
+	target := rev
+	if repo != "" {
+		target += "/" + repo
+	}
+	return "changeset", target, "r" + rev
+}
+
+// resolveReference returns the GitLab URL for a typed Trac version control reference
+func (c *Converter) resolveReference(kind, target string) (string, error) {
+	switch kind {
+	case "changeset":
+		rev, repoName, _ := strings.Cut(target, "/")
+		repo, ok := c.repos[repoName]
+		if !ok {
+			// changeset:1234/trunk/path restricts to a path in the default repository
+			repo, ok = c.repos[""]
+		}
+		if !ok {
+			return "", fmt.Errorf("no repository configured for %q", repoName)
+		}
+		sha, err := repo.commit(rev)
+		if err != nil {
+			return "", err
+		}
+		return repo.projectURL + "/-/commit/" + sha, nil
+
+	case "log":
+		repo, path, err := c.repositoryForPath(target)
+		if err != nil {
+			return "", err
+		}
+		path, revs, _ := strings.Cut(path, "@")
+		if revs != "" {
+			from, to, found := strings.Cut(revs, ":")
+			if !found {
+				sha, err := repo.commit(from)
+				if err != nil {
+					return "", err
+				}
+				return repo.projectURL + "/-/commits/" + sha, nil
+			}
+			if fromNum, err1 := strconv.ParseInt(from, 10, 64); err1 == nil {
+				if toNum, err2 := strconv.ParseInt(to, 10, 64); err2 == nil && fromNum > toNum {
+					from, to = to, from
+				}
+			}
+			fromSHA, err := repo.commit(from)
+			if err != nil {
+				return "", err
+			}
+			toSHA, err := repo.commit(to)
+			if err != nil {
+				return "", err
+			}
+			return repo.projectURL + "/-/compare/" + fromSHA + "..." + toSHA, nil
+		}
+		branch, rest, err := repo.branch(path)
+		if err != nil {
+			return "", err
+		}
+		return joinURL(repo.projectURL+"/-/commits/"+branch, rest), nil
+
+	case "source", "browser":
+		repo, path, err := c.repositoryForPath(target)
+		if err != nil {
+			return "", err
+		}
+		path, fragment, _ := strings.Cut(path, "#")
+		path, rev, _ := strings.Cut(path, "@")
+		branch, rest, err := repo.branch(path)
+		if err != nil {
+			return "", err
+		}
+		ref := branch
+		if rev != "" && !strings.EqualFold(rev, "head") {
+			if ref, err = repo.commit(rev); err != nil {
+				return "", err
+			}
+		}
+		url := joinURL(repo.projectURL+"/-/tree/"+ref, rest)
+		if rest != "" {
+			url = joinURL(repo.projectURL+"/-/blob/"+ref, rest)
+		}
+		if fragment != "" {
+			url += "#" + fragment
+		}
+		return url, nil
+	}
+
+	return "", fmt.Errorf("unsupported reference type %q", kind)
+}
+
+// repositoryForPath picks the repository named by the first path component
+// and falls back to the default repository
+func (c *Converter) repositoryForPath(path string) (*repository, string, error) {
+	path = strings.TrimPrefix(path, "/")
+	head, rest, _ := strings.Cut(path, "/")
+	name, revs, hasRevs := strings.Cut(head, "@")
+	if repo, ok := c.repos[name]; ok && name != "" {
+		if hasRevs {
+			rest = "@" + revs
+		}
+		return repo, rest, nil
+	}
+	if repo, ok := c.repos[""]; ok {
+		return repo, path, nil
+	}
+	return nil, "", fmt.Errorf("no repository configured for path %q", path)
+}
+
+// joinURL appends a path to a base URL if it is not empty
+func joinURL(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return base + "/" + path
+}
@@ -0,0 +1,101 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestConverter() *Converter {
+	return &Converter{
+		repos: map[string]*repository{
+			"": {
+				projectURL: "https://gitlab.example.com/group/project",
+				revisions:  RevisionMap{100: "aaa100", 200: "bbb200", 1234: "ccc1234"},
+				branches:   map[string]string{"branches/release-1.0": "release/1.0"},
+			},
+			"tools": {
+				projectURL: "https://gitlab.example.com/group/tools",
+				revisions:  RevisionMap{7: "ddd7"},
+			},
+		},
+		report: NewReport(),
+	}
+}
+
+func TestConvertChangesets(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"revision", "Fixed in r1234.", "Fixed in [r1234](https://gitlab.example.com/group/project/-/commit/ccc1234)."},
+		{"bracketed revision", "See [1234]", "See [r1234](https://gitlab.example.com/group/project/-/commit/ccc1234)"},
+		{"changeset in repository", "changeset:7/tools", "[changeset:7/tools](https://gitlab.example.com/group/tools/-/commit/ddd7)"},
+		{"labelled changeset", "[changeset:100 the fix]", "[the fix](https://gitlab.example.com/group/project/-/commit/aaa100)"},
+		{"revision range", "r200:100", "[r200:100](https://gitlab.example.com/group/project/-/compare/aaa100...bbb200)"},
+		{"log range", "log:trunk@100:200", "[log:trunk@100:200](https://gitlab.example.com/group/project/-/compare/aaa100...bbb200)"},
+		{"log of branch", "log:trunk", "[log:trunk](https://gitlab.example.com/group/project/-/commits/main)"},
+		{"source at revision", "source:trunk/src/foo.c@1234#L10", "[source:trunk/src/foo.c@1234#L10](https://gitlab.example.com/group/project/-/blob/ccc1234/src/foo.c#L10)"},
+		{"source on mapped branch", "source:branches/release-1.0/README", "[source:branches/release-1.0/README](https://gitlab.example.com/group/project/-/blob/release/1.0/README)"},
+		{"source directory", "browser:tags/v2", "[browser:tags/v2](https://gitlab.example.com/group/project/-/tree/v2)"},
+		{"inline code untouched", "`r1234` stays", "`r1234` stays"},
+		{"fenced code untouched", "```\nr1234\n```", "```\nr1234\n```"},
+		{"markdown link untouched", "[1234](https://example.com)", "[1234](https://example.com)"},
+		{"url untouched", "https://example.com/r1234", "https://example.com/r1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestConverter()
+			got := c.convertChangesets(Document{Kind: KindTicket, Name: "1"}, tt.input)
+			if got != tt.want {
+				t.Errorf("convertChangesets(%q) = %q; want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertChangesets_Unresolved(t *testing.T) {
+	c := newTestConverter()
+	input := "See r999 and source:vendor/lib.c"
+
+	got := c.convertChangesets(Document{Kind: KindWiki, Name: "WikiStart"}, input)
+	if got != input {
+		t.Errorf("unresolved references should be left untouched, got %q", got)
+	}
+
+	entries := c.Report().Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 unresolved references, got %d", len(entries))
+	}
+	if entries[0].Reference != "r999" || !strings.Contains(entries[0].Reason, "not found") {
+		t.Errorf("unexpected report entry: %+v", entries[0])
+	}
+	if entries[1].Reference != "source:vendor/lib.c" {
+		t.Errorf("unexpected report entry: %+v", entries[1])
+	}
+}
+
+func TestParseGitSVNLog(t *testing.T) {
+	log := "abc\x00Fix bug\n\ngit-svn-id: https://svn.example.com/repo/trunk@42 0a1b-uuid\n\x1e\n" +
+		"def\x00Unrelated commit\n\x1e\n"
+
+	revisions := parseGitSVNLog(log)
+	if len(revisions) != 1 || revisions[42] != "abc" {
+		t.Errorf("unexpected revisions: %v", revisions)
+	}
+}
+
+func TestParseRevisionMap(t *testing.T) {
+	revisions, err := ParseRevisionMap(strings.NewReader("# comment\nr1 abc\n\n2 def\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if revisions[1] != "abc" || revisions[2] != "def" {
+		t.Errorf("unexpected revisions: %v", revisions)
+	}
+
+	if _, err := ParseRevisionMap(strings.NewReader("broken")); err == nil {
+		t.Error("expected error for invalid entry")
+	}
+}
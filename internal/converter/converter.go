@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/utils"
+)
+
+// Kind identifies the type of Trac resource a text belongs to
+type Kind string
+
+const (
+	KindTicket    Kind = "ticket"
+	KindComment   Kind = "comment"
+	KindWiki      Kind = "wiki"
+	KindMilestone Kind = "milestone"
+)
+
+// Document identifies the text being converted, used for reporting
+type Document struct {
+	Kind Kind
+	Name string
+}
+
+// String returns a human readable representation of the document
+func (d Document) String() string {
+	switch d.Kind {
+	case KindTicket:
+		return "ticket #" + d.Name
+	case KindComment:
+		return "comment " + d.Name
+	default:
+		return fmt.Sprintf("%s %s", d.Kind, d.Name)
+	}
+}
+
+// Converter renders raw Trac wiki markup as GitLab flavoured Markdown
+// and records references and macros it could not convert.
+type Converter struct {
+	repos    map[string]*repository
+	mentions *mentions
+	links    *interLinks
+	rules    []*rule
+	report   *Report
+}
+
+// New creates a Converter from the conversion section of the configuration. The
+// InterMapTxt wiki page is read from export.
+func New(config *config.Config, export fs.FS) (*Converter, error) {
+	c := &Converter{
+		repos:  make(map[string]*repository),
+		report: NewReport(),
+	}
+
+	for _, repoCfg := range config.Conversion.Repositories {
+		repo, err := newRepository(repoCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up repository %q: %w", repoCfg.Name, err)
+		}
+		c.repos[repoCfg.Name] = repo
+	}
+
+	rules, err := compileRules(config.Conversion.Rules)
+	if err != nil {
+		return nil, err
+	}
+	c.rules = rules
+
+	links, err := newInterLinks(config, export)
+	if err != nil {
+		return nil, err
+	}
+	c.links = links
+
+	if config.Conversion.Mentions.Enabled {
+		users, err := config.UserMapping.Load()
+		if err != nil {
+			return nil, err
+		}
+		c.mentions = newMentions(config.Conversion.Mentions, users)
+	}
+
+	return c, nil
+}
+
+// Convert renders the raw Trac markup of a document as Markdown
+func (c *Converter) Convert(doc Document, text string) string {
+	text = c.applyRules(StageBefore, doc, text)
+	text = utils.TracToMarkdown(text)
+	if len(c.repos) > 0 {
+		text = c.convertChangesets(doc, text)
+	}
+	if c.links != nil {
+		text = c.links.convert(c, doc, text)
+	}
+	if c.mentions != nil {
+		text = c.mentions.convert(text)
+	}
+	text = c.applyRules(StageAfter, doc, text)
+	c.reportLeftovers(doc, text)
+	return text
+}
+
+// Report returns the report of unresolved references collected so far
+func (c *Converter) Report() *Report {
+	return c.report
+}
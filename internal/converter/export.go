@@ -0,0 +1,213 @@
+package converter
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/internal/utils"
+)
+
+// ConvertExport renders all raw Trac markup of an existing export as Markdown files in outDir.
+// In a dry run the documents are converted and reported but nothing is written.
+func ConvertExport(c *Converter, exportDir, outDir string, dryRun bool) error {
+	ticketCount, err := convertTickets(c, filepath.Join(exportDir, "tickets"), filepath.Join(outDir, "tickets"), dryRun)
+	if err != nil {
+		return err
+	}
+
+	wikiCount, err := convertWiki(c, filepath.Join(exportDir, "wiki"), filepath.Join(outDir, "wiki"), dryRun)
+	if err != nil {
+		return err
+	}
+
+	milestoneCount, err := convertMilestones(c, filepath.Join(exportDir, "milestones"), filepath.Join(outDir, "milestones"), dryRun)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Conversion completed", "tickets", ticketCount, "wikiVersions", wikiCount, "milestones", milestoneCount)
+	return nil
+}
+
+// RenderTicket renders the description and comments of a ticket as a single Markdown document
+func RenderTicket(c *Converter, ticket *schema.Ticket) string {
+	id := strconv.FormatInt(ticket.ID, 10)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# #%s: %s\n\n", id, ticket.Summary)
+
+	// Tickets exported before the schema was versioned are Markdown already
+	convert := c.Convert
+	if ticket.Markdown {
+		convert = func(_ Document, text string) string { return text }
+	}
+
+	if ticket.Description != "" {
+		b.WriteString(convert(Document{Kind: KindTicket, Name: id}, ticket.Description))
+		b.WriteString("\n")
+	}
+
+	for i, comment := range ticket.Comments {
+		if comment.NewValue == nil || *comment.NewValue == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## Comment %d by %s (%s)\n\n", i+1, comment.Author, comment.Time.Format("2006-01-02 15:04"))
+		b.WriteString(convert(Document{Kind: KindComment, Name: fmt.Sprintf("%s:%d", id, i+1)}, *comment.NewValue))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// convertTickets renders every exported ticket JSON file
+func convertTickets(c *Converter, ticketsDir, outDir string, dryRun bool) (int, error) {
+	files, err := filepath.Glob(filepath.Join(ticketsDir, "ticket-*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tickets: %w", err)
+	}
+	if len(files) == 0 {
+		return 0, nil
+	}
+
+	count := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			slog.Warn("Failed to read ticket", "file", file, "error", err)
+			continue
+		}
+
+		ticket, err := schema.ParseTicket(data)
+		if err != nil {
+			slog.Warn("Failed to decode ticket", "file", file, "error", err)
+			continue
+		}
+
+		outFile := filepath.Join(outDir, fmt.Sprintf("ticket-%d.md", ticket.ID))
+		if err := writeOutput(outFile, RenderTicket(c, ticket), dryRun); err != nil {
+			return count, fmt.Errorf("failed to write converted ticket #%d: %w", ticket.ID, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// convertWiki renders every exported wiki page version
+func convertWiki(c *Converter, wikiDir, outDir string, dryRun bool) (int, error) {
+	if _, err := os.Stat(wikiDir); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	count := 0
+	err := filepath.WalkDir(wikiDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "attachments" && filepath.Dir(path) == wikiDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".wiki") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(wikiDir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("Failed to read wiki page", "file", path, "error", err)
+			return nil
+		}
+
+		pageName, _ := WikiPageFromFile(rel)
+		outFile := filepath.Join(outDir, strings.TrimSuffix(rel, ".wiki")+".md")
+		markdown := c.Convert(Document{Kind: KindWiki, Name: pageName}, string(content))
+		if err := writeOutput(outFile, markdown, dryRun); err != nil {
+			return fmt.Errorf("failed to write converted wiki page %q: %w", pageName, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("failed to convert wiki pages: %w", err)
+	}
+
+	return count, nil
+}
+
+// convertMilestones renders the descriptions of all exported milestones
+func convertMilestones(c *Converter, milestonesDir, outDir string, dryRun bool) (int, error) {
+	files, err := filepath.Glob(filepath.Join(milestonesDir, "milestone-*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list milestones: %w", err)
+	}
+	if len(files) == 0 {
+		return 0, nil
+	}
+
+	count := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			slog.Warn("Failed to read milestone", "file", file, "error", err)
+			continue
+		}
+
+		milestone, err := schema.ParseMilestone(data)
+		if err != nil {
+			slog.Warn("Failed to decode milestone", "file", file, "error", err)
+			continue
+		}
+
+		description := ""
+		if milestone.Description != nil {
+			description = c.Convert(Document{Kind: KindMilestone, Name: milestone.Name}, *milestone.Description)
+		}
+
+		outFile := filepath.Join(outDir, strings.TrimSuffix(filepath.Base(file), ".json")+".md")
+		if err := writeOutput(outFile, fmt.Sprintf("# %s\n\n%s\n", milestone.Name, description), dryRun); err != nil {
+			return count, fmt.Errorf("failed to write converted milestone %q: %w", milestone.Name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// writeOutput writes a converted document, creating its directory if needed
+func writeOutput(path, content string, dryRun bool) error {
+	if dryRun {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, []byte(content))
+}
+
+// WikiPageFromFile returns the page name and version encoded in an exported
+// wiki file path relative to the wiki directory, e.g. "Sub/Page.v3.wiki"
+func WikiPageFromFile(rel string) (string, int64) {
+	name := filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+	idx := strings.LastIndex(name, ".v")
+	if idx < 0 {
+		return name, 0
+	}
+	version, err := strconv.ParseInt(name[idx+2:], 10, 64)
+	if err != nil {
+		return name, 0
+	}
+	return name[:idx], version
+}
@@ -0,0 +1,38 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+)
+
+func TestRenderTicket_Markdown(t *testing.T) {
+	c, err := New(&config.Config{}, fstest.MapFS{})
+	if err != nil {
+		t.Fatalf("failed to create converter: %v", err)
+	}
+
+	comment := "*Confirmed*, `x ^ y ^ z`"
+	ticket := &schema.Ticket{
+		ID:          7,
+		Summary:     "Overflow",
+		Description: "Parse `a ^ b ^ c`",
+		Comments:    []schema.Change{{Field: "comment", NewValue: &comment}},
+	}
+
+	raw := RenderTicket(c, ticket)
+	if !strings.Contains(raw, "<sup>") {
+		t.Fatalf("expected raw Trac markup to be converted, got %q", raw)
+	}
+
+	ticket.Markdown = true
+	rendered := RenderTicket(c, ticket)
+	for _, want := range []string{"Parse `a ^ b ^ c`", "*Confirmed*, `x ^ y ^ z`"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("expected %q to be kept as exported, got %q", want, rendered)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+)
+
+// interTrac describes another Trac environment reachable through an InterTrac prefix
+type interTrac struct {
+	url           string
+	gitlabProject string
+}
+
+// interLinks resolves InterTrac and InterWiki links
+type interLinks struct {
+	gitlabURL string
+	trac      map[string]interTrac
+	wiki      map[string]string
+	pattern   *regexp.Regexp
+}
+
+// newInterLinks builds the InterTrac and InterWiki maps from the configuration and,
+// if enabled, from the InterMapTxt wiki page of the export
+func newInterLinks(config *config.Config, export fs.FS) (*interLinks, error) {
+	l := &interLinks{
+		gitlabURL: strings.TrimSuffix(config.GitLab.BaseURL, "/"),
+		trac:      make(map[string]interTrac),
+		wiki:      make(map[string]string),
+	}
+
+	if config.Conversion.LoadInterMap {
+		interMap, err := loadInterMap(export)
+		if err != nil {
+			return nil, err
+		}
+		if interMap == nil {
+			slog.Warn("InterMapTxt wiki page not found in export, only the configured InterWiki prefixes are resolved")
+		}
+		for prefix, target := range interMap {
+			l.wiki[strings.ToLower(prefix)] = target
+		}
+	}
+
+	for prefix, target := range config.Conversion.InterWiki {
+		l.wiki[strings.ToLower(prefix)] = target
+	}
+
+	for _, tracCfg := range config.Conversion.InterTrac {
+		if tracCfg.Prefix == "" {
+			return nil, fmt.Errorf("intertrac entry without prefix")
+		}
+		if tracCfg.URL == "" && tracCfg.GitLabProject == "" {
+			return nil, fmt.Errorf("intertrac prefix %q needs a url or gitlab_project", tracCfg.Prefix)
+		}
+		l.trac[strings.ToLower(tracCfg.Prefix)] = interTrac{
+			url:           strings.TrimSuffix(tracCfg.URL, "/"),
+			gitlabProject: strings.Trim(tracCfg.GitLabProject, "/"),
+		}
+	}
+
+	prefixes := make([]string, 0, len(l.trac)+len(l.wiki))
+	for prefix := range l.trac {
+		prefixes = append(prefixes, regexp.QuoteMeta(prefix))
+	}
+	for prefix := range l.wiki {
+		if _, ok := l.trac[prefix]; !ok {
+			prefixes = append(prefixes, regexp.QuoteMeta(prefix))
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, nil
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	alternatives := strings.Join(prefixes, "|")
+	l.pattern = regexp.MustCompile(
+		`(?i)\[(` + alternatives + `):([^\s\]]+)(?:\s+([^\]]+))?\]` +
+			`|\b(` + alternatives + `):([^\s\[\]()<>,;"']+)`,
+	)
+
+	return l, nil
+}
+
+// convert rewrites InterTrac and InterWiki links in Markdown prose. Code and existing
+// Markdown links are kept.
+func (l *interLinks) convert(c *Converter, doc Document, text string) string {
+	return replaceOutsideCode(text, func(prose string) string {
+		return replaceOutsideLinks(prose, func(prose string) string {
+			return l.convertProse(c, doc, prose)
+		})
+	})
+}
+
+// convertProse rewrites the InterTrac and InterWiki links in prose without code and links
+func (l *interLinks) convertProse(c *Converter, doc Document, prose string) string {
+	var out strings.Builder
+	last := 0
+
+	for _, m := range l.pattern.FindAllStringSubmatchIndex(prose, -1) {
+		start, end := m[0], m[1]
+		if insideURL(prose, start) {
+			continue
+		}
+
+		group := func(i int) string {
+			if m[2*i] < 0 {
+				return ""
+			}
+			return prose[m[2*i]:m[2*i+1]]
+		}
+
+		var prefix, target, label string
+		if group(1) != "" {
+			prefix, target, label = group(1), group(2), group(3)
+		} else {
+			prefix = group(4)
+			target = strings.TrimRight(group(5), ".,!?:")
+			end -= len(group(5)) - len(target)
+		}
+		if target == "" {
+			continue
+		}
+
+		replacement, err := l.resolve(strings.ToLower(prefix), target, label, prose[start:end])
+		if err != nil {
+			c.report.Add(doc, prose[start:end], err.Error())
+			continue
+		}
+
+		out.WriteString(prose[last:start])
+		out.WriteString(replacement)
+		last = end
+	}
+
+	out.WriteString(prose[last:])
+	return out.String()
+}
+
+// resolve returns the Markdown for an InterTrac or InterWiki link
+func (l *interLinks) resolve(prefix, target, label, original string) (string, error) {
+	if env, ok := l.trac[prefix]; ok {
+		return l.resolveInterTrac(env, target, label, original)
+	}
+
+	template, ok := l.wiki[prefix]
+	if !ok {
+		return "", fmt.Errorf("unknown prefix %q", prefix)
+	}
+	if label == "" {
+		label = original
+	}
+	return fmt.Sprintf("[%s](%s)", label, expandInterWiki(template, target)), nil
+}
+
+// resolveInterTrac resolves a link into another Trac environment, preferring
+// GitLab references if the environment was migrated to a GitLab project
+func (l *interLinks) resolveInterTrac(env interTrac, target, label, original string) (string, error) {
+	kind, id := splitTracLink(target)
+
+	if env.gitlabProject != "" {
+		switch kind {
+		case "ticket":
+			return labelReference(env.gitlabProject+"#"+id, label), nil
+		case "milestone":
+			return labelReference(env.gitlabProject+`%"`+id+`"`, label), nil
+		case "wiki":
+			if l.gitlabURL != "" {
+				if label == "" {
+					label = original
+				}
+				return fmt.Sprintf("[%s](%s/%s/-/wikis/%s)", label, l.gitlabURL, env.gitlabProject, id), nil
+			}
+		}
+	}
+
+	if env.url == "" {
+		return "", fmt.Errorf("%s link cannot be resolved in GitLab project %s", kind, env.gitlabProject)
+	}
+
+	if label == "" {
+		label = original
+	}
+	var link string
+	switch kind {
+	case "ticket":
+		link = env.url + "/ticket/" + id
+	case "wiki":
+		link = env.url + "/wiki/" + id
+	case "milestone":
+		link = env.url + "/milestone/" + url.PathEscape(id)
+	default:
+		link = env.url + "/intertrac/" + url.PathEscape(target)
+	}
+	return fmt.Sprintf("[%s](%s)", label, link), nil
+}
+
+// labelReference keeps the label of a link next to a GitLab reference,
+// which GitLab renders as a link on its own
+func labelReference(reference, label string) string {
+	if label == "" {
+		return reference
+	}
+	return label + " (" + reference + ")"
+}
+
+// splitTracLink splits an InterTrac target like "#45", "ticket:45" or "wiki:Page"
+// into its link type and identifier
+func splitTracLink(target string) (string, string) {
+	if strings.HasPrefix(target, "#") {
+		return "ticket", target[1:]
+	}
+	kind, id, found := strings.Cut(target, ":")
+	if !found {
+		return "", target
+	}
+	return kind, id
+}
+
+// expandInterWiki fills the $1..$9 placeholders of an InterWiki URL with the
+// colon separated arguments of the target, or appends the target if there are none
+func expandInterWiki(template, target string) string {
+	if !strings.Contains(template, "$") {
+		return template + target
+	}
+
+	args := strings.Split(target, ":")
+	for i := 9; i >= 1; i-- {
+		value := ""
+		if i <= len(args) {
+			value = args[i-1]
+		}
+		template = strings.ReplaceAll(template, "$"+strconv.Itoa(i), value)
+	}
+	return template
+}
+
+// loadInterMap parses the latest exported version of the InterMapTxt wiki page.
+// Entries are "PREFIX URL # comment" lines inside the {{{ }}} block. The map is nil if
+// the export has no InterMapTxt page.
+func loadInterMap(export fs.FS) (map[string]string, error) {
+	files, err := fs.Glob(export, "wiki/InterMapTxt.v*.wiki")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list InterMapTxt versions: %w", err)
+	}
+
+	latestFile := ""
+	var latestVersion int64
+	for _, file := range files {
+		if _, version := WikiPageFromFile(path.Base(file)); version > latestVersion {
+			latestFile, latestVersion = file, version
+		}
+	}
+	if latestFile == "" {
+		return nil, nil
+	}
+
+	file, err := export.Open(latestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open InterMapTxt: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	interMap := make(map[string]string)
+	inBlock := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "{{{":
+			inBlock = true
+			continue
+		case line == "}}}":
+			inBlock = false
+			continue
+		case !inBlock || line == "" || strings.HasPrefix(line, "#"):
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			interMap[fields[0]] = fields[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read InterMapTxt: %w", err)
+	}
+
+	return interMap, nil
+}
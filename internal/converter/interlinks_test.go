@@ -0,0 +1,77 @@
+package converter
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+)
+
+func TestInterLinksConvert(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.GitLab.BaseURL = "https://gitlab.example.com"
+	cfg.Conversion.LoadInterMap = true
+	cfg.Conversion.InterWiki = map[string]string{"Bug": "https://bugs.example.com/show?id=$1"}
+	cfg.Conversion.InterTrac = []config.InterTracConfig{
+		{Prefix: "otherproject", URL: "https://trac.example.com/other", GitLabProject: "group/other"},
+		{Prefix: "trac", URL: "https://trac.edgewall.org"},
+	}
+
+	// The export is read through fs.FS, so InterMapTxt is found in archives as well
+	interMap := "= InterMapTxt =\n{{{\nRFC  https://tools.ietf.org/html/rfc$1  # IETF RFCs\nPython https://docs.python.org/\n}}}\n"
+	export := fstest.MapFS{
+		"wiki/InterMapTxt.v1.wiki": {Data: []byte("= InterMapTxt =\n{{{\nRFC https://example.com/outdated/$1\n}}}\n")},
+		"wiki/InterMapTxt.v2.wiki": {Data: []byte(interMap)},
+	}
+
+	links, err := newInterLinks(cfg, export)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"see RFC:2616.", "see [RFC:2616](https://tools.ietf.org/html/rfc2616)."},
+		{"[Bug:123 the bug]", "[the bug](https://bugs.example.com/show?id=123)"},
+		{"python:library", "[python:library](https://docs.python.org/library)"},
+		{"otherproject:#45", "group/other#45"},
+		{"[otherproject:ticket:45 that ticket]", "that ticket (group/other#45)"},
+		{"otherproject:wiki:Setup", "[otherproject:wiki:Setup](https://gitlab.example.com/group/other/-/wikis/Setup)"},
+		{"otherproject:report:1", "[otherproject:report:1](https://trac.example.com/other/intertrac/report:1)"},
+		{"trac:wiki:TracLinks", "[trac:wiki:TracLinks](https://trac.edgewall.org/wiki/TracLinks)"},
+		{"`RFC:2616`", "`RFC:2616`"},
+		{"https://example.com/RFC:2616", "https://example.com/RFC:2616"},
+		{"[RFC:2616](http://x)", "[RFC:2616](http://x)"},
+		{"see [the python:library docs](http://x) and RFC:1", "see [the python:library docs](http://x) and [RFC:1](https://tools.ietf.org/html/rfc1)"},
+	}
+
+	for _, tt := range tests {
+		c := &Converter{report: NewReport()}
+		got := links.convert(c, Document{Kind: KindWiki, Name: "WikiStart"}, tt.input)
+		if got != tt.want {
+			t.Errorf("convert(%q) = %q; want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestInterLinksMissingInterMap(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Conversion.LoadInterMap = true
+	cfg.Conversion.InterWiki = map[string]string{"Bug": "https://bugs.example.com/show?id=$1"}
+
+	links, err := newInterLinks(cfg, fstest.MapFS{})
+	if err != nil {
+		t.Fatalf("expected a missing InterMapTxt to be skipped, got %v", err)
+	}
+	c := &Converter{report: NewReport()}
+	if got := links.convert(c, Document{Kind: KindWiki, Name: "WikiStart"}, "Bug:1"); got != "[Bug:1](https://bugs.example.com/show?id=1)" {
+		t.Errorf("configured prefix not resolved: %q", got)
+	}
+
+	cfg.Conversion.InterWiki = nil
+	if _, err := New(cfg, fstest.MapFS{}); err != nil {
+		t.Errorf("New failed without InterMapTxt and prefixes: %v", err)
+	}
+}
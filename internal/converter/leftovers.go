@@ -0,0 +1,34 @@
+package converter
+
+import "regexp"
+
+var (
+	// macroPattern matches Trac macro calls like [[TOC]] or [[Image(logo.png)]]
+	macroPattern = regexp.MustCompile(`\[\[([A-Za-z]\w*)(?:\(([^)]*)\))?\]\]`)
+
+	// markdownLinkPattern matches Markdown links, whose text may contain converted TracLinks
+	markdownLinkPattern = regexp.MustCompile(`\[[^\]]*\]\([^)]*\)`)
+
+	// tracLinkPattern matches TracLinks that have no Markdown equivalent yet
+	tracLinkPattern = regexp.MustCompile(
+		`\[(?:wiki|ticket|milestone|report|attachment|query|comment|search|timeline|roadmap|htdocs):[^\]]*\]` +
+			`|\b(?:wiki|ticket|milestone|report|attachment|query):[^\s\[\]()<>,;]+`,
+	)
+)
+
+// reportLeftovers records macros and TracLinks that survived all conversion passes
+func (c *Converter) reportLeftovers(doc Document, text string) {
+	replaceOutsideCode(text, func(prose string) string {
+		prose = markdownLinkPattern.ReplaceAllString(prose, "")
+		for _, m := range macroPattern.FindAllStringSubmatch(prose, -1) {
+			c.report.Add(doc, m[0], "unsupported macro "+m[1])
+		}
+		for _, idx := range tracLinkPattern.FindAllStringIndex(prose, -1) {
+			if insideURL(prose, idx[0]) {
+				continue
+			}
+			c.report.Add(doc, prose[idx[0]:idx[1]], "unconverted link")
+		}
+		return prose
+	})
+}
@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// replaceOutsideCode applies fn to every part of a Markdown text that is not
+// inside a fenced code block or an inline code span
+func replaceOutsideCode(text string, fn func(string) string) string {
+	var out, prose strings.Builder
+	inFence := false
+
+	flush := func() {
+		out.WriteString(replaceOutsideInlineCode(prose.String(), fn))
+		prose.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if !inFence {
+				flush()
+			}
+			inFence = !inFence
+			out.WriteString(line)
+			continue
+		}
+		if inFence {
+			out.WriteString(line)
+		} else {
+			prose.WriteString(line)
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+// replaceOutsideInlineCode applies fn to the parts of text outside `code` spans
+func replaceOutsideInlineCode(text string, fn func(string) string) string {
+	var out strings.Builder
+	for {
+		start := strings.IndexByte(text, '`')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start+1:], '`')
+		if end < 0 {
+			break
+		}
+		end += start + 2
+
+		out.WriteString(fn(text[:start]))
+		out.WriteString(text[start:end])
+		text = text[end:]
+	}
+	out.WriteString(fn(text))
+
+	return out.String()
+}
+
+// markdownLink matches an inline Markdown link or image, "[label](target)"
+var markdownLink = regexp.MustCompile(`!?\[[^\]\n]*\]\([^)\n]*\)`)
+
+// replaceOutsideLinks applies fn to the parts of text outside inline Markdown links, so
+// neither their labels nor their targets are rewritten
+func replaceOutsideLinks(text string, fn func(string) string) string {
+	var out strings.Builder
+	last := 0
+	for _, idx := range markdownLink.FindAllStringIndex(text, -1) {
+		out.WriteString(fn(text[last:idx[0]]))
+		out.WriteString(text[idx[0]:idx[1]])
+		last = idx[1]
+	}
+	out.WriteString(fn(text[last:]))
+	return out.String()
+}
+
+// insideURL reports whether the position pos in text belongs to a bare URL
+// or the target of a Markdown link
+func insideURL(text string, pos int) bool {
+	tokenStart := strings.LastIndexAny(text[:pos], " \t\n") + 1
+	token := text[tokenStart:pos]
+	return strings.Contains(token, "://") || strings.Contains(token, "](")
+}
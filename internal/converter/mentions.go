@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+)
+
+var (
+	emailPattern   = regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)
+	mentionPattern = regexp.MustCompile(`@[\w.-]*\w`)
+)
+
+// mentionCues are the words tickets put in front of usernames, "assigned to jdoe" or
+// "cc: alice, bob". Usernames elsewhere in prose may be ordinary words and are kept.
+const mentionCues = `(?:re)?assigned to|reported by|by|cc|owner|reporter`
+
+// mentions turns Trac usernames and email addresses into GitLab mentions
+type mentions struct {
+	users map[string]string
+	// identities matches a known Trac username, cued matches a cue and the list of
+	// usernames following it
+	identities       *regexp.Regexp
+	cued             *regexp.Regexp
+	notificationSafe bool
+	obfuscateEmails  bool
+}
+
+// newMentions builds the mentions pass from the user mapping
+func newMentions(mentionsCfg config.MentionsConfig, users map[string]string) *mentions {
+	m := &mentions{
+		users:            make(map[string]string, len(users)),
+		notificationSafe: mentionsCfg.NotificationSafe,
+		obfuscateEmails:  mentionsCfg.ObfuscateEmails,
+	}
+
+	names := make([]string, 0, len(users))
+	for tracUser, gitlabUser := range users {
+		if tracUser == "" || gitlabUser == "" {
+			continue
+		}
+		m.users[strings.ToLower(tracUser)] = gitlabUser
+		names = append(names, regexp.QuoteMeta(tracUser))
+	}
+
+	if len(names) > 0 {
+		// Longest names first, so "jdoe2" is not matched as "jdoe"
+		sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+		name := `(?:` + strings.Join(names, "|") + `)`
+		m.identities = regexp.MustCompile(`(?i)\b` + name + `\b`)
+		m.cued = regexp.MustCompile(`(?i)(\b(?:` + mentionCues + `)\b:?\s+)(` + name + `(?:\s*(?:,|\band\b)\s*` + name + `)*)\b`)
+	}
+
+	return m
+}
+
+// convert rewrites explicit mentions, email addresses and usernames following a cue in
+// Markdown prose. Code and the labels and targets of links are kept.
+func (m *mentions) convert(text string) string {
+	return replaceOutsideCode(text, func(prose string) string {
+		return replaceOutsideLinks(prose, m.convertProse)
+	})
+}
+
+// convertProse rewrites the mentions in prose without code and links
+func (m *mentions) convertProse(prose string) string {
+	// Explicit mentions first, the mentions made of usernames and emails are final
+	prose = replaceOutsideURLs(prose, mentionPattern, func(mention string) string {
+		if gitlabUser, ok := m.users[strings.ToLower(mention[1:])]; ok {
+			return m.mention(gitlabUser)
+		}
+		if m.notificationSafe {
+			return "`" + mention + "`"
+		}
+		return mention
+	})
+
+	prose = replaceOutsideURLs(prose, emailPattern, func(email string) string {
+		if gitlabUser, ok := m.users[strings.ToLower(email)]; ok {
+			return m.mention(gitlabUser)
+		}
+		if m.obfuscateEmails {
+			return obfuscateEmail(email)
+		}
+		return email
+	})
+
+	if m.cued != nil {
+		prose = replaceOutsideURLs(prose, m.cued, func(match string) string {
+			parts := m.cued.FindStringSubmatch(match)
+			return parts[1] + m.identities.ReplaceAllStringFunc(parts[2], func(name string) string {
+				return m.mention(m.users[strings.ToLower(name)])
+			})
+		})
+	}
+
+	return prose
+}
+
+// mention formats a mention, in notification safe mode it is wrapped in a code span
+func (m *mentions) mention(gitlabUser string) string {
+	if m.notificationSafe {
+		return "`@" + gitlabUser + "`"
+	}
+	return "@" + gitlabUser
+}
+
+// obfuscateEmail makes an email address unusable for harvesting and mailto links
+func obfuscateEmail(email string) string {
+	local, domain, _ := strings.Cut(email, "@")
+	return local + " [at] " + strings.ReplaceAll(domain, ".", " [dot] ")
+}
+
+// replaceOutsideURLs replaces matches of pattern that are not part of a URL,
+// an existing mention, an email address or a code span. Matches starting with "@"
+// are only mentions after a non-word character.
+func replaceOutsideURLs(text string, pattern *regexp.Regexp, fn func(string) string) string {
+	var out strings.Builder
+	last := 0
+	for _, idx := range pattern.FindAllStringIndex(text, -1) {
+		start, end := idx[0], idx[1]
+		if insideURL(text, start) {
+			continue
+		}
+		if start > 0 && strings.ContainsRune("@`./\\", rune(text[start-1])) {
+			continue
+		}
+		if start > 0 && text[start] == '@' && isWordByte(text[start-1]) {
+			continue
+		}
+		if end < len(text) && strings.ContainsRune("@`", rune(text[end])) {
+			continue
+		}
+		out.WriteString(text[last:start])
+		out.WriteString(fn(text[start:end]))
+		last = end
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// isWordByte reports whether b is an ASCII letter, digit or underscore
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
@@ -0,0 +1,47 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+)
+
+func TestMentionsConvert(t *testing.T) {
+	users := map[string]string{
+		"jdoe":              "john.doe",
+		"bob2":              "bob",
+		"will":              "william",
+		"alice@example.com": "alice",
+	}
+
+	tests := []struct {
+		name  string
+		cfg   config.MentionsConfig
+		input string
+		want  string
+	}{
+		{"username", config.MentionsConfig{}, "assigned to jdoe", "assigned to @john.doe"},
+		{"cc list", config.MentionsConfig{}, "cc: jdoe, bob2 and will", "cc: @john.doe, @bob and @william"},
+		{"explicit mention", config.MentionsConfig{}, "thanks @jdoe!", "thanks @john.doe!"},
+		{"username in prose untouched", config.MentionsConfig{}, "jdoe says I will look", "jdoe says I will look"},
+		{"ordinary word after cue", config.MentionsConfig{}, "fixed by will", "fixed by @william"},
+		{"email", config.MentionsConfig{}, "cc: alice@example.com", "cc: @alice"},
+		{"unknown email kept", config.MentionsConfig{}, "mail bob@example.org", "mail bob@example.org"},
+		{"unknown email obfuscated", config.MentionsConfig{ObfuscateEmails: true}, "mail bob@example.org", "mail bob [at] example [dot] org"},
+		{"notification safe", config.MentionsConfig{NotificationSafe: true}, "cc: jdoe and @someone", "cc: `@john.doe` and `@someone`"},
+		{"source link untouched", config.MentionsConfig{NotificationSafe: true}, "see [source:trunk/foo.c@1234#L10](https://gitlab.example.com/p/-/blob/abc/foo.c#L10)", "see [source:trunk/foo.c@1234#L10](https://gitlab.example.com/p/-/blob/abc/foo.c#L10)"},
+		{"link label untouched", config.MentionsConfig{}, "assigned to [jdoe](https://example.com/~jdoe) by jdoe", "assigned to [jdoe](https://example.com/~jdoe) by @john.doe"},
+		{"partial word untouched", config.MentionsConfig{}, "jdoe2 and xjdoe", "jdoe2 and xjdoe"},
+		{"code untouched", config.MentionsConfig{}, "`jdoe`", "`jdoe`"},
+		{"url untouched", config.MentionsConfig{}, "https://example.com/jdoe", "https://example.com/jdoe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newMentions(tt.cfg, users).convert(tt.input)
+			if got != tt.want {
+				t.Errorf("convert(%q) = %q; want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
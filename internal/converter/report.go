@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Unresolved describes a reference that could not be converted
+type Unresolved struct {
+	Document  Document
+	Reference string
+	Reason    string
+}
+
+// RuleHit records how often a rewrite rule matched in a document
+type RuleHit struct {
+	Document Document
+	Rule     string
+	Matches  int
+}
+
+// Report collects unresolved references and rewrite rule hits, it is safe for concurrent use
+type Report struct {
+	entries  []Unresolved
+	ruleHits []RuleHit
+	mu       sync.Mutex
+}
+
+// NewReport initializes an empty Report
+func NewReport() *Report {
+	return &Report{}
+}
+
+// Add records an unresolved reference for a document
+func (r *Report) Add(doc Document, reference, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, Unresolved{Document: doc, Reference: reference, Reason: reason})
+}
+
+// AddRuleHit records that a rewrite rule matched in a document
+func (r *Report) AddRuleHit(doc Document, rule string, matches int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ruleHits = append(r.ruleHits, RuleHit{Document: doc, Rule: rule, Matches: matches})
+}
+
+// RuleHits returns the recorded rule hits in the order they happened
+func (r *Report) RuleHits() []RuleHit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hits := make([]RuleHit, len(r.ruleHits))
+	copy(hits, r.ruleHits)
+	return hits
+}
+
+// Entries returns the recorded references sorted by document and reference
+func (r *Report) Entries() []Unresolved {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]Unresolved, len(r.entries))
+	copy(entries, r.entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Document.String() != entries[j].Document.String() {
+			return entries[i].Document.String() < entries[j].Document.String()
+		}
+		return entries[i].Reference < entries[j].Reference
+	})
+	return entries
+}
+
+// WriteFile writes the report as tab separated lines to the given path, creating
+// its directory if needed
+func (r *Report) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+
+	for _, entry := range r.Entries() {
+		if _, err := fmt.Fprintf(file, "%s\t%s\t%s\n", entry.Document, entry.Reference, entry.Reason); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("failed to write report entry: %w", err)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close report file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RevisionMap maps SVN revision numbers to git commit SHAs
+type RevisionMap map[int64]string
+
+// gitSVNIDPattern matches the trailer git-svn adds to every imported commit,
+// e.g. "git-svn-id: https://svn.example.com/repo/trunk@1234 <uuid>"
+var gitSVNIDPattern = regexp.MustCompile(`(?m)^git-svn-id: \S+@(\d+) `)
+
+// LoadRevisionMap reads a revision map file with one "<revision> <sha>" pair per line.
+// Empty lines and lines starting with '#' are ignored.
+func LoadRevisionMap(path string) (RevisionMap, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open revision map: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return ParseRevisionMap(file)
+}
+
+// ParseRevisionMap parses revision map lines from the given reader
+func ParseRevisionMap(r io.Reader) (RevisionMap, error) {
+	revisions := make(RevisionMap)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid revision map entry on line %d: %q", lineNo, line)
+		}
+
+		rev, err := strconv.ParseInt(strings.TrimPrefix(fields[0], "r"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid revision on line %d: %w", lineNo, err)
+		}
+		revisions[rev] = fields[1]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read revision map: %w", err)
+	}
+	return revisions, nil
+}
+
+// ScanGitSVN builds a revision map from the git-svn-id trailers of all commits in a local git-svn clone
+func ScanGitSVN(clonePath string) (RevisionMap, error) {
+	cmd := exec.Command("git", "-C", clonePath, "log", "--all", "--format=%H%x00%B%x1e")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git log of %q: %w", clonePath, err)
+	}
+
+	return parseGitSVNLog(string(out)), nil
+}
+
+// parseGitSVNLog extracts revisions from "<sha>\x00<message>\x1e" records
+func parseGitSVNLog(log string) RevisionMap {
+	revisions := make(RevisionMap)
+	for _, record := range strings.Split(log, "\x1e") {
+		sha, message, ok := strings.Cut(strings.TrimSpace(record), "\x00")
+		if !ok {
+			continue
+		}
+
+		match := gitSVNIDPattern.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+
+		rev, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, exists := revisions[rev]; !exists {
+			revisions[rev] = sha
+		}
+	}
+	return revisions
+}
@@ -0,0 +1,98 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+)
+
+// Rule stages
+const (
+	StageBefore = "before"
+	StageAfter  = "after"
+)
+
+// rule is a compiled user defined rewrite rule
+type rule struct {
+	name    string
+	stage   string
+	scopes  []Kind
+	pattern *regexp.Regexp
+	replace string
+	literal bool
+}
+
+// compileRules validates and compiles the configured rewrite rules in order
+func compileRules(rulesCfg []config.RewriteRule) ([]*rule, error) {
+	rules := make([]*rule, 0, len(rulesCfg))
+
+	for i, ruleCfg := range rulesCfg {
+		r := &rule{
+			name:    ruleCfg.Name,
+			stage:   ruleCfg.Stage,
+			replace: ruleCfg.Replace,
+			literal: ruleCfg.Literal,
+		}
+		if r.name == "" {
+			r.name = fmt.Sprintf("rule-%d", i+1)
+		}
+
+		switch r.stage {
+		case "":
+			r.stage = StageBefore
+		case StageBefore, StageAfter:
+		default:
+			return nil, fmt.Errorf("rule %q: unknown stage %q (expected %q or %q)", r.name, r.stage, StageBefore, StageAfter)
+		}
+
+		for _, scope := range ruleCfg.Scopes {
+			kind := Kind(scope)
+			if !slices.Contains([]Kind{KindTicket, KindComment, KindWiki, KindMilestone}, kind) {
+				return nil, fmt.Errorf("rule %q: unknown scope %q", r.name, scope)
+			}
+			r.scopes = append(r.scopes, kind)
+		}
+
+		if ruleCfg.Pattern == "" {
+			return nil, fmt.Errorf("rule %q: pattern is required", r.name)
+		}
+
+		expr := ruleCfg.Pattern
+		if r.literal {
+			expr = regexp.QuoteMeta(expr)
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: invalid pattern: %w", r.name, err)
+		}
+		r.pattern = pattern
+
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// applyRules runs all rules of a stage that are in scope for the document
+func (c *Converter) applyRules(stage string, doc Document, text string) string {
+	for _, r := range c.rules {
+		if r.stage != stage || (len(r.scopes) > 0 && !slices.Contains(r.scopes, doc.Kind)) {
+			continue
+		}
+
+		matches := len(r.pattern.FindAllStringIndex(text, -1))
+		if matches == 0 {
+			continue
+		}
+
+		if r.literal {
+			text = r.pattern.ReplaceAllLiteralString(text, r.replace)
+		} else {
+			text = r.pattern.ReplaceAllString(text, r.replace)
+		}
+		c.report.AddRuleHit(doc, r.name, matches)
+	}
+	return text
+}
@@ -0,0 +1,50 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+)
+
+func TestApplyRules(t *testing.T) {
+	rules, err := compileRules([]config.RewriteRule{
+		{Name: "codes", Scopes: []string{"ticket"}, Pattern: `PRD-(\d+)`, Replace: "[PRD-$1](https://jira.example.com/PRD-$1)"},
+		{Name: "toc", Stage: StageAfter, Literal: true, Pattern: "[[TOC]]", Replace: "[[_TOC_]]"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := &Converter{rules: rules, report: NewReport()}
+
+	ticket := Document{Kind: KindTicket, Name: "1"}
+	if got := c.applyRules(StageBefore, ticket, "see PRD-7"); got != "see [PRD-7](https://jira.example.com/PRD-7)" {
+		t.Errorf("unexpected ticket result: %q", got)
+	}
+
+	wiki := Document{Kind: KindWiki, Name: "WikiStart"}
+	if got := c.applyRules(StageBefore, wiki, "see PRD-7"); got != "see PRD-7" {
+		t.Errorf("rule applied outside its scope: %q", got)
+	}
+	if got := c.applyRules(StageAfter, wiki, "[[TOC]]"); got != "[[_TOC_]]" {
+		t.Errorf("unexpected wiki result: %q", got)
+	}
+
+	hits := c.Report().RuleHits()
+	if len(hits) != 2 || hits[0].Rule != "codes" || hits[1].Rule != "toc" || hits[1].Document != wiki {
+		t.Errorf("unexpected rule hits: %+v", hits)
+	}
+}
+
+func TestCompileRules_Invalid(t *testing.T) {
+	invalid := [][]config.RewriteRule{
+		{{Pattern: "("}},
+		{{Pattern: "x", Stage: "during"}},
+		{{Pattern: "x", Scopes: []string{"changeset"}}},
+		{{Name: "empty"}},
+	}
+	for _, rulesCfg := range invalid {
+		if _, err := compileRules(rulesCfg); err == nil {
+			t.Errorf("expected error for %+v", rulesCfg)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package exportdb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// Tables counted by Build
+const (
+	CountTickets      = "tickets"
+	CountChanges      = "ticket_changes"
+	CountComments     = "ticket_comments"
+	CountAttachments  = "attachments"
+	CountMilestones   = "milestones"
+	CountWikiVersions = "wiki_versions"
+	CountUsers        = "users"
+)
+
+// wikiVersionFile matches the file of a wiki page version, e.g. "Sub/Page.v3.wiki"
+var wikiVersionFile = regexp.MustCompile(`^(.+)\.v(\d+)\.wiki$`)
+
+// Build writes the export in fsys into a new SQLite database at path, replacing an
+// existing one once complete. It returns the number of rows written per table.
+func Build(ctx context.Context, fsys fs.FS, dbPath string) (map[string]int, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(dbPath), "."+filepath.Base(dbPath)+".tmp-build")
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale database: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmpPath)
+	}()
+
+	db, err := sql.Open("sqlite", "file:"+tmpPath+"?_pragma=foreign_keys(1)&_pragma=journal_mode(off)&_pragma=synchronous(off)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export database: %w", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	if _, err := db.ExecContext(ctx, schemaSQL); err != nil {
+		return nil, fmt.Errorf("failed to create database schema: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	b := &builder{ctx: ctx, tx: tx, fsys: fsys, counts: make(map[string]int)}
+	steps := []func() error{b.tickets, b.milestones, b.wiki, b.wikiAttachments, b.users, b.meta}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit export database: %w", err)
+	}
+	if err := db.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close export database: %w", err)
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		return nil, fmt.Errorf("failed to move export database into place: %w", err)
+	}
+
+	return b.counts, nil
+}
+
+// builder fills the tables of an export database inside a single transaction
+type builder struct {
+	ctx    context.Context
+	tx     *sql.Tx
+	fsys   fs.FS
+	counts map[string]int
+}
+
+// exec runs a statement and counts the row for table
+func (b *builder) exec(table, query string, args ...any) error {
+	if _, err := b.tx.ExecContext(b.ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert into %s: %w", table, err)
+	}
+	b.counts[table]++
+	return nil
+}
+
+// jsonFiles returns the JSON files directly inside dir, a missing directory has none
+func (b *builder) jsonFiles(dir string) ([]string, error) {
+	entries, err := fs.ReadDir(b.fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, path.Join(dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+func (b *builder) tickets() error {
+	files, err := b.jsonFiles("tickets")
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(b.fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		ticket, err := schema.ParseTicket(data)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+		if err := b.ticket(ticket); err != nil {
+			return fmt.Errorf("failed to store ticket #%d: %w", ticket.ID, err)
+		}
+	}
+	return nil
+}
+
+func (b *builder) ticket(t *schema.Ticket) error {
+	err := b.exec(CountTickets, `INSERT INTO tickets (id, schema_version, created, changed, summary, description, reporter, owner, type, status,
+		resolution, priority, severity, component, version, milestone, keywords, cc, markdown) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.SchemaVersion, t.Created.UTC().Format(time.RFC3339Nano), t.Changed.UTC().Format(time.RFC3339Nano), t.Summary, t.Description, t.Reporter,
+		t.Owner, t.Type, t.Status, t.Resolution, t.Priority, t.Severity, t.Component, t.Version, t.Milestone, t.Keywords, t.CC, t.Markdown)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range t.CustomFields {
+		if _, err := b.tx.ExecContext(b.ctx, `INSERT INTO ticket_custom (ticket, name, value) VALUES (?, ?, ?)`, t.ID, name, value); err != nil {
+			return fmt.Errorf("failed to insert into ticket_custom: %w", err)
+		}
+	}
+
+	for table, changes := range map[string][]schema.Change{CountChanges: t.History, CountComments: t.Comments} {
+		for seq, change := range changes {
+			err := b.exec(table, `INSERT INTO `+table+` (ticket, seq, time, author, field, old_value, new_value, permanent)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				t.ID, seq, change.Time.UTC().Format(time.RFC3339Nano), change.Author, change.Field,
+				nullString(change.OldValue), nullString(change.NewValue), change.Permanent)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return b.attachments(string(trac.ResourceTicket), strconv.FormatInt(t.ID, 10), t.Attachments)
+}
+
+func (b *builder) attachments(resourceType, resource string, attachments []schema.Attachment) error {
+	for seq, att := range attachments {
+		err := b.exec(CountAttachments, `INSERT INTO attachments (resource_type, resource, seq, filename, description, size, time, author, sha256, skipped)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			resourceType, resource, seq, att.Filename, att.Description, att.Size, formatTime(att.Time), att.Author,
+			optional(att.SHA256), optional(att.Skipped))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) milestones() error {
+	files, err := b.jsonFiles("milestones")
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := fs.ReadFile(b.fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		m, err := schema.ParseMilestone(data)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+		err = b.exec(CountMilestones, `INSERT INTO milestones (name, description, due_date, completed_date) VALUES (?, ?, ?, ?)`,
+			m.Name, nullString(m.Description), formatTimePtr(m.DueDate), formatTimePtr(m.CompletedDate))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) wiki() error {
+	err := fs.WalkDir(b.fsys, "wiki", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name == "wiki/attachments" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		match := wikiVersionFile.FindStringSubmatch(strings.TrimPrefix(name, "wiki/"))
+		if match == nil {
+			return nil
+		}
+		page := match[1]
+		version, _ := strconv.ParseInt(match[2], 10, 64)
+
+		text, err := fs.ReadFile(b.fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		// The metadata of a version is optional, it is missing if Trac did not return it
+		var meta trac.WikiPage
+		if data, err := fs.ReadFile(b.fsys, strings.TrimSuffix(name, ".wiki")+".json"); err == nil {
+			if err := json.Unmarshal(data, &meta); err != nil {
+				return fmt.Errorf("failed to decode metadata of %s: %w", name, err)
+			}
+		}
+
+		return b.exec(CountWikiVersions, `INSERT INTO wiki_versions (page, version, author, comment, modified, text) VALUES (?, ?, ?, ?, ?, ?)`,
+			page, version, optional(meta.Author), nullString(meta.Comment), formatTime(meta.LastModified), string(text))
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (b *builder) wikiAttachments() error {
+	err := fs.WalkDir(b.fsys, "wiki/attachments", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(name, ".json") {
+			return nil
+		}
+
+		data, err := fs.ReadFile(b.fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		var index schema.PageAttachments
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		return b.attachments(string(trac.ResourceWiki), index.Page, index.Attachments)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// users stores the users of users.txt with their names and email addresses from
+// users.json, users only known to users.json are stored as well
+func (b *builder) users() error {
+	var usernames []string
+	data, err := fs.ReadFile(b.fsys, "users.txt")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read users.txt: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if user := strings.TrimSpace(scanner.Text()); user != "" {
+			usernames = append(usernames, user)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read users.txt: %w", err)
+	}
+
+	details := make(map[string]trac.User)
+	data, err = fs.ReadFile(b.fsys, "users.json")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read users.json: %w", err)
+	}
+	if err == nil {
+		var users []trac.User
+		if err := json.Unmarshal(data, &users); err != nil {
+			return fmt.Errorf("failed to decode users.json: %w", err)
+		}
+		for _, user := range users {
+			if user.Username == "" {
+				continue
+			}
+			if _, ok := details[user.Username]; !ok {
+				usernames = append(usernames, user.Username)
+			}
+			details[user.Username] = user
+		}
+	}
+
+	stored := make(map[string]bool)
+	for _, username := range usernames {
+		if stored[username] {
+			continue
+		}
+		stored[username] = true
+		user := details[username]
+		if err := b.exec(CountUsers, `INSERT INTO users (username, name, email) VALUES (?, ?, ?)`,
+			username, optional(user.Name), optional(user.Email)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *builder) meta() error {
+	values := map[string]string{
+		"version":        strconv.Itoa(Version),
+		"schema_version": strconv.Itoa(schema.Version),
+		"created_at":     time.Now().UTC().Format(time.RFC3339),
+	}
+	for key, value := range values {
+		if _, err := b.tx.ExecContext(b.ctx, `INSERT INTO meta (key, value) VALUES (?, ?)`, key, value); err != nil {
+			return fmt.Errorf("failed to insert into meta: %w", err)
+		}
+	}
+	return nil
+}
+
+// optional stores an empty string as NULL
+func optional(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
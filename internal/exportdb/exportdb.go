@@ -0,0 +1,106 @@
+// Package exportdb writes an export into a normalized SQLite database for analysis
+// and reads tickets and milestones back from it for the import.
+package exportdb
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Version is the version of the database layout, stored in the meta table
+const Version = 3
+
+//go:embed schema.sql
+var schemaSQL string
+
+// DB is an export database opened for reading
+type DB struct {
+	db *sql.DB
+}
+
+// Open opens an export database written by Build
+func Open(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open export database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export database: %w", err)
+	}
+
+	var version string
+	if err := db.QueryRow(`SELECT value FROM meta WHERE key = 'version'`).Scan(&version); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to read version of export database: %w", err)
+	}
+	if v, err := strconv.Atoi(version); err != nil || v != Version {
+		_ = db.Close()
+		return nil, fmt.Errorf("export database has version %s, this release reads version %d", version, Version)
+	}
+
+	return &DB{db: db}, nil
+}
+
+// Close closes the database
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// formatTime formats a time for storage, the zero time is stored as NULL
+func formatTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// formatTimePtr formats an optional time for storage
+func formatTimePtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
+
+// parseTime parses a stored time, NULL becomes the zero time
+func parseTime(value sql.NullString) (time.Time, error) {
+	if !value.Valid {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value.String)
+}
+
+// parseTimePtr parses an optional stored time
+func parseTimePtr(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// nullString converts an optional string for storage
+func nullString(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+// stringPtr converts an optional stored string
+func stringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
@@ -0,0 +1,140 @@
+package exportdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/internal/utils"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+func TestBuildAndRead(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel string, v any) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		data, ok := v.([]byte)
+		if !ok {
+			var err error
+			if data, err = json.Marshal(v); err != nil {
+				t.Fatalf("failed to encode %s: %v", rel, err)
+			}
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", rel, err)
+		}
+	}
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	oldText, newText := "old", "new"
+	ticket := &schema.Ticket{
+		SchemaVersion: schema.Version,
+		ID:            7,
+		Created:       created,
+		Changed:       created.Add(time.Hour),
+		Summary:       "Crash on start",
+		Description:   "It crashes",
+		Reporter:      "alice",
+		Status:        "closed",
+		Resolution:    "fixed",
+		Component:     "core",
+		Milestone:     "1.0",
+		CustomFields:  map[string]string{"customer": "ACME"},
+		Attachments: []schema.Attachment{
+			{Filename: "trace.txt", Size: 5, Time: created, Author: "alice", SHA256: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+			{Filename: "core.1", Size: 1 << 30, Time: created, Author: "alice", Skipped: "larger than 100 MB"},
+		},
+		History:  []schema.Change{{Time: created, Author: "alice", Field: "description", OldValue: &oldText, NewValue: &newText}},
+		Comments: []schema.Change{{Time: created.Add(time.Minute), Author: "bob", Field: "comment", NewValue: &newText, Permanent: true}},
+	}
+	due := created.AddDate(0, 1, 0)
+	write("tickets/ticket-7.json", ticket)
+	write("milestones/milestone-1.0.json", schema.Milestone{SchemaVersion: schema.Version, Name: "1.0", DueDate: &due})
+	write("wiki/Sub/Page.v1.wiki", []byte("= Page ="))
+	write("wiki/Sub/Page.v1.json", map[string]any{"author": "carol", "name": "Sub/Page", "version": 1, "lastModified": created})
+	write("wiki/attachments/Sub/Page.json", schema.PageAttachments{SchemaVersion: schema.Version, Page: "Sub/Page", Attachments: []schema.Attachment{{Filename: "logo.png", Size: 3}}})
+	write("users.txt", []byte("alice\nbob\n"))
+	write("users.json", []trac.User{{Username: "bob", Name: "Bob", Email: "bob@example.com"}, {Username: "carol", Email: "carol@example.com"}})
+
+	dbPath := filepath.Join(t.TempDir(), "export.db")
+	counts, err := Build(context.Background(), os.DirFS(dir), dbPath)
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+
+	want := map[string]int{CountTickets: 1, CountChanges: 1, CountComments: 1, CountAttachments: 3, CountMilestones: 1, CountWikiVersions: 1, CountUsers: 3}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("expected counts %v, got %v", want, counts)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// Names and email addresses come from users.json, users only listed there are kept
+	users := make(map[string][2]sql.NullString)
+	rows, err := db.db.Query(`SELECT username, name, email FROM users`)
+	if err != nil {
+		t.Fatalf("failed to query users: %v", err)
+	}
+	for rows.Next() {
+		var username string
+		var name, email sql.NullString
+		if err := rows.Scan(&username, &name, &email); err != nil {
+			t.Fatalf("failed to read user: %v", err)
+		}
+		users[username] = [2]sql.NullString{name, email}
+	}
+	_ = rows.Close()
+	wantUsers := map[string][2]sql.NullString{
+		"alice": {},
+		"bob":   {{String: "Bob", Valid: true}, {String: "bob@example.com", Valid: true}},
+		"carol": {{}, {String: "carol@example.com", Valid: true}},
+	}
+	if !reflect.DeepEqual(users, wantUsers) {
+		t.Errorf("expected users %v, got %v", wantUsers, users)
+	}
+
+	got, err := db.Ticket(7)
+	if err != nil {
+		t.Fatalf("Ticket returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, ticket) {
+		t.Errorf("ticket did not round trip:\nwant %+v\ngot  %+v", ticket, got)
+	}
+
+	// The importer reads the database like an export directory
+	files, err := utils.ReadFilesFromFS(db.FS(os.DirFS(dir)), "tickets", ".json", nil)
+	if err != nil {
+		t.Fatalf("failed to read tickets from database: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 ticket file, got %d", len(files))
+	}
+	parsed, err := schema.ParseTicket(files[0])
+	if err != nil || parsed.ID != 7 {
+		t.Errorf("unexpected ticket file %s: %v", files[0], err)
+	}
+
+	milestones, err := utils.ReadFilesFromFS(db.FS(nil), "milestones", ".json", nil)
+	if err != nil || len(milestones) != 1 {
+		t.Fatalf("expected 1 milestone file, got %d: %v", len(milestones), err)
+	}
+	milestone, err := schema.ParseMilestone(milestones[0])
+	if err != nil || milestone.Name != "1.0" || !milestone.DueDate.Equal(due) {
+		t.Errorf("unexpected milestone %+v: %v", milestone, err)
+	}
+}
@@ -0,0 +1,351 @@
+package exportdb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// TicketIDs returns the IDs of all tickets in ascending order
+func (d *DB) TicketIDs() ([]int64, error) {
+	rows, err := d.db.Query(`SELECT id FROM tickets ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Ticket reads a ticket with its custom fields, changes, comments and attachments
+func (d *DB) Ticket(id int64) (*schema.Ticket, error) {
+	t := &schema.Ticket{}
+	var created, changed sql.NullString
+	err := d.db.QueryRow(`SELECT id, schema_version, created, changed, summary, description, reporter, owner, type, status, resolution,
+		priority, severity, component, version, milestone, keywords, cc, markdown FROM tickets WHERE id = ?`, id).Scan(
+		&t.ID, &t.SchemaVersion, &created, &changed, &t.Summary, &t.Description, &t.Reporter, &t.Owner, &t.Type, &t.Status, &t.Resolution,
+		&t.Priority, &t.Severity, &t.Component, &t.Version, &t.Milestone, &t.Keywords, &t.CC, &t.Markdown)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ticket #%d: %w", id, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ticket #%d: %w", id, err)
+	}
+	if t.Created, err = parseTime(created); err != nil {
+		return nil, err
+	}
+	if t.Changed, err = parseTime(changed); err != nil {
+		return nil, err
+	}
+
+	if t.CustomFields, err = d.customFields(id); err != nil {
+		return nil, err
+	}
+	if t.History, err = d.changes("ticket_changes", id); err != nil {
+		return nil, err
+	}
+	if t.Comments, err = d.changes("ticket_comments", id); err != nil {
+		return nil, err
+	}
+	if t.Attachments, err = d.attachments(string(trac.ResourceTicket), strconv.FormatInt(id, 10)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (d *DB) customFields(id int64) (map[string]string, error) {
+	rows, err := d.db.Query(`SELECT name, value FROM ticket_custom WHERE ticket = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom fields of ticket #%d: %w", id, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var fields map[string]string
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[name] = value
+	}
+	return fields, rows.Err()
+}
+
+func (d *DB) changes(table string, id int64) ([]schema.Change, error) {
+	rows, err := d.db.Query(`SELECT time, author, field, old_value, new_value, permanent FROM `+table+` WHERE ticket = ? ORDER BY seq`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s of ticket #%d: %w", table, id, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var changes []schema.Change
+	for rows.Next() {
+		var change schema.Change
+		var changeTime, oldValue, newValue sql.NullString
+		if err := rows.Scan(&changeTime, &change.Author, &change.Field, &oldValue, &newValue, &change.Permanent); err != nil {
+			return nil, err
+		}
+		if change.Time, err = parseTime(changeTime); err != nil {
+			return nil, err
+		}
+		change.OldValue = stringPtr(oldValue)
+		change.NewValue = stringPtr(newValue)
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+func (d *DB) attachments(resourceType, resource string) ([]schema.Attachment, error) {
+	rows, err := d.db.Query(`SELECT filename, description, size, time, author, sha256, skipped FROM attachments
+		WHERE resource_type = ? AND resource = ? ORDER BY seq`, resourceType, resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachments of %s %s: %w", resourceType, resource, err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var attachments []schema.Attachment
+	for rows.Next() {
+		var att schema.Attachment
+		var attTime, hash, skipped sql.NullString
+		if err := rows.Scan(&att.Filename, &att.Description, &att.Size, &attTime, &att.Author, &hash, &skipped); err != nil {
+			return nil, err
+		}
+		if att.Time, err = parseTime(attTime); err != nil {
+			return nil, err
+		}
+		att.SHA256 = hash.String
+		att.Skipped = skipped.String
+		attachments = append(attachments, att)
+	}
+	return attachments, rows.Err()
+}
+
+// Milestones reads all milestones ordered by name
+func (d *DB) Milestones() ([]schema.Milestone, error) {
+	rows, err := d.db.Query(`SELECT name, description, due_date, completed_date FROM milestones ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list milestones: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var milestones []schema.Milestone
+	for rows.Next() {
+		m := schema.Milestone{SchemaVersion: schema.Version}
+		var description, due, completed sql.NullString
+		if err := rows.Scan(&m.Name, &description, &due, &completed); err != nil {
+			return nil, err
+		}
+		m.Description = stringPtr(description)
+		if m.DueDate, err = parseTimePtr(due); err != nil {
+			return nil, err
+		}
+		if m.CompletedDate, err = parseTimePtr(completed); err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, m)
+	}
+	return milestones, rows.Err()
+}
+
+// FS presents the tickets and milestones of the database as the files of an export,
+// so the importer can read from the database. Other paths, such as attachment blobs,
+// are read from base.
+func (d *DB) FS(base fs.FS) fs.FS {
+	return &dbFS{db: d, base: base, milestones: sync.OnceValues(d.Milestones)}
+}
+
+// dbFS serves tickets/ticket-<id>.json and milestones/milestone-<n>.json from the database.
+// The milestones are read once, their files are named by their index in that list.
+type dbFS struct {
+	db         *DB
+	base       fs.FS
+	milestones func() ([]schema.Milestone, error)
+}
+
+func (f *dbFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	switch dir := path.Dir(name); {
+	case name == "tickets" || name == "milestones":
+		entries, err := f.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &dirFile{info: fileInfo{name: name, dir: true}, entries: entries}, nil
+	case dir == "tickets" || dir == "milestones":
+		data, err := f.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return &dataFile{Reader: bytes.NewReader(data), info: fileInfo{name: name, size: int64(len(data))}}, nil
+	}
+
+	if f.base == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.base.Open(name)
+}
+
+func (f *dbFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var names []string
+	switch name {
+	case "tickets":
+		ids, err := f.db.TicketIDs()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			names = append(names, fmt.Sprintf("ticket-%d.json", id))
+		}
+	case "milestones":
+		milestones, err := f.milestones()
+		if err != nil {
+			return nil, err
+		}
+		for i := range milestones {
+			names = append(names, fmt.Sprintf("milestone-%d.json", i))
+		}
+	default:
+		if f.base == nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+		}
+		return fs.ReadDir(f.base, name)
+	}
+
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, n := range names {
+		entries = append(entries, fileInfo{name: n})
+	}
+	return entries, nil
+}
+
+func (f *dbFS) ReadFile(name string) ([]byte, error) {
+	notExist := &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+
+	switch path.Dir(name) {
+	case "tickets":
+		idText, ok := strings.CutPrefix(strings.TrimSuffix(path.Base(name), ".json"), "ticket-")
+		id, err := strconv.ParseInt(idText, 10, 64)
+		if !ok || err != nil {
+			return nil, notExist
+		}
+		ticket, err := f.db.Ticket(id)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(ticket)
+	case "milestones":
+		indexText, ok := strings.CutPrefix(strings.TrimSuffix(path.Base(name), ".json"), "milestone-")
+		index, err := strconv.Atoi(indexText)
+		if !ok || err != nil {
+			return nil, notExist
+		}
+		milestones, err := f.milestones()
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= len(milestones) {
+			return nil, notExist
+		}
+		return json.Marshal(milestones[index])
+	}
+
+	if f.base == nil {
+		return nil, notExist
+	}
+	return fs.ReadFile(f.base, name)
+}
+
+// fileInfo describes a file or directory served from the database
+type fileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i fileInfo) Name() string               { return path.Base(i.name) }
+func (i fileInfo) Size() int64                { return i.size }
+func (i fileInfo) ModTime() time.Time         { return time.Time{} }
+func (i fileInfo) IsDir() bool                { return i.dir }
+func (i fileInfo) Sys() any                   { return nil }
+func (i fileInfo) Type() fs.FileMode          { return i.Mode().Type() }
+func (i fileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+func (i fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// dataFile is an open file generated from the database
+type dataFile struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *dataFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *dataFile) Close() error               { return nil }
+
+// dirFile is an open directory generated from the database
+type dirFile struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,108 @@
+-- Normalized view of a trac2gitlab export. Times are RFC 3339 strings in UTC.
+
+CREATE TABLE meta (
+    key   TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
+CREATE TABLE users (
+    username TEXT PRIMARY KEY,
+    name     TEXT,
+    email    TEXT
+);
+
+CREATE TABLE milestones (
+    name           TEXT PRIMARY KEY,
+    description    TEXT,
+    due_date       TEXT,
+    completed_date TEXT
+);
+
+CREATE TABLE tickets (
+    id          INTEGER PRIMARY KEY,
+    -- schema version the ticket was exported with, see schema.ParseTicket
+    schema_version INTEGER NOT NULL,
+    created     TEXT NOT NULL,
+    changed     TEXT NOT NULL,
+    summary     TEXT NOT NULL,
+    description TEXT NOT NULL,
+    reporter    TEXT NOT NULL,
+    owner       TEXT NOT NULL,
+    type        TEXT NOT NULL,
+    status      TEXT NOT NULL,
+    resolution  TEXT NOT NULL,
+    priority    TEXT NOT NULL,
+    severity    TEXT NOT NULL,
+    component   TEXT NOT NULL,
+    version     TEXT NOT NULL,
+    milestone   TEXT NOT NULL,
+    keywords    TEXT NOT NULL,
+    cc          TEXT NOT NULL,
+    -- 1 if description and comments were exported as Markdown by a release before schema version 2
+    markdown    INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX tickets_reporter ON tickets (reporter);
+CREATE INDEX tickets_component ON tickets (component);
+CREATE INDEX tickets_milestone ON tickets (milestone);
+
+CREATE TABLE ticket_custom (
+    ticket INTEGER NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    name   TEXT NOT NULL,
+    value  TEXT NOT NULL,
+    PRIMARY KEY (ticket, name)
+);
+
+-- Changelog events of the ticket description
+CREATE TABLE ticket_changes (
+    ticket    INTEGER NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    seq       INTEGER NOT NULL,
+    time      TEXT NOT NULL,
+    author    TEXT NOT NULL,
+    field     TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    permanent INTEGER NOT NULL,
+    PRIMARY KEY (ticket, seq)
+);
+
+CREATE TABLE ticket_comments (
+    ticket    INTEGER NOT NULL REFERENCES tickets (id) ON DELETE CASCADE,
+    seq       INTEGER NOT NULL,
+    time      TEXT NOT NULL,
+    author    TEXT NOT NULL,
+    field     TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    permanent INTEGER NOT NULL,
+    PRIMARY KEY (ticket, seq)
+);
+
+CREATE INDEX ticket_comments_author ON ticket_comments (author);
+
+-- Attachments of tickets and wiki pages, resource is the ticket ID or page name
+CREATE TABLE attachments (
+    resource_type TEXT NOT NULL,
+    resource      TEXT NOT NULL,
+    seq           INTEGER NOT NULL,
+    filename      TEXT NOT NULL,
+    description   TEXT NOT NULL,
+    size          INTEGER NOT NULL,
+    time          TEXT,
+    author        TEXT NOT NULL,
+    sha256        TEXT,
+    skipped       TEXT,
+    PRIMARY KEY (resource_type, resource, seq)
+);
+
+CREATE INDEX attachments_sha256 ON attachments (sha256);
+
+CREATE TABLE wiki_versions (
+    page     TEXT NOT NULL,
+    version  INTEGER NOT NULL,
+    author   TEXT,
+    comment  TEXT,
+    modified TEXT,
+    text     TEXT NOT NULL,
+    PRIMARY KEY (page, version)
+);
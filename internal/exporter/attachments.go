@@ -1 +1,222 @@
 package exporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/internal/utils"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// errAttachmentTooLarge stops a download that exceeds the configured size limit
+var errAttachmentTooLarge = errors.New("attachment exceeds the size limit")
+
+// attachmentPolicy applies the attachment limits of the configuration
+type attachmentPolicy struct {
+	maxSize        int64
+	patterns       []string
+	onOversize     string
+	onSizeMismatch string
+}
+
+// newAttachmentPolicy validates the attachment limits of the configuration
+func newAttachmentPolicy(cfg *config.Config) (*attachmentPolicy, error) {
+	limits := cfg.ExportOptions.AttachmentLimits
+
+	for _, pattern := range limits.SkipPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid attachment skip pattern %q: %w", pattern, err)
+		}
+	}
+
+	policy := &attachmentPolicy{
+		maxSize:        limits.MaxSizeMB << 20,
+		patterns:       limits.SkipPatterns,
+		onOversize:     limits.OnOversize,
+		onSizeMismatch: limits.OnSizeMismatch,
+	}
+
+	switch policy.onOversize {
+	case "":
+		policy.onOversize = config.AttachmentPolicySkip
+	case config.AttachmentPolicySkip, config.AttachmentPolicyFail:
+	default:
+		return nil, fmt.Errorf("invalid on_oversize policy %q, use %q or %q", limits.OnOversize, config.AttachmentPolicySkip, config.AttachmentPolicyFail)
+	}
+
+	switch policy.onSizeMismatch {
+	case "":
+		policy.onSizeMismatch = config.AttachmentPolicyFail
+	case config.AttachmentPolicyFail, config.AttachmentPolicyKeep:
+	default:
+		return nil, fmt.Errorf("invalid on_size_mismatch policy %q, use %q or %q", limits.OnSizeMismatch, config.AttachmentPolicyFail, config.AttachmentPolicyKeep)
+	}
+
+	return policy, nil
+}
+
+// skipReason returns why an attachment is not downloaded, or an empty string to download it
+func (p *attachmentPolicy) skipReason(att schema.Attachment) string {
+	for _, pattern := range p.patterns {
+		if ok, _ := path.Match(pattern, path.Base(att.Filename)); ok {
+			return fmt.Sprintf("matches skip pattern %q", pattern)
+		}
+	}
+	return ""
+}
+
+// oversize applies the oversize policy to an attachment larger than the size limit
+func (p *attachmentPolicy) oversize(resType trac.ResourceType, id any, att *schema.Attachment) error {
+	if p.onOversize == config.AttachmentPolicyFail {
+		return fmt.Errorf("%w of %d MB", errAttachmentTooLarge, p.maxSize>>20)
+	}
+	att.Skipped = fmt.Sprintf("larger than %d MB", p.maxSize>>20)
+	slog.Info("Skipping attachment", "resource", resType, "id", id, "filename", att.Filename, "reason", att.Skipped)
+	return nil
+}
+
+// downloadAttachment streams an attachment into the blob store and records its hash.
+// Attachments excluded by the policy are marked as skipped, the returned blob path is then empty.
+func downloadAttachment(ctx context.Context, client AttachmentSource, policy *attachmentPolicy, exportDir string, resType trac.ResourceType, id any, att *schema.Attachment) (string, error) {
+	if reason := policy.skipReason(*att); reason != "" {
+		slog.Info("Skipping attachment", "resource", resType, "id", id, "filename", att.Filename, "reason", reason)
+		att.Skipped = reason
+		return "", nil
+	}
+	if policy.maxSize > 0 && att.Size > policy.maxSize {
+		return "", policy.oversize(resType, id, att)
+	}
+
+	blob, err := createBlob(exportDir)
+	if err != nil {
+		return "", err
+	}
+	defer blob.Abort()
+
+	var w io.Writer = blob
+	if policy.maxSize > 0 {
+		w = &limitWriter{w: blob, remaining: policy.maxSize}
+	}
+
+	// The size Trac reports is checked upfront, this catches attachments without a reported size
+	size, err := client.DownloadAttachment(ctx, resType, id, att.Filename, w)
+	if errors.Is(err, errAttachmentTooLarge) {
+		return "", policy.oversize(resType, id, att)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if att.Size > 0 && size != att.Size {
+		if policy.onSizeMismatch != config.AttachmentPolicyKeep {
+			return "", fmt.Errorf("downloaded %d bytes but Trac reports %d", size, att.Size)
+		}
+		slog.Warn("Attachment size differs from the size Trac reports, keeping it", "resource", resType, "id", id, "filename", att.Filename, "size", size, "reported", att.Size)
+	}
+	att.Size = size
+
+	hash, blobPath, err := blob.Commit()
+	if err != nil {
+		return "", err
+	}
+	att.SHA256 = hash
+	return blobPath, nil
+}
+
+// blobWriter streams content into a temporary file of the blob store while hashing it
+type blobWriter struct {
+	exportDir string
+	file      *utils.AtomicFile
+	hash      hash.Hash
+	size      int64
+}
+
+// createBlob starts writing a new blob to the store of the export directory
+func createBlob(exportDir string) (*blobWriter, error) {
+	blobDir := filepath.Join(exportDir, filepath.FromSlash(schema.BlobDir))
+	if err := os.MkdirAll(blobDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	file, err := utils.CreateAtomic(filepath.Join(blobDir, "download"))
+	if err != nil {
+		return nil, err
+	}
+	return &blobWriter{exportDir: exportDir, file: file, hash: sha256.New()}, nil
+}
+
+func (b *blobWriter) Write(p []byte) (int, error) {
+	n, err := b.file.Write(p)
+	b.hash.Write(p[:n])
+	b.size += int64(n)
+	return n, err
+}
+
+// Commit moves the blob to its content-addressed path and returns its SHA-256 and path.
+// Content already in the store is not written again.
+func (b *blobWriter) Commit() (string, string, error) {
+	sum := hex.EncodeToString(b.hash.Sum(nil))
+	blobPath := filepath.Join(b.exportDir, filepath.FromSlash(schema.BlobPath(sum)))
+
+	if info, err := os.Stat(blobPath); err == nil && info.Size() == b.size {
+		b.file.Abort()
+		return sum, blobPath, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	if err := b.file.CommitTo(blobPath); err != nil {
+		return "", "", err
+	}
+	return sum, blobPath, nil
+}
+
+// Abort discards the blob, it does nothing after Commit
+func (b *blobWriter) Abort() {
+	b.file.Abort()
+}
+
+// limitWriter fails writes once more than remaining bytes were written
+type limitWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining {
+		return 0, errAttachmentTooLarge
+	}
+	l.remaining -= int64(len(p))
+	return l.w.Write(p)
+}
+
+// blobHash returns the hash of the blob recorded for a completed attachment in the checkpoint.
+// Of several recorded files the first by path is taken, so the hash does not change between runs.
+func blobHash(cp *Checkpoint, key string) string {
+	files := cp.Files(key)
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if files[path].SHA256 != "" {
+			return files[path].SHA256
+		}
+	}
+	return ""
+}
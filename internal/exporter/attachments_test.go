@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+func TestBlobWriter_Deduplicates(t *testing.T) {
+	dir := t.TempDir()
+	store := func(content string) (string, string) {
+		blob, err := createBlob(dir)
+		if err != nil {
+			t.Fatalf("createBlob returned error: %v", err)
+		}
+		defer blob.Abort()
+		if _, err := blob.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write blob: %v", err)
+		}
+		hash, path, err := blob.Commit()
+		if err != nil {
+			t.Fatalf("Commit returned error: %v", err)
+		}
+		return hash, path
+	}
+
+	hash, path := store("logo")
+	want := filepath.Join(dir, "attachments", "blobs", hash[:2], hash)
+	if path != want {
+		t.Errorf("expected blob at %s, got %s", want, path)
+	}
+
+	if again, againPath := store("logo"); again != hash || againPath != path {
+		t.Errorf("identical content stored twice: %s and %s", path, againPath)
+	}
+	if other, _ := store("other"); other == hash {
+		t.Error("different content got the same hash")
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("failed to read blob directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected a single blob, got %d", len(entries))
+	}
+
+	// No temporary files are left behind
+	entries, err = os.ReadDir(filepath.Join(dir, "attachments", "blobs"))
+	if err != nil {
+		t.Fatalf("failed to read blob store: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			t.Errorf("unexpected file %s in blob store", entry.Name())
+		}
+	}
+}
+
+func TestBlobHash_Stable(t *testing.T) {
+	dir := t.TempDir()
+	cp, err := OpenCheckpoint(dir, false)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint returned error: %v", err)
+	}
+	defer func() {
+		_ = cp.Close()
+	}()
+
+	var paths []string
+	for _, name := range []string{"c.bin", "a.bin", "b.bin"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		paths = append(paths, path)
+	}
+	if err := cp.Complete("wiki-attachment:WikiStart/logo.png", nil, 0, paths...); err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+
+	want := cp.Files("wiki-attachment:WikiStart/logo.png")["a.bin"].SHA256
+	for range 20 {
+		if got := blobHash(cp, "wiki-attachment:WikiStart/logo.png"); got != want {
+			t.Fatalf("expected the hash of the first file %s, got %s", want, got)
+		}
+	}
+	if got := blobHash(cp, "wiki-attachment:WikiStart/missing.png"); got != "" {
+		t.Errorf("expected no hash for an unknown attachment, got %s", got)
+	}
+}
+
+func TestAttachmentPolicy(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ExportOptions.AttachmentLimits = config.AttachmentLimits{
+		MaxSizeMB:    1,
+		SkipPatterns: []string{"*.core", "core.*"},
+	}
+
+	policy, err := newAttachmentPolicy(cfg)
+	if err != nil {
+		t.Fatalf("newAttachmentPolicy returned error: %v", err)
+	}
+
+	if policy.skipReason(schema.Attachment{Filename: "core.1234"}) == "" {
+		t.Error("expected core.1234 to match a skip pattern")
+	}
+	if reason := policy.skipReason(schema.Attachment{Filename: "screenshot.png"}); reason != "" {
+		t.Errorf("expected screenshot.png to be downloaded, got %q", reason)
+	}
+
+	att := &schema.Attachment{Filename: "dump.bin", Size: 2 << 20}
+	if err := policy.oversize(trac.ResourceTicket, 1, att); err != nil || att.Skipped == "" {
+		t.Errorf("expected oversized attachment to be skipped, got %v", err)
+	}
+
+	policy.onOversize = config.AttachmentPolicyFail
+	if err := policy.oversize(trac.ResourceTicket, 1, &schema.Attachment{}); !errors.Is(err, errAttachmentTooLarge) {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+
+	w := &limitWriter{w: &blobWriter{}, remaining: 0}
+	if _, err := w.Write([]byte("x")); !errors.Is(err, errAttachmentTooLarge) {
+		t.Errorf("expected size limit error from writer, got %v", err)
+	}
+
+	cfg.ExportOptions.AttachmentLimits.OnSizeMismatch = "ignore"
+	if _, err := newAttachmentPolicy(cfg); err == nil {
+		t.Error("expected invalid policy to be rejected")
+	}
+}
@@ -0,0 +1,31 @@
+package exporter
+
+import (
+	"log/slog"
+
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// capabilityReporter is implemented by sources that do not support every capability
+type capabilityReporter interface {
+	Capabilities() *trac.Capabilities
+}
+
+// SourceCapabilities returns the capabilities of a source, sources that do not report
+// them support everything
+func SourceCapabilities(src any) *trac.Capabilities {
+	if reporter, ok := src.(capabilityReporter); ok {
+		return reporter.Capabilities()
+	}
+	return nil
+}
+
+// supports reports whether the source has a capability and warns what is left out of
+// the export if it does not
+func supports(src any, capability trac.Capability, skipped string) bool {
+	if SourceCapabilities(src).Has(capability) {
+		return true
+	}
+	slog.Warn("Trac does not support a capability the export uses", "capability", capability, "effect", skipped)
+	return false
+}
@@ -0,0 +1,259 @@
+package exporter
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkpointFile is the append-only manifest of completed entities inside the export directory
+const checkpointFile = "checkpoint.jsonl"
+
+// CheckpointFile describes a file written for an entity
+type CheckpointFile struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// CheckpointEntry records a completed entity, e.g. "ticket:12" or "wiki:WikiStart@3"
+type CheckpointEntry struct {
+	Key     string                    `json:"key"`
+	Started *time.Time                `json:"started,omitempty"`
+	Changed *time.Time                `json:"changed,omitempty"`
+	Version int64                     `json:"version,omitempty"`
+	Files   map[string]CheckpointFile `json:"files,omitempty"`
+}
+
+// Checkpoint keeps track of completed entities so an interrupted export can resume.
+// Every completion is appended as a JSON line, a truncated last line is cut off on load.
+type Checkpoint struct {
+	exportDir string
+	startedAt time.Time
+	entries   map[string]CheckpointEntry
+	file      *os.File
+	mu        sync.Mutex
+}
+
+// OpenCheckpoint loads the checkpoint manifest of an export directory or starts a new one.
+// With fresh set, an existing manifest is discarded.
+func OpenCheckpoint(exportDir string, fresh bool) (*Checkpoint, error) {
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	path := filepath.Join(exportDir, checkpointFile)
+	if fresh {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove checkpoint: %w", err)
+		}
+	}
+
+	cp := &Checkpoint{
+		exportDir: exportDir,
+		entries:   make(map[string]CheckpointEntry),
+	}
+
+	// complete is the length of the manifest up to its last complete line
+	var complete int64
+	if f, err := os.Open(path); err == nil {
+		reader := bufio.NewReader(f)
+		var readErr error
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				} else if len(line) > 0 {
+					slog.Warn("Ignoring truncated checkpoint entry", "bytes", len(line))
+				}
+				break
+			}
+			complete += int64(len(line))
+
+			var entry CheckpointEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				slog.Warn("Ignoring damaged checkpoint entry", "error", err)
+				continue
+			}
+			if entry.Started != nil {
+				cp.startedAt = *entry.Started
+				continue
+			}
+			cp.entries[entry.Key] = entry
+		}
+		if err := f.Close(); err != nil {
+			slog.Warn("Failed to close checkpoint", "error", err)
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read checkpoint: %w", readErr)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint for writing: %w", err)
+	}
+	// Cut off a line torn by a crash, the next entry would be appended to it otherwise
+	if err := file.Truncate(complete); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to repair checkpoint: %w", err)
+	}
+	cp.file = file
+
+	if cp.startedAt.IsZero() {
+		cp.startedAt = time.Now().UTC()
+		if err := cp.append(CheckpointEntry{Started: &cp.startedAt}); err != nil {
+			return nil, err
+		}
+	}
+
+	return cp, nil
+}
+
+// StartedAt returns when the export tracked by this checkpoint was first started
+func (c *Checkpoint) StartedAt() time.Time {
+	return c.startedAt
+}
+
+// Count returns the number of completed entities whose key starts with prefix
+func (c *Checkpoint) Count(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+// Done reports whether an entity was completed with the given change time and
+// version and all of its files are still present with their recorded size.
+// A nil changed or zero version matches any recorded value.
+func (c *Checkpoint) Done(key string, changed *time.Time, version int64) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	if changed != nil && (entry.Changed == nil || !entry.Changed.Equal(*changed)) {
+		return false
+	}
+	if version != 0 && entry.Version != version {
+		return false
+	}
+
+	for rel, recorded := range entry.Files {
+		info, err := os.Stat(filepath.Join(c.exportDir, rel))
+		if err != nil || info.Size() != recorded.Size {
+			return false
+		}
+	}
+	return true
+}
+
+// Files returns the files recorded for a completed entity
+func (c *Checkpoint) Files(key string) map[string]CheckpointFile {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key].Files
+}
+
+// Complete records an entity as completed together with checksums of the files written for it
+func (c *Checkpoint) Complete(key string, changed *time.Time, version int64, files ...string) error {
+	if c == nil {
+		return nil
+	}
+
+	entry := CheckpointEntry{
+		Key:     key,
+		Changed: changed,
+		Version: version,
+		Files:   make(map[string]CheckpointFile, len(files)),
+	}
+
+	for _, path := range files {
+		rel, err := filepath.Rel(c.exportDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %q relative to export directory: %w", path, err)
+		}
+		sum, size, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		entry.Files[filepath.ToSlash(rel)] = CheckpointFile{SHA256: sum, Size: size}
+	}
+
+	if err := c.append(entry); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	return nil
+}
+
+// Close closes the checkpoint manifest
+func (c *Checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+// append writes a single entry as a JSON line
+func (c *Checkpoint) append(entry CheckpointEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint entry: %w", err)
+	}
+	return nil
+}
+
+// fileChecksum returns the hex encoded SHA-256 and the size of a file
+func fileChecksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open %q for checksum: %w", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read %q for checksum: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckpoint_Resume(t *testing.T) {
+	dir := t.TempDir()
+	ticketFile := filepath.Join(dir, "ticket-1.json")
+	if err := os.WriteFile(ticketFile, []byte(`{"ID":1}`), 0644); err != nil {
+		t.Fatalf("failed to write ticket: %v", err)
+	}
+	changed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cp, err := OpenCheckpoint(dir, false)
+	if err != nil {
+		t.Fatalf("failed to open checkpoint: %v", err)
+	}
+	if err := cp.Complete("ticket:1", &changed, 0, ticketFile); err != nil {
+		t.Fatalf("failed to complete entry: %v", err)
+	}
+	started := cp.StartedAt()
+	if err := cp.Close(); err != nil {
+		t.Fatalf("failed to close checkpoint: %v", err)
+	}
+
+	// Simulate a crash in the middle of writing the next entry
+	f, err := os.OpenFile(filepath.Join(dir, checkpointFile), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open checkpoint file: %v", err)
+	}
+	if _, err := f.WriteString(`{"key":"ticket:2","fil`); err != nil {
+		t.Fatalf("failed to write partial entry: %v", err)
+	}
+	_ = f.Close()
+
+	cp, err = OpenCheckpoint(dir, false)
+	if err != nil {
+		t.Fatalf("failed to reopen checkpoint: %v", err)
+	}
+	defer func() {
+		_ = cp.Close()
+	}()
+
+	if !cp.StartedAt().Equal(started) {
+		t.Errorf("start time not preserved: %v != %v", cp.StartedAt(), started)
+	}
+	if !cp.Done("ticket:1", &changed, 0) {
+		t.Error("expected ticket 1 to be done")
+	}
+	later := changed.Add(time.Minute)
+	if cp.Done("ticket:1", &later, 0) {
+		t.Error("ticket changed in Trac should not be done")
+	}
+	if cp.Done("ticket:2", nil, 0) {
+		t.Error("partially recorded ticket should not be done")
+	}
+	if cp.Count("ticket:") != 1 {
+		t.Errorf("expected 1 completed ticket, got %d", cp.Count("ticket:"))
+	}
+
+	// A truncated file has to be exported again
+	if err := os.WriteFile(ticketFile, []byte(`{"ID"`), 0644); err != nil {
+		t.Fatalf("failed to truncate ticket: %v", err)
+	}
+	if cp.Done("ticket:1", &changed, 0) {
+		t.Error("ticket with truncated file should not be done")
+	}
+}
+
+func TestCheckpoint_CompleteAfterTornLine(t *testing.T) {
+	dir := t.TempDir()
+	cp, err := OpenCheckpoint(dir, false)
+	if err != nil {
+		t.Fatalf("failed to open checkpoint: %v", err)
+	}
+	if err := cp.Complete("ticket:1", nil, 0); err != nil {
+		t.Fatalf("failed to complete entry: %v", err)
+	}
+	if err := cp.Close(); err != nil {
+		t.Fatalf("failed to close checkpoint: %v", err)
+	}
+
+	// Simulate a crash in the middle of writing the next entry
+	f, err := os.OpenFile(filepath.Join(dir, checkpointFile), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open checkpoint file: %v", err)
+	}
+	if _, err := f.WriteString(`{"key":"ticket:2","fil`); err != nil {
+		t.Fatalf("failed to write partial entry: %v", err)
+	}
+	_ = f.Close()
+
+	// The entry completed after resuming must survive the next resume
+	cp, err = OpenCheckpoint(dir, false)
+	if err != nil {
+		t.Fatalf("failed to reopen checkpoint: %v", err)
+	}
+	if err := cp.Complete("ticket:3", nil, 0); err != nil {
+		t.Fatalf("failed to complete entry: %v", err)
+	}
+	if err := cp.Close(); err != nil {
+		t.Fatalf("failed to close checkpoint: %v", err)
+	}
+
+	cp, err = OpenCheckpoint(dir, false)
+	if err != nil {
+		t.Fatalf("failed to reopen checkpoint: %v", err)
+	}
+	defer func() {
+		_ = cp.Close()
+	}()
+	for key, want := range map[string]bool{"ticket:1": true, "ticket:2": false, "ticket:3": true} {
+		if got := cp.Done(key, nil, 0); got != want {
+			t.Errorf("Done(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
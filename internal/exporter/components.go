@@ -0,0 +1,46 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// ExportComponents exports the components with their owners and descriptions to components.json
+func ExportComponents(ctx context.Context, client Source, config *config.Config) error {
+	slog.Info("Starting component export...")
+
+	if !supports(client, trac.CapComponents, "skipping the component export") {
+		return nil
+	}
+	source, ok := client.(ComponentSource)
+	if !ok {
+		return nil
+	}
+
+	names, err := source.GetComponentNames(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get component names: %w", err)
+	}
+
+	components := make([]trac.Component, 0, len(names))
+	for _, name := range names {
+		component, err := source.GetComponentByName(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to get component %q: %w", name, err)
+		}
+		components = append(components, *component)
+	}
+
+	if err := writeJSON(filepath.Join(config.ExportOptions.ExportDir, schema.ComponentsFile), components); err != nil {
+		return fmt.Errorf("failed to write components: %w", err)
+	}
+
+	slog.Info("Component export completed", "count", len(components))
+	return nil
+}
@@ -0,0 +1,265 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// tracEnvSchema is the subset of the Trac 1.x database schema read by trac.Env
+const tracEnvSchema = `
+CREATE TABLE ticket (id INTEGER PRIMARY KEY, type TEXT, time INTEGER, changetime INTEGER, component TEXT,
+	severity TEXT, priority TEXT, owner TEXT, reporter TEXT, cc TEXT, version TEXT, milestone TEXT,
+	status TEXT, resolution TEXT, summary TEXT, description TEXT, keywords TEXT);
+CREATE TABLE ticket_custom (ticket INTEGER, name TEXT, value TEXT, UNIQUE (ticket, name));
+CREATE TABLE ticket_change (ticket INTEGER, time INTEGER, author TEXT, field TEXT, oldvalue TEXT, newvalue TEXT);
+CREATE TABLE milestone (name TEXT PRIMARY KEY, due INTEGER, completed INTEGER, description TEXT);
+CREATE TABLE component (name TEXT PRIMARY KEY, owner TEXT, description TEXT);
+CREATE TABLE version (name TEXT PRIMARY KEY, time INTEGER, description TEXT);
+CREATE TABLE enum (type TEXT, name TEXT, value TEXT, UNIQUE (type, name));
+CREATE TABLE wiki (name TEXT, version INTEGER, time INTEGER, author TEXT, text TEXT, comment TEXT, readonly INTEGER);
+CREATE TABLE attachment (type TEXT, id TEXT, filename TEXT, size INTEGER, time INTEGER, description TEXT, author TEXT);
+CREATE TABLE session_attribute (sid TEXT, authenticated INTEGER, name TEXT, value TEXT);
+`
+
+// tracTicketColumns are the ticket attributes stored in the ticket table, the others are custom fields
+var tracTicketColumns = []string{"type", "component", "severity", "priority", "owner", "reporter", "cc",
+	"version", "milestone", "status", "resolution", "summary", "description", "keywords"}
+
+// writeTracEnv stores the data of src the way Trac does in a new environment directory:
+// a SQLite database, hashed attachment paths and the custom fields in trac.ini
+func writeTracEnv(t *testing.T, src Source) string {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "db"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dir, "db", "trac.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	exec := func(query string, args ...any) {
+		t.Helper()
+		if _, err := db.Exec(query, args...); err != nil {
+			t.Fatalf("failed to seed Trac database: %v", err)
+		}
+	}
+	exec(tracEnvSchema)
+
+	attachments := func(resType trac.ResourceType, id string, list []trac.Attachment) {
+		t.Helper()
+		parent := sha1Hex(id)
+		for _, att := range list {
+			exec(`INSERT INTO attachment VALUES (?, ?, ?, ?, ?, ?, ?)`, string(resType), id, att.Filename, att.Size, att.Time.UnixMicro(), att.Description, att.Author)
+
+			var content bytes.Buffer
+			if _, err := src.DownloadAttachment(ctx, resType, id, att.Filename, &content); err != nil {
+				t.Fatalf("failed to download attachment %s: %v", att.Filename, err)
+			}
+			path := filepath.Join(dir, "files", "attachments", string(resType), parent[:3], parent, sha1Hex(att.Filename)+filepath.Ext(att.Filename))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, content.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	ids, err := src.GetAllTicketIDs(ctx, "max=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	customFields := make(map[string]bool)
+	for _, id := range ids {
+		ticket, err := src.GetTicket(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := []any{id, ticket.TimeCreated.UnixMicro(), ticket.TimeChanged.UnixMicro()}
+		for _, column := range tracTicketColumns {
+			values = append(values, fmt.Sprint(ticket.Attributes[column]))
+			if ticket.Attributes[column] == nil {
+				values[len(values)-1] = ""
+			}
+		}
+		exec(`INSERT INTO ticket (id, time, changetime, `+strings.Join(tracTicketColumns, ", ")+`) VALUES (?, ?, ?`+strings.Repeat(", ?", len(tracTicketColumns))+`)`, values...)
+
+		for name, value := range ticket.Attributes {
+			if name != "time" && name != "changetime" && !slices.Contains(tracTicketColumns, name) {
+				customFields[name] = true
+				exec(`INSERT INTO ticket_custom VALUES (?, ?, ?)`, id, name, fmt.Sprint(value))
+			}
+		}
+
+		changes := append(slices.Clone(ticket.Comments), ticket.History...)
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].Time.Before(changes[j].Time) })
+		for _, change := range changes {
+			exec(`INSERT INTO ticket_change VALUES (?, ?, ?, ?, ?, ?)`, id, change.Time.UnixMicro(), change.Author, change.Field, change.OldValue, change.NewValue)
+		}
+		attachments(trac.ResourceTicket, fmt.Sprint(id), ticket.Attachments)
+	}
+
+	milestones, err := src.GetMilestoneNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range milestones {
+		m, err := src.GetMilestoneByName(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		micro := func(t *time.Time) int64 {
+			if t == nil {
+				return 0
+			}
+			return t.UnixMicro()
+		}
+		exec(`INSERT INTO milestone VALUES (?, ?, ?, ?)`, name, micro(m.DueDate), micro(m.CompletedDate), m.Description)
+	}
+
+	pages, err := src.GetWikiPageNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range pages {
+		latest, err := src.GetWikiPageInfo(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for version := int64(1); version <= latest.Version; version++ {
+			info, err := src.GetWikiPageInfoVersion(ctx, name, version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text, err := src.GetWikiPageVersion(ctx, name, version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exec(`INSERT INTO wiki VALUES (?, ?, ?, ?, ?, ?, 0)`, name, version, info.LastModified.UnixMicro(), info.Author, *text, info.Comment)
+		}
+		attachments(trac.ResourceWiki, name, latest.Attachments)
+	}
+
+	var ini strings.Builder
+	ini.WriteString("[ticket-custom]\n")
+	for name := range customFields {
+		fmt.Fprintf(&ini, "%s = text\n", name)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "conf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "conf", "trac.ini"), []byte(ini.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// readExport reads all files of an export directory except the checkpoint. XML-RPC lists
+// wiki attachments by name only, so their time and size reported by Trac are left out.
+func readExport(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == checkpointFile {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(rel, "wiki/") && strings.HasSuffix(rel, ".json") {
+			data = withoutAttachmentDetails(t, data)
+		}
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	return files
+}
+
+// withoutAttachmentDetails removes the time and size of the attachments of a wiki JSON file
+func withoutAttachmentDetails(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to decode %s: %v", data, err)
+	}
+	attachments, _ := doc["attachments"].([]any)
+	for _, att := range attachments {
+		for _, key := range []string{"time", "size", "Time", "Size"} {
+			delete(att.(map[string]any), key)
+		}
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestExport_EnvMatchesXMLRPC(t *testing.T) {
+	backend := loadExportFixtures(t)
+
+	env, err := trac.OpenEnv(writeTracEnv(t, backend))
+	if err != nil {
+		t.Fatalf("OpenEnv failed: %v", err)
+	}
+	defer func() {
+		_ = env.Close()
+	}()
+
+	rpc := readExport(t, exportAll(t, fakeTracClient(t, backend)).ExportOptions.ExportDir)
+	local := readExport(t, exportAll(t, env).ExportOptions.ExportDir)
+
+	if len(rpc) == 0 {
+		t.Fatal("expected the XML-RPC export to write files")
+	}
+	for name, want := range rpc {
+		got, ok := local[name]
+		switch {
+		case !ok:
+			t.Errorf("%s is missing from the environment export", name)
+		case got != want:
+			t.Errorf("%s differs between the exports:\nXML-RPC     %s\nenvironment %s", name, want, got)
+		}
+	}
+	for name := range local {
+		if _, ok := rpc[name]; !ok {
+			t.Errorf("%s is only in the environment export", name)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/faketrac"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// exportFixtures describe a small Trac instance. Like Trac, every ticket carries all custom fields.
+var exportFixtures = map[string]string{
+	"tickets.json": `[{
+		"id": 1, "created": "2020-01-02T03:04:05Z", "changed": "2020-01-05T00:00:00Z",
+		"attributes": {"summary": "Crash on start", "description": "It {{{crashes}}}", "status": "closed",
+			"resolution": "fixed", "reporter": "alice", "milestone": "1.0", "customer": "ACME"},
+		"attachments": [{"filename": "trace.txt", "description": "Stack trace", "time": "2020-01-02T03:05:00Z", "author": "alice"}],
+		"changelog": [
+			{"time": "2020-01-05T00:00:00Z", "author": "bob", "field": "comment", "oldvalue": "1", "newvalue": "Fixed in r12"},
+			{"time": "2020-01-05T00:00:00Z", "author": "bob", "field": "status", "oldvalue": "new", "newvalue": "closed"}
+		]
+	}, {
+		"id": 2, "created": "2020-02-01T00:00:00Z",
+		"attributes": {"summary": "Add logo", "status": "new", "reporter": "bob", "customer": ""}
+	}]`,
+	"milestones.json":                     `[{"name": "1.0", "description": "First release", "completed_date": "2020-02-01T00:00:00Z"}]`,
+	"ticket_fields.json":                  `[{"label": "Priority", "name": "priority", "type": "select", "options": ["high", "low"]}]`,
+	"wiki.json":                           `[{"name": "WikiStart", "versions": [{"author": "alice", "time": "2020-01-01T00:00:00Z", "text": "= Start ="}, {"author": "bob", "time": "2020-01-05T00:00:00Z", "text": "= Start page ="}]}]`,
+	"attachments/ticket/1/trace.txt":      "panic: nil map",
+	"attachments/wiki/WikiStart/logo.png": "\x89PNG",
+}
+
+// loadExportFixtures writes exportFixtures to a fixture directory and loads it
+func loadExportFixtures(t *testing.T) *trac.Memory {
+	t.Helper()
+	fixtures := t.TempDir()
+	for name, content := range exportFixtures {
+		path := filepath.Join(fixtures, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backend, err := faketrac.LoadFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("LoadFixtures failed: %v", err)
+	}
+	return backend
+}
+
+// fakeTracClient serves the backend over XML-RPC and returns a client for it
+func fakeTracClient(t *testing.T, backend faketrac.Backend) *trac.Client {
+	t.Helper()
+	srv := httptest.NewServer(faketrac.NewServer(backend))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{}
+	cfg.Trac.BaseURL = srv.URL
+	cfg.Trac.RPCPath = "/login/rpc"
+	client, err := trac.NewTracClient(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DetectCapabilities(context.Background()); err != nil {
+		t.Fatalf("DetectCapabilities failed: %v", err)
+	}
+	return client
+}
+
+// exportAll exports tickets, milestones and the wiki with attachments of client into a new directory
+func exportAll(t *testing.T, client Source) *config.Config {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.ExportOptions.ExportDir = t.TempDir()
+	cfg.ExportOptions.IncludeWiki = true
+	cfg.ExportOptions.IncludeAttachments = true
+	cfg.ExportOptions.IncludeClosedTickets = true
+
+	ctx := context.Background()
+	sched := NewScheduler(cfg)
+	cp, err := OpenCheckpoint(cfg.ExportOptions.ExportDir, true)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint failed: %v", err)
+	}
+	defer func() {
+		_ = cp.Close()
+	}()
+
+	if err := ExportTickets(ctx, client, cfg, sched, cp); err != nil {
+		t.Fatalf("ExportTickets failed: %v", err)
+	}
+	if err := ExportMilestones(ctx, client, cfg, sched); err != nil {
+		t.Fatalf("ExportMilestones failed: %v", err)
+	}
+	if err := ExportWiki(ctx, client, cfg, sched, cp); err != nil {
+		t.Fatalf("ExportWiki failed: %v", err)
+	}
+	return cfg
+}
+
+func TestExport_FakeTrac(t *testing.T) {
+	client := fakeTracClient(t, loadExportFixtures(t))
+	cfg := exportAll(t, client)
+	if err := ExportTicketFields(context.Background(), client, cfg); err != nil {
+		t.Fatalf("ExportTicketFields failed: %v", err)
+	}
+
+	read := func(rel string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(cfg.ExportOptions.ExportDir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("expected %s to be exported: %v", rel, err)
+		}
+		return data
+	}
+
+	ticket, err := schema.ParseTicket(read("tickets/ticket-1.json"))
+	if err != nil {
+		t.Fatalf("failed to parse ticket 1: %v", err)
+	}
+	if ticket.SchemaVersion != schema.Version || ticket.Markdown || ticket.Description != "It {{{crashes}}}" {
+		t.Errorf("expected the raw description in the current schema, got %+v", ticket)
+	}
+	if ticket.Resolution != "fixed" || ticket.Milestone != "1.0" || ticket.CustomFields["customer"] != "ACME" {
+		t.Errorf("unexpected fields of ticket 1: %+v", ticket)
+	}
+	if len(ticket.Comments) != 1 || len(ticket.History) != 1 || ticket.History[0].Field != "status" {
+		t.Errorf("unexpected change log of ticket 1: comments %+v, history %+v", ticket.Comments, ticket.History)
+	}
+	if len(ticket.Attachments) != 1 || ticket.Attachments[0].SHA256 == "" {
+		t.Fatalf("expected the stored attachment of ticket 1, got %+v", ticket.Attachments)
+	}
+	if got := string(read(schema.AttachmentPath(ticket.ID, ticket.Attachments[0]))); got != "panic: nil map" {
+		t.Errorf("unexpected attachment content %q", got)
+	}
+	if _, err := schema.ParseTicket(read("tickets/ticket-2.json")); err != nil {
+		t.Errorf("failed to parse ticket 2: %v", err)
+	}
+
+	milestone, err := schema.ParseMilestone(read("milestones/milestone-1.0.json"))
+	if err != nil {
+		t.Fatalf("failed to parse milestone: %v", err)
+	}
+	if milestone.Name != "1.0" || milestone.Description == nil || *milestone.Description != "First release" || milestone.CompletedDate == nil {
+		t.Errorf("unexpected milestone %+v", milestone)
+	}
+
+	var fields []trac.TicketField
+	if err := json.Unmarshal(read(schema.TicketFieldsFile), &fields); err != nil || len(fields) != 1 || fields[0].Name != "priority" {
+		t.Errorf("unexpected ticket fields %+v: %v", fields, err)
+	}
+
+	if got := string(read("wiki/WikiStart.v1.wiki")); got != "= Start =" {
+		t.Errorf("unexpected first wiki version %q", got)
+	}
+	if got := string(read("wiki/WikiStart.v2.wiki")); got != "= Start page =" {
+		t.Errorf("unexpected latest wiki version %q", got)
+	}
+
+	var page schema.PageAttachments
+	if err := json.Unmarshal(read(schema.PageAttachmentsPath("WikiStart")), &page); err != nil {
+		t.Fatalf("failed to decode wiki attachments: %v", err)
+	}
+	if len(page.Attachments) != 1 || page.Attachments[0].Filename != "logo.png" {
+		t.Fatalf("unexpected wiki attachments %+v", page.Attachments)
+	}
+	if got := string(read(schema.BlobPath(page.Attachments[0].SHA256))); got != "\x89PNG" {
+		t.Errorf("unexpected wiki attachment content %q", got)
+	}
+}
@@ -0,0 +1,18 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bnidev/trac2gitlab/internal/utils"
+)
+
+// writeJSON atomically writes v as indented JSON
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %q: %w", filepath.Base(path), err)
+	}
+	return utils.WriteFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,280 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/converter"
+)
+
+// ManifestFileName is the name of the integrity manifest inside the export directory
+const ManifestFileName = "manifest.json"
+
+// Entity count keys of the manifest
+const (
+	CountTickets    = "tickets"
+	CountWikiPages  = "wiki_pages"
+	CountMilestones = "milestones"
+)
+
+// Manifest describes a complete export: every file with its checksum and the
+// number of entities Trac reported at export time
+type Manifest struct {
+	CreatedAt time.Time                 `json:"created_at"`
+	TracURL   string                    `json:"trac_url"`
+	Counts    map[string]int            `json:"counts"`
+	Files     map[string]CheckpointFile `json:"files"`
+}
+
+// ticketFilePattern matches exported ticket files relative to the export directory
+var ticketFilePattern = regexp.MustCompile(`^tickets/ticket-\d+\.json$`)
+
+// WriteManifest checksums all files of the export directory and writes the manifest
+// together with the entity counts reported by Trac
+func WriteManifest(ctx context.Context, client Source, config *config.Config) (*Manifest, error) {
+	counts, err := TracCounts(ctx, client, config)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := buildManifest(config.ExportOptions.ExportDir, counts)
+	if err != nil {
+		return nil, err
+	}
+	manifest.TracURL = config.Trac.BaseURL
+
+	if err := writeJSON(filepath.Join(config.ExportOptions.ExportDir, ManifestFileName), manifest); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// buildManifest checksums the data files of an export directory
+func buildManifest(exportDir string, counts map[string]int) (*Manifest, error) {
+	files, err := scanExportFiles(exportDir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		CreatedAt: time.Now().UTC(),
+		Counts:    counts,
+		Files:     make(map[string]CheckpointFile, len(files)),
+	}
+	for _, rel := range files {
+		sum, size, err := fileChecksum(filepath.Join(exportDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files[rel] = CheckpointFile{SHA256: sum, Size: size}
+	}
+	return manifest, nil
+}
+
+// LoadManifest reads the manifest of an export directory
+func LoadManifest(exportDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(exportDir, ManifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// RefreshManifest updates the checksums of rewritten files in the manifest of an
+// export directory, it does nothing if the export has no manifest
+func RefreshManifest(exportDir string, files []string) error {
+	manifest, err := LoadManifest(exportDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, rel := range files {
+		sum, size, err := fileChecksum(filepath.Join(exportDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		manifest.Files[rel] = CheckpointFile{SHA256: sum, Size: size}
+	}
+
+	if err := writeJSON(filepath.Join(exportDir, ManifestFileName), manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// TracCounts queries Trac for the number of entities the configured export covers
+func TracCounts(ctx context.Context, client Source, config *config.Config) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	ids, err := client.GetAllTicketIDs(ctx, ticketQuery(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tickets: %w", err)
+	}
+	counts[CountTickets] = len(ids)
+
+	milestones, err := client.GetMilestoneNames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count milestones: %w", err)
+	}
+	counts[CountMilestones] = len(milestones)
+
+	if config.ExportOptions.IncludeWiki {
+		pages, err := client.GetWikiPageNames(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count wiki pages: %w", err)
+		}
+		counts[CountWikiPages] = len(pages)
+	}
+
+	return counts, nil
+}
+
+// VerifyResult lists the problems found when checking an export against its manifest
+type VerifyResult struct {
+	Missing []string
+	Corrupt []string
+	Extra   []string
+	// Counts maps entity kinds to a description of the mismatch between expected and found entities
+	Counts map[string]string
+}
+
+// OK reports whether the export matched its manifest
+func (r *VerifyResult) OK() bool {
+	return len(r.Missing) == 0 && len(r.Corrupt) == 0 && len(r.Extra) == 0 && len(r.Counts) == 0
+}
+
+// VerifyExport checks the files of an export directory against its manifest and the
+// number of exported entities against the counts recorded in the manifest
+func VerifyExport(exportDir string, manifest *Manifest) (*VerifyResult, error) {
+	result := &VerifyResult{Counts: make(map[string]string)}
+
+	files, err := scanExportFiles(exportDir)
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, rel := range files {
+		present[rel] = true
+		if _, ok := manifest.Files[rel]; !ok {
+			result.Extra = append(result.Extra, rel)
+		}
+	}
+
+	for rel, recorded := range manifest.Files {
+		if !present[rel] {
+			result.Missing = append(result.Missing, rel)
+			continue
+		}
+		sum, size, err := fileChecksum(filepath.Join(exportDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		if sum != recorded.SHA256 || size != recorded.Size {
+			result.Corrupt = append(result.Corrupt, rel)
+		}
+	}
+
+	sort.Strings(result.Missing)
+	sort.Strings(result.Corrupt)
+	sort.Strings(result.Extra)
+
+	for kind, found := range CountExportedEntities(files) {
+		expected, ok := manifest.Counts[kind]
+		if ok && expected != found {
+			result.Counts[kind] = fmt.Sprintf("Trac reported %d, export contains %d", expected, found)
+		}
+	}
+
+	return result, nil
+}
+
+// CountExportedEntities counts the tickets, wiki pages and milestones among the
+// files of an export, given relative to the export directory
+func CountExportedEntities(files []string) map[string]int {
+	counts := map[string]int{CountTickets: 0, CountWikiPages: 0, CountMilestones: 0}
+	pages := make(map[string]bool)
+
+	for _, rel := range files {
+		switch {
+		case ticketFilePattern.MatchString(rel):
+			counts[CountTickets]++
+		case strings.HasPrefix(rel, "milestones/milestone-") && strings.HasSuffix(rel, ".json"):
+			counts[CountMilestones]++
+		case strings.HasPrefix(rel, "wiki/") && !strings.HasPrefix(rel, "wiki/attachments/") && strings.HasSuffix(rel, ".wiki"):
+			name, _ := converter.WikiPageFromFile(strings.TrimPrefix(rel, "wiki/"))
+			pages[name] = true
+		}
+	}
+
+	counts[CountWikiPages] = len(pages)
+	return counts
+}
+
+// scanExportFiles lists the data files of an export directory relative to it, leaving out
+// bookkeeping files, temporary files and the output of offline conversion
+func scanExportFiles(exportDir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(exportDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(exportDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel == "converted" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isBookkeepingFile(rel) {
+			return nil
+		}
+
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan export directory: %w", err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// isBookkeepingFile reports whether a file of the export directory is not part of the exported data
+func isBookkeepingFile(rel string) bool {
+	base := filepath.Base(rel)
+	switch {
+	case rel == ManifestFileName, rel == checkpointFile:
+		return true
+	case strings.HasPrefix(base, ".") && strings.Contains(base, ".tmp-"):
+		return true
+	case !strings.Contains(rel, "/") && strings.HasPrefix(base, "conversion-report"):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyExport(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", rel, err)
+		}
+	}
+
+	write("tickets/ticket-1.json", `{"ID":1}`)
+	write("tickets/ticket-2.json", `{"ID":2}`)
+	write("tickets/attachments/1/log.txt", "log")
+	write("wiki/WikiStart.v1.wiki", "= Welcome =")
+	write("wiki/WikiStart.v2.wiki", "= Welcome! =")
+	write(checkpointFile, "{}\n")
+
+	manifest, err := buildManifest(dir, map[string]int{CountTickets: 2, CountWikiPages: 1})
+	if err != nil {
+		t.Fatalf("failed to build manifest: %v", err)
+	}
+	if len(manifest.Files) != 5 {
+		t.Fatalf("expected 5 files in manifest, got %d", len(manifest.Files))
+	}
+
+	result, err := VerifyExport(dir, manifest)
+	if err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	if !result.OK() {
+		t.Fatalf("expected untouched export to verify, got %+v", result)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "tickets", "ticket-2.json")); err != nil {
+		t.Fatalf("failed to remove ticket: %v", err)
+	}
+	write("wiki/WikiStart.v1.wiki", "= Changed =")
+	write("tickets/ticket-3.json", `{"ID":3}`)
+
+	result, err = VerifyExport(dir, manifest)
+	if err != nil {
+		t.Fatalf("verification failed: %v", err)
+	}
+	if want := []string{"tickets/ticket-2.json"}; !reflect.DeepEqual(result.Missing, want) {
+		t.Errorf("expected missing %v, got %v", want, result.Missing)
+	}
+	if want := []string{"wiki/WikiStart.v1.wiki"}; !reflect.DeepEqual(result.Corrupt, want) {
+		t.Errorf("expected corrupt %v, got %v", want, result.Corrupt)
+	}
+	if want := []string{"tickets/ticket-3.json"}; !reflect.DeepEqual(result.Extra, want) {
+		t.Errorf("expected extra %v, got %v", want, result.Extra)
+	}
+	if len(result.Counts) != 0 {
+		t.Errorf("expected matching ticket count, got %v", result.Counts)
+	}
+}
@@ -1,21 +1,26 @@
 package exporter
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 
 	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
 	"github.com/bnidev/trac2gitlab/pkg/trac"
 )
 
 // ExportMilestones exports milestones from Trac and saves them as JSON files
-func ExportMilestones(client *trac.Client, config *config.Config) error {
+func ExportMilestones(ctx context.Context, client MilestoneSource, config *config.Config, sched *Scheduler) error {
 	slog.Info("Starting milestone export...")
 
-	milestoneNames, err := client.GetMilestoneNames()
+	if !supports(client, trac.CapMilestones, "skipping the milestone export") {
+		return nil
+	}
+
+	milestoneNames, err := client.GetMilestoneNames(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get milestone names: %w", err)
 	}
@@ -27,31 +32,35 @@ func ExportMilestones(client *trac.Client, config *config.Config) error {
 
 	slog.Debug("Milestones found", "count", len(milestoneNames))
 
+	group := sched.Group(ctx, TaskMilestone)
 	for _, name := range milestoneNames {
-		milestone, err := client.GetMilestoneByName(name)
-		if err != nil {
-			slog.Warn("Failed to fetch milestone", "name", name, "error", err)
-			continue
-		}
+		group.Go(func() {
+			exportSingleMilestone(ctx, client, milestonesDir, name)
+		})
+	}
+	group.Wait()
 
-		filename := filepath.Join(milestonesDir, fmt.Sprintf("milestone-%s.json", name))
-		file, err := os.Create(filename)
-		if err != nil {
-			slog.Warn("Failed to create milestone file", "name", name, "error", err)
-			continue
-		}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("milestone export interrupted: %w", err)
+	}
 
-		encoder := json.NewEncoder(file)
-		encoder.SetIndent("", "  ")
-		if err := encoder.Encode(milestone); err != nil {
-			slog.Warn("Failed to encode milestone", "name", name, "error", err)
-		}
+	slog.Info("Milestone export completed", "count", len(milestoneNames))
+	return nil
+}
 
-		if cerr := file.Close(); cerr != nil {
-			slog.Warn("File closed with error", "name", name, "error", cerr)
+// exportSingleMilestone fetches a milestone and writes it as JSON, failures are logged
+func exportSingleMilestone(ctx context.Context, client MilestoneSource, milestonesDir, name string) {
+	milestone, err := client.GetMilestoneByName(ctx, name)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
 		}
+		slog.Warn("Failed to fetch milestone", "name", name, "error", err)
+		return
 	}
 
-	slog.Info("Milestone export completed", "count", len(milestoneNames))
-	return nil
+	filename := filepath.Join(milestonesDir, fmt.Sprintf("milestone-%s.json", name))
+	if err := writeJSON(filename, schema.MilestoneFromTrac(milestone)); err != nil {
+		slog.Warn("Failed to write milestone", "name", name, "error", err)
+	}
 }
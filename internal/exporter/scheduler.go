@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/pkg/xmlrpc"
+
+	"golang.org/x/time/rate"
+)
+
+// Default limits used when the concurrency options are not configured
+const (
+	defaultMaxRequests       = 10
+	defaultTicketWorkers     = 10
+	defaultAttachmentWorkers = 4
+	defaultWikiWorkers       = 10
+	defaultMilestoneWorkers  = 4
+)
+
+// TaskKind identifies a type of export work with its own concurrency limit
+type TaskKind string
+
+const (
+	TaskTicket     TaskKind = "tickets"
+	TaskAttachment TaskKind = "attachments"
+	TaskWiki       TaskKind = "wiki_pages"
+	TaskMilestone  TaskKind = "milestones"
+)
+
+// Scheduler coordinates the load the export puts on Trac. All XML-RPC requests share
+// the global request and rate limits of its transport, every kind of work has its own
+// number of workers.
+type Scheduler struct {
+	maxRequests       int
+	requestsPerSecond float64
+	slots             map[TaskKind]chan struct{}
+}
+
+// NewScheduler creates a scheduler from the concurrency options of the configuration
+func NewScheduler(config *config.Config) *Scheduler {
+	opts := config.ExportOptions.Concurrency
+
+	return &Scheduler{
+		maxRequests:       orDefault(opts.MaxRequests, defaultMaxRequests),
+		requestsPerSecond: opts.RequestsPerSecond,
+		slots: map[TaskKind]chan struct{}{
+			TaskTicket:     make(chan struct{}, orDefault(opts.Tickets, defaultTicketWorkers)),
+			TaskAttachment: make(chan struct{}, orDefault(opts.Attachments, defaultAttachmentWorkers)),
+			TaskWiki:       make(chan struct{}, orDefault(opts.WikiPages, defaultWikiWorkers)),
+			TaskMilestone:  make(chan struct{}, orDefault(opts.Milestones, defaultMilestoneWorkers)),
+		},
+	}
+}
+
+// Transport wraps base so that requests respect the global request and rate limits
+func (s *Scheduler) Transport(base http.RoundTripper) http.RoundTripper {
+	var limiter xmlrpc.Limiter
+	if s.requestsPerSecond > 0 {
+		burst := max(1, int(s.requestsPerSecond))
+		limiter = rate.NewLimiter(rate.Limit(s.requestsPerSecond), burst)
+	}
+	return xmlrpc.NewThrottledTransport(base, s.maxRequests, limiter)
+}
+
+// Group starts a new group of tasks of the given kind, no new tasks are started once ctx is cancelled
+func (s *Scheduler) Group(ctx context.Context, kind TaskKind) *TaskGroup {
+	return &TaskGroup{ctx: ctx, slots: s.slots[kind]}
+}
+
+// TaskGroup runs tasks of one kind within the worker limit of that kind
+type TaskGroup struct {
+	ctx   context.Context
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// Go runs fn in a new goroutine, blocking until a worker of this kind is free.
+// fn is not run if the context of the group is cancelled while waiting.
+func (g *TaskGroup) Go(fn func()) {
+	select {
+	case g.slots <- struct{}{}:
+	case <-g.ctx.Done():
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() { <-g.slots }()
+		fn()
+	}()
+}
+
+// Wait blocks until all tasks of the group finished
+func (g *TaskGroup) Wait() {
+	g.wg.Wait()
+}
+
+// orDefault returns value, or fallback if value is not positive
+func orDefault(value, fallback int) int {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
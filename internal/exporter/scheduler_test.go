@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+)
+
+func TestScheduler_GroupLimit(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ExportOptions.Concurrency.Milestones = 2
+	sched := NewScheduler(cfg)
+
+	var running, peak atomic.Int32
+	group := sched.Group(context.Background(), TaskMilestone)
+	for range 8 {
+		group.Go(func() {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		})
+	}
+	group.Wait()
+
+	if got := peak.Load(); got != 2 {
+		t.Errorf("expected at most 2 concurrent tasks, got %d", got)
+	}
+}
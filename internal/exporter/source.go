@@ -0,0 +1,89 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// TicketSource lists and reads tickets with their attributes, attachments and change log
+type TicketSource interface {
+	GetAllTicketIDs(ctx context.Context, query string) ([]int, error)
+	GetRecentTicketChanges(ctx context.Context, since time.Time) ([]int, error)
+	GetTicket(ctx context.Context, id int) (*trac.Ticket, error)
+}
+
+// ChangeLogSource reads the description, status, resolution and comment entries of a
+// ticket's change log, the comments are returned separately
+type ChangeLogSource interface {
+	GetTicketHistory(ctx context.Context, id int) ([]trac.ChangeLogEntry, []trac.ChangeLogEntry, error)
+}
+
+// AttachmentSource lists the attachments of tickets and wiki pages and streams their content
+type AttachmentSource interface {
+	ListAttachments(ctx context.Context, resType trac.ResourceType, id any) ([]trac.Attachment, error)
+	DownloadAttachment(ctx context.Context, resType trac.ResourceType, id any, filename string, w io.Writer) (int64, error)
+}
+
+// WikiSource reads wiki pages and their history
+type WikiSource interface {
+	GetWikiPageNames(ctx context.Context) ([]string, error)
+	GetWikiPageInfo(ctx context.Context, pageName string) (*trac.WikiPage, error)
+	GetWikiPageInfoVersion(ctx context.Context, pageName string, version int64) (*trac.WikiPage, error)
+	GetWikiPageVersion(ctx context.Context, pageName string, version int64) (*string, error)
+}
+
+// MilestoneSource reads milestones
+type MilestoneSource interface {
+	GetMilestoneNames(ctx context.Context) ([]string, error)
+	GetMilestoneByName(ctx context.Context, name string) (*trac.Milestone, error)
+}
+
+// ComponentSource reads components and their default owners. The web pages do not show them.
+type ComponentSource interface {
+	GetComponentNames(ctx context.Context) ([]string, error)
+	GetComponentByName(ctx context.Context, name string) (*trac.Component, error)
+}
+
+// WorkflowSource reads the ticket workflow. The web pages do not show it.
+type WorkflowSource interface {
+	GetWorkflow(ctx context.Context) (*trac.Workflow, error)
+}
+
+// FieldSource reads the ticket field definitions
+type FieldSource interface {
+	GetTicketFields(ctx context.Context) ([]trac.TicketField, error)
+}
+
+// Source provides all Trac data the exporters read. It is implemented by the XML-RPC
+// client, by a Trac environment read from disk and by the in-memory trac.Memory.
+type Source interface {
+	TicketSource
+	ChangeLogSource
+	AttachmentSource
+	WikiSource
+	MilestoneSource
+	FieldSource
+}
+
+// userDirectory is implemented by sources that know the names and email addresses of users
+type userDirectory interface {
+	GetUsers(ctx context.Context) ([]trac.User, error)
+}
+
+var (
+	_ Source          = (*trac.Client)(nil)
+	_ Source          = (*trac.Env)(nil)
+	_ Source          = (*trac.Memory)(nil)
+	_ Source          = (*trac.Web)(nil)
+	_ ComponentSource = (*trac.Client)(nil)
+	_ ComponentSource = (*trac.Env)(nil)
+	_ ComponentSource = (*trac.Memory)(nil)
+	_ WorkflowSource  = (*trac.Client)(nil)
+	_ WorkflowSource  = (*trac.Env)(nil)
+	_ WorkflowSource  = (*trac.Memory)(nil)
+	_ userDirectory   = (*trac.Env)(nil)
+	_ userDirectory   = (*trac.Memory)(nil)
+)
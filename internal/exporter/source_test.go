@@ -0,0 +1,167 @@
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// memorySource returns an in-memory Trac with an open and a closed ticket, a milestone,
+// a component and a wiki page with two versions and an attachment
+func memorySource() *trac.Memory {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	comment := "Fixed"
+	src := trac.NewMemory()
+	src.AddTicket(trac.Ticket{
+		ID: 1, TimeCreated: created, TimeChanged: created,
+		Attributes:  map[string]any{"summary": "Crash", "status": "closed", "reporter": "alice", "owner": "bob"},
+		Attachments: []trac.Attachment{{Filename: "trace.txt", Size: 5, Time: created, Author: "alice"}},
+		Comments:    []trac.ChangeLogEntry{{Time: created, Author: "carol", Field: "comment", NewValue: &comment, Permanent: 1}},
+	})
+	src.AddTicket(trac.Ticket{
+		ID: 2, TimeCreated: created, TimeChanged: created,
+		Attributes: map[string]any{"summary": "Polish", "status": "new", "reporter": "dave"},
+	})
+	src.AddAttachment(trac.ResourceTicket, 1, trac.Attachment{Filename: "trace.txt"}, []byte("trace"))
+	src.AddMilestone(trac.Milestone{Name: "1.0"})
+	src.AddComponent(trac.Component{Name: "core", Owner: "bob", Description: "The engine"})
+	src.AddWikiVersion("WikiStart", "alice", "", "= Welcome =", created)
+	src.AddWikiVersion("WikiStart", "bob", "typo", "= Welcome! =", created.Add(time.Hour))
+	src.AddAttachment(trac.ResourceWiki, "WikiStart", trac.Attachment{Filename: "logo.png", Size: 4}, []byte("logo"))
+	src.AddUser(trac.User{Username: "alice", Email: "alice@example.com"})
+	return src
+}
+
+func TestExport_FromMemorySource(t *testing.T) {
+	ctx := context.Background()
+	src := memorySource()
+
+	cfg := &config.Config{ExportOptions: config.ExportOptions{
+		ExportDir:            t.TempDir(),
+		IncludeAttachments:   true,
+		IncludeClosedTickets: true,
+		IncludeWiki:          true,
+		IncludeUsers:         true,
+	}}
+	exportDir := cfg.ExportOptions.ExportDir
+
+	cp, err := OpenCheckpoint(exportDir, false)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint returned error: %v", err)
+	}
+	defer func() {
+		_ = cp.Close()
+	}()
+	sched := NewScheduler(cfg)
+
+	if err := ExportTickets(ctx, src, cfg, sched, cp); err != nil {
+		t.Fatalf("ExportTickets returned error: %v", err)
+	}
+	if err := ExportMilestones(ctx, src, cfg, sched); err != nil {
+		t.Fatalf("ExportMilestones returned error: %v", err)
+	}
+	if err := ExportWiki(ctx, src, cfg, sched, cp); err != nil {
+		t.Fatalf("ExportWiki returned error: %v", err)
+	}
+	if err := ExportUsers(ctx, src, cfg); err != nil {
+		t.Fatalf("ExportUsers returned error: %v", err)
+	}
+	if err := ExportComponents(ctx, src, cfg); err != nil {
+		t.Fatalf("ExportComponents returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(exportDir, "tickets", "ticket-1.json"))
+	if err != nil {
+		t.Fatalf("ticket 1 was not exported: %v", err)
+	}
+	ticket, err := schema.ParseTicket(data)
+	if err != nil {
+		t.Fatalf("exported ticket is invalid: %v", err)
+	}
+	if ticket.Summary != "Crash" || len(ticket.Comments) != 1 || len(ticket.Attachments) != 1 {
+		t.Errorf("unexpected ticket %+v", ticket)
+	}
+	if blob := ticket.Attachments[0].SHA256; blob == "" {
+		t.Error("attachment was not downloaded")
+	} else if content, err := os.ReadFile(filepath.Join(exportDir, filepath.FromSlash(schema.BlobPath(blob)))); err != nil || string(content) != "trace" {
+		t.Errorf("unexpected attachment blob %q: %v", content, err)
+	}
+
+	for _, rel := range []string{
+		"tickets/ticket-2.json",
+		"wiki/WikiStart.v1.wiki",
+		"wiki/WikiStart.v2.wiki",
+		"wiki/attachments/WikiStart.json",
+		"users.txt",
+		"users.json",
+		schema.ComponentsFile,
+	} {
+		if _, err := os.Stat(filepath.Join(exportDir, filepath.FromSlash(rel))); err != nil {
+			t.Errorf("expected %s in the export: %v", rel, err)
+		}
+	}
+
+	milestones, err := os.ReadDir(filepath.Join(exportDir, "milestones"))
+	if err != nil || len(milestones) != 1 {
+		t.Errorf("expected 1 milestone file, got %d: %v", len(milestones), err)
+	}
+
+	// Closed tickets are left out unless configured
+	cfg.ExportOptions.IncludeClosedTickets = false
+	counts, err := TracCounts(ctx, src, cfg)
+	if err != nil {
+		t.Fatalf("TracCounts returned error: %v", err)
+	}
+	if counts[CountTickets] != 1 || counts[CountMilestones] != 1 || counts[CountWikiPages] != 1 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+}
+
+func TestExportTickets_ReexportsOlderSchema(t *testing.T) {
+	ctx := context.Background()
+	src := memorySource()
+	cfg := &config.Config{ExportOptions: config.ExportOptions{ExportDir: t.TempDir(), IncludeClosedTickets: true}}
+	exportDir := cfg.ExportOptions.ExportDir
+
+	// Ticket 1 was completed by a release writing schema version 2
+	path := filepath.Join(exportDir, "tickets", "ticket-1.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"schema_version": 2, "id": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := OpenCheckpoint(exportDir, false)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint returned error: %v", err)
+	}
+	defer func() {
+		_ = cp.Close()
+	}()
+	changed := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := cp.Complete(ticketKey(1), &changed, 0, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExportTickets(ctx, src, cfg, NewScheduler(cfg), cp); err != nil {
+		t.Fatalf("ExportTickets returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := schema.ParseTicket(data)
+	if err != nil || ticket.SchemaVersion != schema.Version || ticket.Summary != "Crash" {
+		t.Errorf("expected ticket 1 to be exported again, got %+v: %v", ticket, err)
+	}
+	if !cp.Done(ticketKey(1), &changed, schema.Version) {
+		t.Error("expected ticket 1 to be recorded with the current schema version")
+	}
+}
@@ -1,14 +1,14 @@
 package exporter
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log/slog"
-	"os"
 	"path/filepath"
 	"slices"
 
 	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
 	"github.com/bnidev/trac2gitlab/pkg/trac"
 )
 
@@ -19,13 +19,17 @@ type ExportTicketField struct {
 }
 
 // ExportTicketFields exports ticket fields from Trac
-func ExportTicketFields(client *trac.Client, config *config.Config) error {
+func ExportTicketFields(ctx context.Context, client FieldSource, config *config.Config) error {
 	slog.Info("Starting ticket field export...")
 
-	defaultFields := []string{"priority", "component", "type"}
+	if !supports(client, trac.CapTicketFields, "skipping the ticket field export") {
+		return nil
+	}
+
+	defaultFields := []string{"priority", "component", "type", "status", "resolution"}
 	additionalFields := config.ExportOptions.AdditionalTicketFields
 
-	fields, err := client.GetTicketFields()
+	fields, err := client.GetTicketFields(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get ticket fields: %w", err)
 	}
@@ -47,18 +51,9 @@ func ExportTicketFields(client *trac.Client, config *config.Config) error {
 		slog.Info("No default ticket fields found to export")
 	}
 
-	filename := filepath.Join(config.ExportOptions.ExportDir, "ticket-fields.json")
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(exportFields); err != nil {
-		return fmt.Errorf("failed to encode ticket fields to JSON: %w", err)
-	}
-	if cerr := file.Close(); cerr != nil {
-		return fmt.Errorf("failed to close file: %w", cerr)
+	filename := filepath.Join(config.ExportOptions.ExportDir, schema.TicketFieldsFile)
+	if err := writeJSON(filename, exportFields); err != nil {
+		return fmt.Errorf("failed to write ticket fields: %w", err)
 	}
 
 	return nil
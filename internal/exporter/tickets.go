@@ -1,27 +1,42 @@
 package exporter
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
 	"github.com/bnidev/trac2gitlab/pkg/trac"
 )
 
-// ExportTickets exports tickets from Trac and saves them as JSON files
-func ExportTickets(client *trac.Client, config *config.Config) error {
+// ticketProgressInterval is the number of tickets between progress log messages
+const ticketProgressInterval = 100
+
+// ExportTickets exports tickets from Trac and saves them as JSON files.
+// Tickets recorded in the checkpoint that did not change since are skipped, unless an
+// older release exported them with a previous schema version.
+func ExportTickets(ctx context.Context, client Source, config *config.Config, sched *Scheduler, cp *Checkpoint) error {
 	slog.Info("Starting ticket export...")
 
-	query := "max=0"
-	if !config.ExportOptions.IncludeClosedTickets {
-		query += "&status!=closed"
+	if !SourceCapabilities(client).Has(trac.CapTickets) {
+		return fmt.Errorf("trac does not support listing and reading tickets")
 	}
+	supports(client, trac.CapTicketChangeLog, "tickets are exported without comments and history")
+	includeAttachments := config.ExportOptions.IncludeAttachments &&
+		supports(client, trac.CapTicketAttachments, "tickets are exported without attachments")
 
-	ids, err := client.GetAllTicketIDs(query)
+	policy, err := newAttachmentPolicy(config)
+	if err != nil {
+		return err
+	}
+
+	ids, err := client.GetAllTicketIDs(ctx, ticketQuery(config))
 	if err != nil {
 		return fmt.Errorf("failed to get ticket IDs: %w", err)
 	}
@@ -33,100 +48,136 @@ func ExportTickets(client *trac.Client, config *config.Config) error {
 
 	slog.Debug("Tickets found", "count", len(ids))
 
-	const maxWorkers = 10
-	var wg sync.WaitGroup
-	ticketChan := make(chan int, len(ids))
-
-	for range make([]struct{}, maxWorkers) {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for id := range ticketChan {
-				if err := exportSingleTicket(client, ticketsDir, id, config.ExportOptions.IncludeAttachments); err != nil {
-					slog.Error("Failed to export ticket", "ticketID", id, "error", err)
+	// Tickets changed since the export started have to be exported again
+	var changedSince map[int]bool
+	if done := cp.Count("ticket:"); done > 0 {
+		if supports(client, trac.CapRecentChanges, "comparing change times to find changed tickets") {
+			// Allow for clock skew between this machine and the Trac server
+			since := cp.StartedAt().Add(-time.Hour)
+			recent, err := client.GetRecentTicketChanges(ctx, since)
+			if err != nil {
+				slog.Warn("Failed to get recent ticket changes, comparing change times instead", "error", err)
+			} else {
+				changedSince = make(map[int]bool, len(recent))
+				for _, id := range recent {
+					changedSince[id] = true
 				}
 			}
-		}()
+		}
+		slog.Info("Resuming ticket export", "completed", done, "total", len(ids), "changedSinceStart", len(changedSince))
 	}
 
+	var processed, skipped atomic.Int64
+
+	group := sched.Group(ctx, TaskTicket)
 	for _, id := range ids {
-		ticketChan <- id
+		group.Go(func() {
+			if changedSince != nil && !changedSince[id] && cp.Done(ticketKey(id), nil, schema.Version) {
+				skipped.Add(1)
+			} else if err := exportSingleTicket(ctx, client, sched, cp, policy, config.ExportOptions.ExportDir, id, includeAttachments); err != nil {
+				if ctx.Err() == nil {
+					slog.Error("Failed to export ticket", "ticketID", id, "error", err)
+				}
+			}
+
+			if n := processed.Add(1); n%ticketProgressInterval == 0 {
+				slog.Info("Ticket export progress", "processed", n, "total", len(ids), "skipped", skipped.Load())
+			}
+		})
 	}
-	close(ticketChan)
+	group.Wait()
 
-	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("ticket export interrupted after %d of %d tickets: %w", processed.Load(), len(ids), err)
+	}
 
-	slog.Info("Ticket export completed", "count", len(ids))
+	slog.Info("Ticket export completed", "count", len(ids), "skipped", skipped.Load())
 	return nil
 }
 
-// exportSingleTicket exports a single ticket and its attachments
-func exportSingleTicket(client *trac.Client, ticketsDir string, id int, includeAttachments bool) error {
+// ticketQuery returns the Trac query selecting the tickets to export
+func ticketQuery(config *config.Config) string {
+	query := "max=0"
+	if !config.ExportOptions.IncludeClosedTickets {
+		query += "&status!=closed"
+	}
+	return query
+}
+
+// ticketKey returns the checkpoint key of a ticket
+func ticketKey(id int) string {
+	return fmt.Sprintf("ticket:%d", id)
+}
+
+// exportSingleTicket exports a single ticket and stores its attachments in the blob store
+func exportSingleTicket(ctx context.Context, client Source, sched *Scheduler, cp *Checkpoint, policy *attachmentPolicy, exportDir string, id int, includeAttachments bool) error {
 	slog.Debug("Exporting ticket", "ticketID", id)
-	ticket, err := client.GetTicket(id)
+	ticket, err := client.GetTicket(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to fetch ticket: %w", err)
 	}
 
-	// Write ticket JSON
-	filename := filepath.Join(ticketsDir, fmt.Sprintf("ticket-%d.json", id))
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(ticket); err != nil {
-		slog.Warn("Failed to encode ticket", "ticketID", id, "error", err)
-	}
-	if cerr := file.Close(); cerr != nil {
-		slog.Warn("Failed to close ticket file", "ticketID", id, "error", cerr)
+	if cp.Done(ticketKey(id), &ticket.TimeChanged, schema.Version) {
+		slog.Debug("Ticket unchanged since last export, skipping", "ticketID", id)
+		return nil
 	}
 
-	// Download attachments
-	if includeAttachments && len(ticket.Attachments) > 0 {
-		attachmentsDir := filepath.Join(ticketsDir, "attachments", fmt.Sprintf("%d", id))
-		if err := os.MkdirAll(attachmentsDir, 0755); err != nil {
-			return fmt.Errorf("failed to create attachments directory for ticket #%d: %w", id, err)
-		}
-
-		var attWg sync.WaitGroup
-		attErrs := make(chan error, len(ticket.Attachments))
+	exported := schema.FromTrac(ticket)
 
-		for _, att := range ticket.Attachments {
-			att := att
-			attWg.Add(1)
-			go func() {
-				defer attWg.Done()
+	// Files written for this ticket, recorded in the checkpoint once everything succeeded
+	files := []string{}
+	var errCount int
 
-				content, err := trac.GetAttachment(client, trac.ResourceTicket, id, att.Filename)
+	// Download attachments
+	if includeAttachments && len(exported.Attachments) > 0 {
+		var attMu sync.Mutex
+		attErrs := make(chan error, len(exported.Attachments))
+
+		group := sched.Group(ctx, TaskAttachment)
+		for i := range exported.Attachments {
+			att := &exported.Attachments[i]
+			group.Go(func() {
+				blobPath, err := downloadAttachment(ctx, client, policy, exportDir, trac.ResourceTicket, id, att)
 				if err != nil {
 					attErrs <- fmt.Errorf("failed to download attachment %q for ticket #%d: %w", att.Filename, id, err)
 					return
 				}
-
-				safeFilename := filepath.Base(att.Filename)
-				attPath := filepath.Join(attachmentsDir, safeFilename)
-
-				if err := os.WriteFile(attPath, content, 0644); err != nil {
-					attErrs <- fmt.Errorf("failed to write attachment %q for ticket #%d: %w", att.Filename, id, err)
+				if blobPath == "" {
 					return
 				}
-			}()
+
+				attMu.Lock()
+				files = append(files, blobPath)
+				attMu.Unlock()
+			})
 		}
 
-		attWg.Wait()
+		group.Wait()
 		close(attErrs)
 
-		var errCount int
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		for err := range attErrs {
 			slog.Warn("Attachment error", "error", err)
 			errCount++
 		}
+	}
 
-		if errCount > 0 {
-			return fmt.Errorf("%d attachment(s) failed for ticket #%d", errCount, id)
-		}
+	// Write ticket JSON, attachments that failed are listed without a blob
+	filename := filepath.Join(exportDir, "tickets", fmt.Sprintf("ticket-%d.json", id))
+	if err := writeJSON(filename, exported); err != nil {
+		return fmt.Errorf("failed to write ticket: %w", err)
+	}
+	files = append(files, filename)
+
+	if errCount > 0 {
+		return fmt.Errorf("%d attachment(s) failed for ticket #%d", errCount, id)
+	}
+
+	if err := cp.Complete(ticketKey(id), &ticket.TimeChanged, schema.Version, files...); err != nil {
+		return fmt.Errorf("failed to record ticket #%d in checkpoint: %w", id, err)
 	}
 
 	return nil
@@ -1,19 +1,22 @@
 package exporter
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 
 	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/utils"
 	"github.com/bnidev/trac2gitlab/pkg/trac"
 )
 
 // ExportUsers exports unique users from Trac tickets and saves them to a file
-func ExportUsers(client *trac.Client, config *config.Config) error {
-	ids, err := client.GetAllTicketIDs("max=0")
+func ExportUsers(ctx context.Context, client TicketSource, config *config.Config) error {
+	ids, err := client.GetAllTicketIDs(ctx, "max=0")
 	if err != nil {
 		return fmt.Errorf("failed to get ticket IDs: %w", err)
 	}
@@ -24,7 +27,7 @@ func ExportUsers(client *trac.Client, config *config.Config) error {
 
 	for _, id := range ids {
 
-		ticket, err := client.GetTicket(id)
+		ticket, err := client.GetTicket(ctx, id)
 		if err != nil {
 			return fmt.Errorf("failed to fetch ticket: %w", err)
 		}
@@ -94,19 +97,38 @@ func ExportUsers(client *trac.Client, config *config.Config) error {
 		return fmt.Errorf("failed to create tickets directory: %w", err)
 	}
 	usersFile := filepath.Join(config.ExportOptions.ExportDir, "users.txt")
-	file, err := os.Create(usersFile)
-	if err != nil {
-		return fmt.Errorf("failed to create users file: %w", err)
-	}
+	var content strings.Builder
 	for _, user := range users {
-		if _, err := file.WriteString(user + "\n"); err != nil {
-			return fmt.Errorf("failed to write user to file: %w", err)
-		}
+		content.WriteString(user + "\n")
+	}
+	if err := utils.WriteFileAtomic(usersFile, []byte(content.String())); err != nil {
+		return fmt.Errorf("failed to write users file: %w", err)
 	}
-	if err := file.Close(); err != nil {
-		return fmt.Errorf("failed to close users file: %w", err)
+
+	if dir, ok := client.(userDirectory); ok {
+		if err := exportUserDetails(ctx, dir, config.ExportOptions.ExportDir); err != nil {
+			return err
+		}
 	}
 
 	slog.Info("User export completed", "count", len(users))
 	return nil
 }
+
+// exportUserDetails saves the names and email addresses of the users known to the source to users.json
+func exportUserDetails(ctx context.Context, dir userDirectory, exportDir string) error {
+	details, err := dir.GetUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get user details: %w", err)
+	}
+	if details == nil {
+		details = []trac.User{}
+	}
+
+	if err := writeJSON(filepath.Join(exportDir, "users.json"), details); err != nil {
+		return fmt.Errorf("failed to write user details: %w", err)
+	}
+
+	slog.Info("Exported user details", "count", len(details))
+	return nil
+}
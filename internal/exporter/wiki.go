@@ -1,7 +1,7 @@
 package exporter
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -9,14 +9,28 @@ import (
 	"sync"
 
 	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/internal/utils"
 	"github.com/bnidev/trac2gitlab/pkg/trac"
 )
 
-// ExportWiki exports wiki pages from Trac and saves them as Markdown files
-func ExportWiki(client *trac.Client, config *config.Config) error {
+// ExportWiki exports wiki pages from Trac and saves their raw markup and metadata.
+// Page versions and attachments recorded in the checkpoint are skipped.
+func ExportWiki(ctx context.Context, client Source, config *config.Config, sched *Scheduler, cp *Checkpoint) error {
 	slog.Info("Starting wiki export...")
 
-	pages, err := client.GetWikiPageNames()
+	if !supports(client, trac.CapWiki, "skipping the wiki export") {
+		return nil
+	}
+	includeAttachments := config.ExportOptions.IncludeAttachments &&
+		supports(client, trac.CapWikiAttachments, "wiki pages are exported without attachments")
+
+	policy, err := newAttachmentPolicy(config)
+	if err != nil {
+		return err
+	}
+
+	pages, err := client.GetWikiPageNames(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get wiki page names: %w", err)
 	}
@@ -27,35 +41,35 @@ func ExportWiki(client *trac.Client, config *config.Config) error {
 
 	slog.Debug("Wiki pages found", "count", len(pages))
 
+	if done := cp.Count("wiki:"); done > 0 {
+		slog.Info("Resuming wiki export", "completedVersions", done, "pages", len(pages))
+	}
+
 	wikiDir := filepath.Join(config.ExportOptions.ExportDir, "wiki")
 	if err := os.MkdirAll(wikiDir, 0755); err != nil {
 		return fmt.Errorf("failed to create wiki directory: %w", err)
 	}
 
-	const concurrency = 10
-	sem := make(chan struct{}, concurrency)
-	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var firstErr error
 
+	group := sched.Group(ctx, TaskWiki)
 	for pageIndex, pageName := range pages {
-		wg.Add(1)
-		sem <- struct{}{}
-		go func(idx int, pName string) {
-			defer wg.Done()
-			defer func() { <-sem }()
-
-			if err := exportWikiPage(client, wikiDir, pName, idx, len(pages), config.ExportOptions.IncludeAttachments); err != nil {
+		group.Go(func() {
+			if err := exportWikiPage(ctx, client, cp, policy, config.ExportOptions.ExportDir, pageName, pageIndex, len(pages), includeAttachments); err != nil {
 				mu.Lock()
 				if firstErr == nil {
 					firstErr = err
 				}
 				mu.Unlock()
 			}
-		}(pageIndex, pageName)
+		})
 	}
 
-	wg.Wait()
+	group.Wait()
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("wiki export interrupted: %w", err)
+	}
 	if firstErr != nil {
 		return firstErr
 	}
@@ -64,16 +78,27 @@ func ExportWiki(client *trac.Client, config *config.Config) error {
 	return nil
 }
 
-func exportWikiPage(client *trac.Client, wikiDir, pageName string, pageIndex, totalPages int, includeAttachments bool) error {
-	wikiMeta, err := client.GetWikiPageInfo(pageName)
+func exportWikiPage(ctx context.Context, client Source, cp *Checkpoint, policy *attachmentPolicy, exportDir, pageName string, pageIndex, totalPages int, includeAttachments bool) error {
+	wikiMeta, err := client.GetWikiPageInfo(ctx, pageName)
 	if err != nil {
 		return fmt.Errorf("failed to get wiki page info for %q: %w", pageName, err)
 	}
 
+	wikiDir := filepath.Join(exportDir, "wiki")
+
 	slog.Debug("Exporting wiki page", "current", pageIndex+1, "total", totalPages, "page", pageName)
 
 	for version := int64(1); version <= wikiMeta.Version; version++ {
-		content, err := client.GetWikiPageVersion(pageName, version)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		versionKey := fmt.Sprintf("wiki:%s@%d", pageName, version)
+		if cp.Done(versionKey, nil, 0) {
+			continue
+		}
+
+		content, err := client.GetWikiPageVersion(ctx, pageName, version)
 		if err != nil {
 			slog.Warn("Failed to get wiki page version", "page", pageName, "version", version, "error", err)
 			continue
@@ -84,51 +109,31 @@ func exportWikiPage(client *trac.Client, wikiDir, pageName string, pageIndex, to
 			continue
 		}
 
-		filename := filepath.Join(wikiDir, fmt.Sprintf("%s.v%d.md", pageName, version))
+		filename := filepath.Join(wikiDir, fmt.Sprintf("%s.v%d.wiki", pageName, version))
 
 		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
 			return fmt.Errorf("failed to create directories for wiki page %q: %w", pageName, err)
 		}
 
 		// Write content to file
-		file, err := os.Create(filename)
-		if err != nil {
-			return fmt.Errorf("failed to create file for wiki page %q version %d: %w", pageName, version, err)
-		}
-
-		if _, err := file.WriteString(*content); err != nil {
-			if cerr := file.Close(); cerr != nil {
-				slog.Warn("Failed to close file for wiki page", "page", pageName, "version", version, "error", cerr)
-			}
+		if err := utils.WriteFileAtomic(filename, []byte(*content)); err != nil {
 			return fmt.Errorf("failed to write content for wiki page %q version %d: %w", pageName, version, err)
 		}
 
-		if cerr := file.Close(); cerr != nil {
-			slog.Warn("Failed to close file for wiki page", "page", pageName, "version", version, "error", cerr)
-		}
-
 		// Write metadata to file
-		meta, err := client.GetWikiPageInfoVersion(pageName, version)
+		meta, err := client.GetWikiPageInfoVersion(ctx, pageName, version)
 		if err != nil {
 			slog.Warn("Failed to get wiki page metadata", "page", pageName, "version", version, "error", err)
 			continue
 		}
 
 		metaFile := filepath.Join(wikiDir, fmt.Sprintf("%s.v%d.json", pageName, version))
-		metaFileHandle, err := os.Create(metaFile)
-		if err != nil {
-			return fmt.Errorf("failed to create metadata file for wiki page %q version %d: %w", pageName, version, err)
-		}
-
-		if err := json.NewEncoder(metaFileHandle).Encode(meta); err != nil {
-			if cerr := metaFileHandle.Close(); cerr != nil {
-				slog.Warn("Failed to close metadata file", "page", pageName, "version", version, "error", cerr)
-			}
+		if err := writeJSON(metaFile, meta); err != nil {
 			return fmt.Errorf("failed to write metadata for wiki page %q version %d: %w", pageName, version, err)
 		}
 
-		if cerr := metaFileHandle.Close(); cerr != nil {
-			slog.Warn("Failed to close metadata file", "page", pageName, "version", version, "error", cerr)
+		if err := cp.Complete(versionKey, nil, version, filename, metaFile); err != nil {
+			return fmt.Errorf("failed to record wiki page %q version %d in checkpoint: %w", pageName, version, err)
 		}
 	}
 
@@ -136,26 +141,45 @@ func exportWikiPage(client *trac.Client, wikiDir, pageName string, pageIndex, to
 	if len(wikiMeta.Attachments) > 0 && includeAttachments {
 		slog.Debug("Exporting attachments for wiki page", "page", pageName, "count", len(wikiMeta.Attachments))
 
-		attachmentsDir := filepath.Join(wikiDir, "attachments", pageName)
-		if err := os.MkdirAll(attachmentsDir, 0755); err != nil {
-			slog.Warn("Failed to create attachments directory", "page", pageName, "error", err)
-		} else {
-			for _, att := range wikiMeta.Attachments {
-				content, err := trac.GetAttachment(client, trac.ResourceWiki, pageName, att.Filename)
-				if err != nil {
-					slog.Warn("Failed to download attachment", "page", pageName, "filename", att.Filename, "error", err)
-					continue
-				}
-				safeFilename := filepath.Base(att.Filename)
-				attPath := filepath.Join(attachmentsDir, safeFilename)
+		index := schema.PageAttachments{SchemaVersion: schema.Version, Page: pageName}
+		for _, att := range wikiMeta.Attachments {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 
-				if err := os.WriteFile(attPath, content, 0644); err != nil {
-					slog.Warn("Failed to write attachment", "page", pageName, "filename", att.Filename, "error", err)
+			exported := schema.Attachment{Filename: att.Filename, Description: att.Description, Size: att.Size, Time: att.Time, Author: att.Author}
+
+			attKey := fmt.Sprintf("wiki-attachment:%s/%s", pageName, att.Filename)
+			if cp.Done(attKey, nil, 0) {
+				if exported.SHA256 = blobHash(cp, attKey); exported.SHA256 != "" {
+					index.Attachments = append(index.Attachments, exported)
 					continue
 				}
+			}
+
+			blobPath, err := downloadAttachment(ctx, client, policy, exportDir, trac.ResourceWiki, pageName, &exported)
+			if err != nil {
+				slog.Warn("Failed to download attachment", "page", pageName, "filename", att.Filename, "error", err)
+				continue
+			}
+			index.Attachments = append(index.Attachments, exported)
+			if blobPath == "" {
+				continue
+			}
 
-				slog.Debug("Attachment written", "page", pageName, "filename", att.Filename)
+			if err := cp.Complete(attKey, nil, 0, blobPath); err != nil {
+				slog.Warn("Failed to record attachment in checkpoint", "page", pageName, "filename", att.Filename, "error", err)
 			}
+
+			slog.Debug("Attachment written", "page", pageName, "filename", att.Filename, "sha256", exported.SHA256)
+		}
+
+		indexFile := filepath.Join(exportDir, filepath.FromSlash(schema.PageAttachmentsPath(pageName)))
+		if err := os.MkdirAll(filepath.Dir(indexFile), 0755); err != nil {
+			return fmt.Errorf("failed to create attachments directory for wiki page %q: %w", pageName, err)
+		}
+		if err := writeJSON(indexFile, index); err != nil {
+			return fmt.Errorf("failed to write attachment list of wiki page %q: %w", pageName, err)
 		}
 	}
 
@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// ExportWorkflow exports the ticket statuses and workflow actions to workflow.json
+func ExportWorkflow(ctx context.Context, client Source, config *config.Config) error {
+	slog.Info("Starting workflow export...")
+
+	if !supports(client, trac.CapWorkflow, "skipping the workflow export") {
+		return nil
+	}
+	source, ok := client.(WorkflowSource)
+	if !ok {
+		return nil
+	}
+
+	workflow, err := source.GetWorkflow(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	if err := writeJSON(filepath.Join(config.ExportOptions.ExportDir, schema.WorkflowFile), workflow); err != nil {
+		return fmt.Errorf("failed to write workflow: %w", err)
+	}
+
+	slog.Info("Workflow export completed", "statuses", len(workflow.Statuses), "actions", len(workflow.Actions))
+	return nil
+}
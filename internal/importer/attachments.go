@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+)
+
+// attachmentsHeading starts the list of Trac attachments appended to an issue description
+const attachmentsHeading = "\n\n---\n\n**Attachments**\n\n"
+
+// attachmentUploader uploads exported attachments to a project. Each distinct blob is
+// uploaded once and the returned upload is reused for every ticket that references it.
+type attachmentUploader struct {
+	client    *gitlab.Client
+	export    fs.FS
+	projectID int
+	uploads   map[string]*gitlab.UploadedFile
+}
+
+// newAttachmentUploader creates an uploader reading attachments from an export
+func newAttachmentUploader(client *gitlab.Client, export fs.FS, projectID int) *attachmentUploader {
+	return &attachmentUploader{
+		client:    client,
+		export:    export,
+		projectID: projectID,
+		uploads:   make(map[string]*gitlab.UploadedFile),
+	}
+}
+
+// upload uploads an attachment of a ticket unless the same content was uploaded before
+func (u *attachmentUploader) upload(ctx context.Context, ticketID int64, att schema.Attachment) (*gitlab.UploadedFile, error) {
+	path := schema.AttachmentPath(ticketID, att)
+	if uploaded, ok := u.uploads[path]; ok {
+		slog.Debug("Reusing uploaded attachment", "ticketID", ticketID, "filename", att.Filename, "url", uploaded.URL)
+		return uploaded, nil
+	}
+
+	f, err := u.export.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment %q: %w", att.Filename, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	uploaded, err := u.client.UploadProjectFile(ctx, u.projectID, f, att.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload attachment %q: %w", att.Filename, err)
+	}
+
+	u.uploads[path] = uploaded
+	slog.Debug("Attachment uploaded", "ticketID", ticketID, "filename", att.Filename, "url", uploaded.URL)
+	return uploaded, nil
+}
+
+// section uploads the attachments of a ticket and renders the list appended to its description.
+// Attachments that were not exported or fail to upload are listed without a link.
+func (u *attachmentUploader) section(ctx context.Context, ticketID int64, attachments []schema.Attachment) (string, error) {
+	if len(attachments) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	b.WriteString(attachmentsHeading)
+	for _, att := range attachments {
+		if att.Skipped != "" {
+			fmt.Fprintf(&b, "- %s (not exported, %s)\n", att.Filename, att.Skipped)
+			continue
+		}
+
+		uploaded, err := u.upload(ctx, ticketID, att)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			slog.Warn("Failed to upload attachment", "ticketID", ticketID, "filename", att.Filename, "error", err)
+			fmt.Fprintf(&b, "- %s (not migrated)", att.Filename)
+		} else {
+			fmt.Fprintf(&b, "- [%s](%s)", att.Filename, uploaded.URL)
+		}
+		if att.Description != "" {
+			fmt.Fprintf(&b, ": %s", att.Description)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// existingSection returns the attachment list of an issue imported before, so a repeated
+// import does not upload the attachments again
+func existingSection(description string) (string, bool) {
+	i := strings.Index(description, attachmentsHeading)
+	if i < 0 {
+		return "", false
+	}
+	return description[i:], true
+}
@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+
+	gitlabClient "gitlab.com/gitlab-org/api/client-go"
+)
+
+// componentColor is the color of the component labels
+var componentColor = gitlab.Colors.BlueGray.HexValue
+
+// ImportComponents creates a label for every exported component, described by the
+// component description. Renamed components share the label of their current name.
+func ImportComponents(ctx context.Context, client *gitlab.Client, config *config.Config, export fs.FS) error {
+	project, err := client.GetProject(ctx, config.GitLab.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Starting component import...", "project", project.Name, "projectID", project.ID)
+
+	components, err := readComponents(export)
+	if err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		slog.Info("No components found in the export, skipping component labels")
+		return nil
+	}
+
+	fmt.Printf("Importing components for project: %s (ID: %d)\n", project.Name, project.ID)
+
+	labels := componentLabels(config.ImportOptions.Components, components)
+	for _, label := range labels {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("component import interrupted: %w", err)
+		}
+
+		name, component := label.name, label.component
+		description := strings.TrimSpace(component.Description)
+		existing, err := client.GetLabelbyName(ctx, project.ID, name)
+		if err != nil {
+			return fmt.Errorf("failed to look up label %q: %w", name, err)
+		}
+
+		if existing == nil {
+			slog.Debug("Creating component label", "component", component.Name, "label", name)
+			_, err := client.CreateLabel(ctx, project.ID, &gitlabClient.CreateLabelOptions{
+				Name:        &name,
+				Color:       &componentColor,
+				Description: &description,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create label %q: %w", name, err)
+			}
+		} else if existing.Description != description {
+			slog.Debug("Updating description of component label", "component", component.Name, "label", name)
+			_, err := client.UpdateLabel(ctx, project.ID, existing.ID, &gitlabClient.UpdateLabelOptions{Description: &description})
+			if err != nil {
+				return fmt.Errorf("failed to update label %q: %w", name, err)
+			}
+		} else {
+			slog.Debug("Component label is up to date", "component", component.Name, "label", name)
+		}
+	}
+
+	slog.Info("Component import completed", "count", len(labels))
+	return nil
+}
+
+// componentLabel is a component label and the component describing it
+type componentLabel struct {
+	name      string
+	component trac.Component
+}
+
+// componentLabels returns the labels of the components in export order. Renamed
+// components share the label of their current name, which is described by the current
+// component if it was exported as well.
+func componentLabels(options config.ComponentOptions, components []trac.Component) []componentLabel {
+	var labels []componentLabel
+	index := make(map[string]int)
+	for _, component := range components {
+		name := options.Label(component.Name)
+		i, ok := index[name]
+		if !ok {
+			index[name] = len(labels)
+			labels = append(labels, componentLabel{name: name, component: component})
+			continue
+		}
+		if options.Name(component.Name) == component.Name {
+			labels[i].component = component
+		}
+	}
+	return labels
+}
+
+// readComponents reads the exported components, exports without components have none
+func readComponents(export fs.FS) ([]trac.Component, error) {
+	var components []trac.Component
+	if _, err := readExportJSON(export, schema.ComponentsFile, &components); err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// componentOwners finds the GitLab user to assign issues without owner to, the default
+// owner of their component
+type componentOwners struct {
+	options config.ComponentOptions
+	// owners maps the current component names to their Trac owner
+	owners map[string]string
+	users  *tracUsers
+}
+
+// newComponentOwners returns nil unless issues are assigned to component owners
+func newComponentOwners(users *tracUsers, config *config.Config, export fs.FS) (*componentOwners, error) {
+	options := config.ImportOptions.Components
+	if !options.AssignOwner {
+		return nil, nil
+	}
+
+	components, err := readComponents(export)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &componentOwners{
+		options: options,
+		owners:  make(map[string]string, len(components)),
+		users:   users,
+	}
+	for _, component := range components {
+		if component.Owner != "" {
+			o.owners[options.Name(component.Name)] = component.Owner
+		}
+	}
+	return o, nil
+}
+
+// assignee returns the GitLab user ID of the owner of a component, 0 if there is none
+func (o *componentOwners) assignee(ctx context.Context, component string) int {
+	if o == nil || component == "" {
+		return 0
+	}
+	owner, ok := o.owners[o.options.Name(component)]
+	if !ok {
+		return 0
+	}
+
+	user, err := o.users.lookup(ctx, owner)
+	if err != nil {
+		slog.Debug("Component owner not found in GitLab, issues of the component stay unassigned", "component", component, "owner", owner, "error", err)
+		return 0
+	}
+	return user.ID
+}
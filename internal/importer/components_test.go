@@ -0,0 +1,156 @@
+package importer
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+
+	gitlabClient "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestComponentOptions_Label(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   config.ComponentOptions
+		component string
+		want      string
+	}{
+		{"plain", config.ComponentOptions{}, "core", "core"},
+		{"scoped", config.ComponentOptions{Scope: "component"}, "core", "component::core"},
+		{"renamed", config.ComponentOptions{Scope: "component", Renames: map[string]string{"gui": "ui"}}, "gui", "component::ui"},
+		{"chain", config.ComponentOptions{Renames: map[string]string{"gui": "frontend", "frontend": "ui"}}, "gui", "ui"},
+		{"cycle", config.ComponentOptions{Renames: map[string]string{"a": "b", "b": "a"}}, "a", "a"},
+		{"self", config.ComponentOptions{Renames: map[string]string{"ui": "ui"}}, "ui", "ui"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.Label(tt.component); got != tt.want {
+				t.Errorf("Label(%q) = %q, want %q", tt.component, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComponentLabels(t *testing.T) {
+	options := config.ComponentOptions{Scope: "component", Renames: map[string]string{"gui": "ui", "web": "ui"}}
+	components := []trac.Component{
+		{Name: "core", Description: "The engine"},
+		{Name: "gui", Description: "Old toolkit"},
+		{Name: "ui", Description: "User interface"},
+		{Name: "web", Description: "Web frontend"},
+	}
+
+	want := []componentLabel{
+		{name: "component::core", component: components[0]},
+		{name: "component::ui", component: components[2]},
+	}
+	if got := componentLabels(options, components); !reflect.DeepEqual(got, want) {
+		t.Errorf("componentLabels = %+v, want %+v", got, want)
+	}
+
+	// Without the current component the first former one describes the label
+	want = []componentLabel{{name: "component::ui", component: components[1]}}
+	if got := componentLabels(options, []trac.Component{components[1], components[3]}); !reflect.DeepEqual(got, want) {
+		t.Errorf("componentLabels without current = %+v, want %+v", got, want)
+	}
+}
+
+func TestImportComponents(t *testing.T) {
+	client, cfg, state := newFakeGitLab(t)
+	cfg.ImportOptions.Components = config.ComponentOptions{Labels: true, Scope: "component", Renames: map[string]string{"gui": "ui"}}
+	ctx := context.Background()
+
+	// A label imported before gets the current description
+	stale := "Outdated"
+	if _, err := client.CreateLabel(ctx, 1, &gitlabClient.CreateLabelOptions{Name: gitlabClient.Ptr("component::core"), Description: &stale}); err != nil {
+		t.Fatal(err)
+	}
+
+	export := fstest.MapFS{schema.ComponentsFile: {Data: []byte(`[
+		{"name": "core", "description": "The engine"},
+		{"name": "gui", "description": "Old toolkit"},
+		{"name": "ui", "description": " User interface "}
+	]`)}}
+	if err := ImportComponents(ctx, client, cfg, export); err != nil {
+		t.Fatalf("ImportComponents failed: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, label := range state().Labels {
+		got[label.Name] = label.Description
+	}
+	want := map[string]string{"component::core": "The engine", "component::ui": "User interface"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected labels %v, got %v", want, got)
+	}
+}
+
+func TestComponentOwners_Assignee(t *testing.T) {
+	client, cfg, _ := newFakeGitLab(t)
+	cfg.ImportOptions.Components = config.ComponentOptions{AssignOwner: true, Renames: map[string]string{"gui": "ui"}}
+	ctx := context.Background()
+
+	alice, err := client.CreateUser(ctx, "alice", "Alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	export := fstest.MapFS{schema.ComponentsFile: {Data: []byte(`[
+		{"name": "core", "owner": "alice"},
+		{"name": "ui", "owner": "carol@example.com"},
+		{"name": "docs", "owner": "nobody"},
+		{"name": "misc"}
+	]`)}}
+	users, err := newTracUsers(client, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owners, err := newComponentOwners(users, cfg, export)
+	if err != nil {
+		t.Fatalf("newComponentOwners failed: %v", err)
+	}
+
+	if id := owners.assignee(ctx, "core"); id != alice.ID {
+		t.Errorf("expected core to be assigned to alice (%d), got %d", alice.ID, id)
+	}
+	// Owners given by email are created, renamed components use the owner of the current one
+	carol := owners.assignee(ctx, "gui")
+	if carol == 0 || carol == alice.ID {
+		t.Errorf("expected gui to be assigned to the created owner of ui, got %d", carol)
+	}
+	if id := owners.assignee(ctx, "ui"); id != carol {
+		t.Errorf("expected ui to be assigned to %d, got %d", carol, id)
+	}
+	for _, component := range []string{"docs", "misc", "unknown", ""} {
+		if id := owners.assignee(ctx, component); id != 0 {
+			t.Errorf("expected %q to stay unassigned, got %d", component, id)
+		}
+	}
+
+	cfg.ImportOptions.Components.AssignOwner = false
+	if owners, err := newComponentOwners(users, cfg, export); err != nil || owners.assignee(ctx, "core") != 0 {
+		t.Errorf("expected no assignment when disabled, got %v", err)
+	}
+}
+
+func TestLabelChanges(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ImportOptions.Components = config.ComponentOptions{Labels: true, Scope: "component", Renames: map[string]string{"gui": "ui"}}
+	cfg.ImportOptions.Resolutions = config.ResolutionOptions{Labels: true}
+
+	flat := &IssueFlat{Component: "gui", Status: trac.StatusClosed, Resolution: "fixed"}
+	add, remove := labelChanges(cfg, flat, []string{"component::gui", "resolution::wontfix", "customer", "component::core"})
+
+	if want := []string{"component::ui", "resolution::fixed"}; !slices.Equal(add, want) {
+		t.Errorf("expected to add %v, got %v", want, add)
+	}
+	if want := []string{"component::gui", "resolution::wontfix"}; !slices.Equal(remove, want) {
+		t.Errorf("expected to remove %v, got %v", want, remove)
+	}
+}
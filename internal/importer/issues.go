@@ -1,26 +1,38 @@
 package importer
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/converter"
+	"github.com/bnidev/trac2gitlab/internal/schema"
 	"github.com/bnidev/trac2gitlab/internal/utils"
 	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+
+	gitlabClient "gitlab.com/gitlab-org/api/client-go"
 )
 
-func ImportIssues(client *gitlab.Client, config *config.Config) error {
-	project, err := client.GetProject(config.GitLab.ProjectID)
+// revokeTimeout bounds the cleanup of impersonation tokens after the import ended or was interrupted
+const revokeTimeout = 30 * time.Second
+
+func ImportIssues(ctx context.Context, client *gitlab.Client, config *config.Config, export fs.FS) error {
+	project, err := client.GetProject(ctx, config.GitLab.ProjectID)
 	if err != nil {
 		return err
 	}
 
 	slog.Info("Starting issue import...", "project", project.Name, "projectID", project.ID)
 
-	existingIssues, err := client.ListProjectIssues(project.ID)
+	existingIssues, err := client.ListProjectIssues(ctx, project.ID)
 	if err != nil {
 		return fmt.Errorf("failed to list existing issues: %w", err)
 	}
@@ -33,39 +45,91 @@ func ImportIssues(client *gitlab.Client, config *config.Config) error {
 
 	fmt.Printf("Importing issues for project: %s (ID: %d)\n", project.Name, project.ID)
 
-	issues, err := utils.ReadFilesFromDir("data/tickets", ".json", os.Stderr)
+	issues, err := utils.ReadFilesFromFS(export, "tickets", ".json", os.Stderr)
 	if err != nil {
 		return fmt.Errorf("failed to read milestones from directory: %w", err)
 	}
 
+	conv, err := converter.New(config, export)
+	if err != nil {
+		return fmt.Errorf("failed to create converter: %w", err)
+	}
+
+	users, err := newTracUsers(client, config)
+	if err != nil {
+		return fmt.Errorf("failed to load user mapping: %w", err)
+	}
+	owners, err := newComponentOwners(users, config, export)
+	if err != nil {
+		return fmt.Errorf("failed to load component owners: %w", err)
+	}
+
+	uploader := newAttachmentUploader(client, export, project.ID)
 	userSessionCache := gitlab.NewUserSessionCache()
 
+	// Impersonation tokens are revoked even if the import fails or is interrupted
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), revokeTimeout)
+		defer cancel()
+		userSessionCache.RevokeAll(cleanupCtx, client)
+	}()
+
+	// closed collects the closed issues, their closing notes are posted once all issues exist
+	var closed []*IssueFlat
+
 	for _, issueData := range issues {
-		flat, err := ConvertToFlatIssue(issueData, client, project.ID)
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("issue import interrupted: %w", err)
+		}
+
+		flat, err := ConvertToFlatIssue(ctx, issueData, client, project.ID)
 		if err != nil {
 			return fmt.Errorf("failed to process issue: %w", err)
 		}
+		if flat.Close != nil {
+			closed = append(closed, flat)
+		}
+
+		if !flat.Markdown {
+			flat.Description = conv.Convert(converter.Document{Kind: converter.KindTicket, Name: strconv.Itoa(flat.ID)}, flat.Description)
+		}
 
-		if existingIssue, err := client.GetIssue(project.ID, flat.ID); err != nil {
+		if existingIssue, err := client.GetIssue(ctx, project.ID, flat.ID); err != nil {
 			slog.Debug("Importing new issue", "ID", flat.ID, "Title", flat.Title)
 
-			// Create the issue in GitLab
-			_, err := client.CreateIssueAsUser(config, userSessionCache, project.ID, flat.Reporter, &gitlab.CreateIssueOptions{
+			section, uploadErr := uploader.section(ctx, int64(flat.ID), flat.Attachments)
+			if uploadErr != nil {
+				return fmt.Errorf("failed to upload attachments of issue %d: %w", flat.ID, uploadErr)
+			}
+			flat.Description += section
+
+			createOpts := &gitlab.CreateIssueOptions{
 				IID:         &flat.ID,
 				Title:       &flat.Title,
 				Description: &flat.Description,
 				CreatedAt:   flat.CreatedAt,
 				MilestoneID: &flat.MileStoneID,
-			})
+			}
+			if labels := issueLabels(config, flat); len(labels) > 0 {
+				createOpts.Labels = &labels
+			}
+			if flat.Owner == "" {
+				if assigneeID := owners.assignee(ctx, flat.Component); assigneeID != 0 {
+					createOpts.AssigneeIDs = &[]int{assigneeID}
+				}
+			}
+
+			// Create the issue in GitLab
+			_, err := client.CreateIssueAsUser(ctx, config, userSessionCache, project.ID, flat.Reporter, createOpts)
 			if err != nil {
 				return fmt.Errorf("failed to create issue %d: %w", flat.ID, err)
 			}
 
 			// Update the issue status, because it cant be set on create
-			if flat.Status == "closed" {
+			if flat.Status == trac.StatusClosed {
 				slog.Debug("Setting issue status to closed", "ID", flat.ID, "Title", flat.Title)
 				stateEvent := "close"
-				_, err = client.UpdateIssue(project.ID, flat.ID, &gitlab.UpdateIssueOptions{
+				_, err = client.UpdateIssue(ctx, project.ID, flat.ID, &gitlab.UpdateIssueOptions{
 					StateEvent: &stateEvent,
 				})
 				if err != nil {
@@ -78,6 +142,16 @@ func ImportIssues(client *gitlab.Client, config *config.Config) error {
 		} else {
 			slog.Debug("Issue already exists, checking for updates", "ID", flat.ID, "Title", flat.Title)
 
+			if section, ok := existingSection(existingIssue.Description); ok {
+				flat.Description += section
+			} else {
+				section, err := uploader.section(ctx, int64(flat.ID), flat.Attachments)
+				if err != nil {
+					return fmt.Errorf("failed to upload attachments of issue %d: %w", flat.ID, err)
+				}
+				flat.Description += section
+			}
+
 			updateOpts := &gitlab.UpdateIssueOptions{}
 
 			needsUpdate := false
@@ -97,13 +171,13 @@ func ImportIssues(client *gitlab.Client, config *config.Config) error {
 			// }
 
 			// INFO: Re-Opening/Closing issues will show up as updates in other GitLab issues (thus their updated_at will change)
-			if existingIssue.State != "closed" && flat.Status == "closed" {
+			if existingIssue.State != "closed" && flat.Status == trac.StatusClosed {
 				stateEvent := "close"
 				updateOpts.StateEvent = &stateEvent
 				needsUpdate = true
 			}
 
-			if existingIssue.State == "closed" && flat.Status != "closed" {
+			if existingIssue.State == "closed" && flat.Status != trac.StatusClosed {
 				stateEvent := "reopen"
 				updateOpts.StateEvent = &stateEvent
 				needsUpdate = true
@@ -116,11 +190,28 @@ func ImportIssues(client *gitlab.Client, config *config.Config) error {
 				}
 			}
 
+			add, remove := labelChanges(config, flat, existingIssue.Labels)
+			if len(add) > 0 {
+				updateOpts.AddLabels = &add
+				needsUpdate = true
+			}
+			if len(remove) > 0 {
+				updateOpts.RemoveLabels = &remove
+				needsUpdate = true
+			}
+
+			if flat.Owner == "" && len(existingIssue.Assignees) == 0 {
+				if assigneeID := owners.assignee(ctx, flat.Component); assigneeID != 0 {
+					updateOpts.AssigneeIDs = &[]int{assigneeID}
+					needsUpdate = true
+				}
+			}
+
 			if needsUpdate {
 				slog.Debug("Updating existing issue", "ID", flat.ID, "Title", flat.Title)
 
 				updateOpts.UpdatedAt = flat.UpdatedAt
-				_, err := client.UpdateIssue(config.GitLab.ProjectID, flat.ID, updateOpts)
+				_, err := client.UpdateIssue(ctx, config.GitLab.ProjectID, flat.ID, updateOpts)
 				if err != nil {
 					fmt.Print(updateOpts)
 					return fmt.Errorf("failed to update issue %d: %w", flat.ID, err)
@@ -132,24 +223,14 @@ func ImportIssues(client *gitlab.Client, config *config.Config) error {
 		}
 
 	}
-	userSessionCache.RevokeAll(client)
 
-	return nil
-}
+	if err := importClosingNotes(ctx, client, config, users, userSessionCache, project.ID, closed); err != nil {
+		return err
+	}
+
+	writeConversionReport(conv, config, "issues")
 
-type IssueRaw struct {
-	ID          int    `json:"ID"`
-	TimeCreated string `json:"TimeCreated"`
-	TimeChanged string `json:"TimeChanged"`
-	Attributes  struct {
-		Summary     string `json:"summary"`
-		Time        string `json:"time"`
-		Owner       string `json:"owner"`
-		Reporter    string `json:"reporter"`
-		Description string `json:"description"`
-		Status      string `json:"status"`
-		Milestone   string `json:"milestone"`
-	} `json:"Attributes"`
+	return nil
 }
 
 type IssueFlat struct {
@@ -160,27 +241,24 @@ type IssueFlat struct {
 	UpdatedAt   *time.Time
 	Reporter    string
 	Owner       string
+	Component   string
 	Status      string
+	Resolution  string
+	// Markdown is set if the description was converted to Markdown when exported
+	Markdown bool
+	// Close is the change that closed the issue, nil if it is open or the change is unknown
+	Close       *ticketClose
 	MileStoneID int
+	Attachments []schema.Attachment
 }
 
-func ConvertToFlatIssue(data []byte, client *gitlab.Client, projectID any) (*IssueFlat, error) {
-	var raw IssueRaw
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("unmarshal raw issue: %w", err)
-	}
-
-	createdAt, err := time.Parse(time.RFC3339, raw.TimeCreated)
-	if err != nil {
-		return nil, fmt.Errorf("parse CreatedAt: %w", err)
-	}
-
-	updatedAt, err := time.Parse(time.RFC3339, raw.TimeChanged)
+func ConvertToFlatIssue(ctx context.Context, data []byte, client *gitlab.Client, projectID any) (*IssueFlat, error) {
+	ticket, err := schema.ParseTicket(data)
 	if err != nil {
-		return nil, fmt.Errorf("parse UpdatedAt: %w", err)
+		return nil, fmt.Errorf("failed to decode ticket: %w", err)
 	}
 
-	milestone, err := client.GetMilestoneByName(projectID, raw.Attributes.Milestone)
+	milestone, err := client.GetMilestoneByName(ctx, projectID, ticket.Milestone)
 
 	var milestoneID int
 	if err != nil || milestone == nil {
@@ -190,16 +268,70 @@ func ConvertToFlatIssue(data []byte, client *gitlab.Client, projectID any) (*Iss
 	}
 
 	flat := &IssueFlat{
-		ID:          raw.ID,
-		Title:       raw.Attributes.Summary,
-		CreatedAt:   &createdAt,
-		UpdatedAt:   &updatedAt,
-		Reporter:    raw.Attributes.Reporter,
-		Owner:       raw.Attributes.Owner,
-		Description: raw.Attributes.Description,
-		Status:      raw.Attributes.Status,
+		ID:          int(ticket.ID),
+		Title:       ticket.Summary,
+		CreatedAt:   &ticket.Created,
+		UpdatedAt:   &ticket.Changed,
+		Reporter:    ticket.Reporter,
+		Owner:       ticket.Owner,
+		Component:   ticket.Component,
+		Description: ticket.Description,
+		Status:      ticket.Status,
+		Resolution:  ticket.Resolution,
+		Markdown:    ticket.Markdown,
+		Close:       closeOf(ticket),
 		MileStoneID: milestoneID,
+		Attachments: ticket.Attachments,
 	}
 
 	return flat, nil
 }
+
+// issueLabels returns the labels of the issue's component, status and resolution, as far as they are imported
+func issueLabels(config *config.Config, flat *IssueFlat) gitlabClient.LabelOptions {
+	var labels gitlabClient.LabelOptions
+	if config.ImportOptions.Components.Labels && flat.Component != "" {
+		labels = append(labels, config.ImportOptions.Components.Label(flat.Component))
+	}
+	if config.ImportOptions.Workflow.StatusLabels() && flat.Status != "" {
+		labels = append(labels, config.ImportOptions.Workflow.Label(flat.Status))
+	}
+	if config.ImportOptions.Resolutions.Labels && flat.Status == trac.StatusClosed && flat.Resolution != "" {
+		labels = append(labels, config.ImportOptions.Resolutions.Label(flat.Resolution))
+	}
+	return labels
+}
+
+// labelChanges returns the imported labels an existing issue lacks and the labels it has
+// to lose: those of former component names and other statuses and resolutions, GitLab
+// only keeps a single label of a scope in paid tiers
+func labelChanges(config *config.Config, flat *IssueFlat, existing []string) (add, remove gitlabClient.LabelOptions) {
+	wanted := issueLabels(config, flat)
+	for _, label := range wanted {
+		if !slices.Contains(existing, label) {
+			add = append(add, label)
+		}
+	}
+
+	var former []string
+	if config.ImportOptions.Components.Labels {
+		former = config.ImportOptions.Components.FormerLabels()
+	}
+	var scopes []string
+	if config.ImportOptions.Workflow.StatusLabels() {
+		scopes = append(scopes, config.ImportOptions.Workflow.LabelScope()+"::")
+	}
+	if config.ImportOptions.Resolutions.Labels {
+		scopes = append(scopes, config.ImportOptions.Resolutions.LabelScope()+"::")
+	}
+	for _, label := range existing {
+		if slices.Contains(wanted, label) {
+			continue
+		}
+		scoped := slices.ContainsFunc(scopes, func(prefix string) bool { return strings.HasPrefix(label, prefix) })
+		if scoped || slices.Contains(former, label) {
+			remove = append(remove, label)
+		}
+	}
+	return add, remove
+}
@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/fakegitlab"
+	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+)
+
+// testExport returns an export with a milestone, components, resolutions and two closed
+// tickets, the second a duplicate of the first with an attachment
+func testExport() fstest.MapFS {
+	trace := []byte("panic: nil map")
+	sum := sha256.Sum256(trace)
+	hash := hex.EncodeToString(sum[:])
+
+	return fstest.MapFS{
+		"milestones/milestone-1.0.json": {Data: []byte(`{"schema_version": 3, "name": "1.0", "description": "First release"}`)},
+		schema.ComponentsFile:           {Data: []byte(`[{"name": "core", "owner": "alice", "description": "The engine"}]`)},
+		schema.TicketFieldsFile:         {Data: []byte(`[{"label": "Resolution", "name": "resolution", "type": "radio", "options": ["fixed", "duplicate"]}]`)},
+		"tickets/ticket-1.json": {Data: []byte(`{
+			"schema_version": 3, "id": 1, "created": "2020-01-02T03:04:05Z", "changed": "2020-01-05T00:00:00Z",
+			"summary": "Crash on start", "description": "It {{{crashes}}}", "reporter": "alice@example.com",
+			"status": "closed", "resolution": "fixed", "component": "core", "milestone": "1.0",
+			"history": [{"time": "2020-01-05T00:00:00Z", "author": "bob@example.com", "field": "status", "old_value": "new", "new_value": "closed", "permanent": true}]
+		}`)},
+		"tickets/ticket-2.json": {Data: []byte(`{
+			"schema_version": 3, "id": 2, "created": "2020-01-03T00:00:00Z", "changed": "2020-01-06T00:00:00Z",
+			"summary": "Crashes at startup", "description": "Same crash", "reporter": "bob@example.com",
+			"status": "closed", "resolution": "duplicate", "component": "core",
+			"attachments": [{"filename": "trace.txt", "size": 14, "time": "2020-01-03T00:00:00Z", "author": "bob@example.com", "sha256": "` + hash + `"}],
+			"history": [{"time": "2020-01-06T00:00:00Z", "author": "alice@example.com", "field": "status", "old_value": "new", "new_value": "closed", "permanent": true}],
+			"comments": [{"time": "2020-01-06T00:00:00Z", "author": "alice@example.com", "field": "comment", "old_value": "1", "new_value": "Duplicate of #1", "permanent": true}]
+		}`)},
+		schema.BlobPath(hash): {Data: trace},
+	}
+}
+
+// newFakeGitLab serves an empty fake GitLab with project 1 and returns a client and
+// configuration for it, state returns what the server holds
+func newFakeGitLab(t *testing.T) (client *gitlab.Client, cfg *config.Config, state func() *fakegitlab.State) {
+	t.Helper()
+	return newFakeGitLabWith(t, fakegitlab.NewState())
+}
+
+// newFakeGitLabWith is newFakeGitLab starting from the given state
+func newFakeGitLabWith(t *testing.T, initial *fakegitlab.State) (client *gitlab.Client, cfg *config.Config, state func() *fakegitlab.State) {
+	t.Helper()
+	initial.EnsureProject(1, "trac")
+	srv := httptest.NewServer(fakegitlab.NewServer(initial, fakegitlab.Options{AdminToken: "admin"}))
+	t.Cleanup(srv.Close)
+
+	cfg = &config.Config{}
+	cfg.GitLab.BaseURL = srv.URL
+	cfg.GitLab.APIPath = "/api/v4"
+	cfg.GitLab.Token = "admin"
+	cfg.GitLab.ProjectID = 1
+	cfg.ImportOptions.CreateUsers = true
+
+	client, err := gitlab.NewGitLabClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state = func() *fakegitlab.State {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/_fake/state")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		var got fakegitlab.State
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode the fake GitLab state: %v", err)
+		}
+		return &got
+	}
+	return client, cfg, state
+}
+
+func TestMigrate_FakeGitLab(t *testing.T) {
+	client, cfg, state := newFakeGitLab(t)
+	cfg.ImportOptions.Components = config.ComponentOptions{Labels: true, Scope: "component"}
+	cfg.ImportOptions.Resolutions = config.ResolutionOptions{Labels: true, ClosingNote: true, Duplicates: true}
+
+	ctx := context.Background()
+	export := testExport()
+
+	// Running the migration twice must not duplicate anything
+	for range 2 {
+		if err := ImportMilestones(ctx, client, cfg, export); err != nil {
+			t.Fatalf("ImportMilestones failed: %v", err)
+		}
+		if err := ImportComponents(ctx, client, cfg, export); err != nil {
+			t.Fatalf("ImportComponents failed: %v", err)
+		}
+		if err := ImportResolutions(ctx, client, cfg, export); err != nil {
+			t.Fatalf("ImportResolutions failed: %v", err)
+		}
+		if err := ImportIssues(ctx, client, cfg, export); err != nil {
+			t.Fatalf("ImportIssues failed: %v", err)
+		}
+	}
+
+	got := state()
+	if len(got.Milestones) != 1 || got.Milestones[0].Title != "1.0" || got.Milestones[0].Description != "First release" {
+		t.Fatalf("unexpected milestones %+v", got.Milestones)
+	}
+
+	var labels []string
+	for _, label := range got.Labels {
+		labels = append(labels, label.Name)
+	}
+	slices.Sort(labels)
+	if want := []string{"component::core", "resolution::duplicate", "resolution::fixed"}; !slices.Equal(labels, want) {
+		t.Errorf("expected labels %v, got %v", want, labels)
+	}
+
+	if len(got.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", got.Issues)
+	}
+	issues := make(map[int]*fakegitlab.Issue)
+	for _, issue := range got.Issues {
+		issues[issue.IID] = issue
+	}
+	crash, duplicate := issues[1], issues[2]
+	if crash == nil || duplicate == nil {
+		t.Fatalf("expected issues 1 and 2, got %+v", got.Issues)
+	}
+	if crash.State != "closed" || crash.MilestoneID != got.Milestones[0].ID || crash.Description != "It `crashes`" {
+		t.Errorf("unexpected issue 1 %+v", crash)
+	}
+	if !slices.Equal(crash.Labels, []string{"component::core", "resolution::fixed"}) {
+		t.Errorf("unexpected labels of issue 1 %v", crash.Labels)
+	}
+	if duplicate.State != "closed" || duplicate.DuplicatedToIID != 1 {
+		t.Errorf("expected issue 2 to be closed as duplicate of 1, got %+v", duplicate)
+	}
+
+	if len(got.Uploads) != 1 || got.Uploads[0].Filename != "trace.txt" || got.Uploads[0].Size != 14 {
+		t.Fatalf("expected the attachment to be uploaded once, got %+v", got.Uploads)
+	}
+	if !strings.Contains(duplicate.Description, "[trace.txt]("+got.Uploads[0].URL+")") {
+		t.Errorf("expected the attachment to be linked in issue 2, got %q", duplicate.Description)
+	}
+
+	closing := make(map[int][]string)
+	for _, note := range got.Notes {
+		if !note.System {
+			closing[note.IssueIID] = append(closing[note.IssueIID], note.Body)
+		}
+	}
+	if !slices.Equal(closing[1], []string{"Closed as fixed."}) {
+		t.Errorf("unexpected notes of issue 1 %v", closing[1])
+	}
+	if !slices.Equal(closing[2], []string{"Closed as duplicate of #1."}) {
+		t.Errorf("unexpected notes of issue 2 %v", closing[2])
+	}
+}
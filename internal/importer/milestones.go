@@ -1,29 +1,32 @@
 package importer
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"time"
 
 	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/converter"
+	"github.com/bnidev/trac2gitlab/internal/schema"
 	"github.com/bnidev/trac2gitlab/internal/utils"
 	"github.com/bnidev/trac2gitlab/pkg/gitlab"
 
 	gitlabClient "gitlab.com/gitlab-org/api/client-go"
 )
 
-func ImportMilestones(client *gitlab.Client, config *config.Config) error {
+func ImportMilestones(ctx context.Context, client *gitlab.Client, config *config.Config, export fs.FS) error {
 
-	project, err := client.GetProject(config.GitLab.ProjectID)
+	project, err := client.GetProject(ctx, config.GitLab.ProjectID)
 	if err != nil {
 		return err
 	}
 
 	slog.Info("Starting milestone import...", "project", project.Name, "projectID", project.ID)
 
-	existingMilestones, err := client.ListMilestones(project.ID, nil)
+	existingMilestones, err := client.ListMilestones(ctx, project.ID, nil)
 	if err != nil {
 		return fmt.Errorf("failed to list existing milestones: %w", err)
 	}
@@ -37,36 +40,41 @@ func ImportMilestones(client *gitlab.Client, config *config.Config) error {
 	fmt.Printf("Importing milestones for project: %s (ID: %d)\n", project.Name, project.ID)
 
 	// Import milestones from exported Trac data
-	milestones, err := utils.ReadFilesFromDir("data/milestones", ".json", os.Stderr)
+	milestones, err := utils.ReadFilesFromFS(export, "milestones", ".json", os.Stderr)
 	if err != nil {
 		return fmt.Errorf("failed to read milestones from directory: %w", err)
 	}
 
+	conv, err := converter.New(config, export)
+	if err != nil {
+		return fmt.Errorf("failed to create converter: %w", err)
+	}
+
 	for _, milestoneData := range milestones {
-		var input struct {
-			Name          string `json:"name"`
-			Description   string `json:"description"`
-			DueDate       string `json:"due_date"`       // format: "2025-07-24T16:00:00Z"
-			CompletedDate string `json:"completed_date"` // format: "2025-07-24T16:00:00Z"
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("milestone import interrupted: %w", err)
+		}
+
+		exported, err := schema.ParseMilestone(milestoneData)
+		if err != nil {
+			return fmt.Errorf("failed to decode milestone data: %w", err)
 		}
 
-		if err := json.Unmarshal(milestoneData, &input); err != nil {
-			return fmt.Errorf("failed to unmarshal milestone data: %w", err)
+		description := ""
+		if exported.Description != nil && *exported.Description != "" {
+			description = conv.Convert(converter.Document{Kind: converter.KindMilestone, Name: exported.Name}, *exported.Description)
 		}
 
 		var dueDate time.Time
-		if input.DueDate != "" {
-			dueDate, err = time.Parse(time.RFC3339, input.DueDate)
-			if err != nil {
-				return fmt.Errorf("failed to parse due date %q: %w", input.DueDate, err)
-			}
+		if exported.DueDate != nil {
+			dueDate = *exported.DueDate
 		}
 
-		// Check if input.Name is in the list of existing milestones
+		// Check if the milestone is in the list of existing milestones
 		milestoneExists := false
 		if len(existingMilestones) > 0 {
 			for _, existing := range existingMilestones {
-				if existing.Title == input.Name {
+				if existing.Title == exported.Name {
 					milestoneExists = true
 					slog.Debug("Found existing milestone, skipping creation", "title", existing.Title, "id", existing.ID)
 
@@ -75,15 +83,15 @@ func ImportMilestones(client *gitlab.Client, config *config.Config) error {
 
 					needsUpdate := false
 
-					if input.Description != "" && existing.Description != input.Description {
-						updateOpts.Description = &input.Description
+					if description != "" && existing.Description != description {
+						updateOpts.Description = &description
 						needsUpdate = true
 					}
 
-					if input.DueDate != "" {
+					if exported.DueDate != nil {
 						parsedDueDate, err := gitlabClient.ParseISOTime(dueDate.Format("2006-01-02"))
 						if err != nil {
-							return fmt.Errorf("failed to parse due date %q: %w", input.DueDate, err)
+							return fmt.Errorf("failed to parse due date %q: %w", dueDate.Format("2006-01-02"), err)
 						}
 						if existing.DueDate == nil || *existing.DueDate != parsedDueDate {
 							updateOpts.DueDate = &parsedDueDate
@@ -91,13 +99,13 @@ func ImportMilestones(client *gitlab.Client, config *config.Config) error {
 						}
 					}
 
-					if input.CompletedDate != "" && existing.State != "closed" {
+					if exported.CompletedDate != nil && existing.State != "closed" {
 						var stateEvent = "close"
 						updateOpts.StateEvent = &stateEvent
 						needsUpdate = true
 					}
 
-					if input.CompletedDate == "" && existing.State == "closed" {
+					if exported.CompletedDate == nil && existing.State == "closed" {
 						var stateEvent = "activate"
 						updateOpts.StateEvent = &stateEvent
 						needsUpdate = true
@@ -105,7 +113,7 @@ func ImportMilestones(client *gitlab.Client, config *config.Config) error {
 
 					if needsUpdate {
 						slog.Debug("Updating existing milestone", "title", existing.Title, "id", existing.ID)
-						_, err = client.UpdateMilestone(project.ID, existing.ID, updateOpts)
+						_, err = client.UpdateMilestone(ctx, project.ID, existing.ID, updateOpts)
 						if err != nil {
 							return fmt.Errorf("failed to update milestone: %w", err)
 						}
@@ -121,12 +129,12 @@ func ImportMilestones(client *gitlab.Client, config *config.Config) error {
 
 		if !milestoneExists {
 			opts := &gitlab.MilestoneOptions{
-				Title:       input.Name,
-				Description: input.Description,
+				Title:       exported.Name,
+				Description: description,
 				DueDate:     (*gitlab.ISOTime)(&dueDate),
 			}
 
-			milestone, err := client.CreateMilestone(project.ID, opts)
+			milestone, err := client.CreateMilestone(ctx, project.ID, opts)
 			if err != nil {
 				return fmt.Errorf("failed to create milestone: %s", err)
 			}
@@ -134,13 +142,13 @@ func ImportMilestones(client *gitlab.Client, config *config.Config) error {
 			slog.Info("Milestone created successfully", "title", milestone.Title, "id", milestone.ID)
 
 			// close milestone if CompletedDate is provided
-			if input.CompletedDate != "" {
+			if exported.CompletedDate != nil {
 				var stateEvent = "close"
 
 				updateOpts := &gitlab.UpdateMilestoneOptions{
 					StateEvent: &stateEvent,
 				}
-				_, err = client.UpdateMilestone(project.ID, milestone.ID, updateOpts)
+				_, err = client.UpdateMilestone(ctx, project.ID, milestone.ID, updateOpts)
 				if err != nil {
 					slog.Error("Failed to close milestone", "title", milestone.Title, "id", milestone.ID, "error", err)
 				} else {
@@ -150,6 +158,8 @@ func ImportMilestones(client *gitlab.Client, config *config.Config) error {
 		}
 	}
 
+	writeConversionReport(conv, config, "milestones")
+
 	slog.Info("Milestone import completed", "count", len(milestones))
 	return nil
 }
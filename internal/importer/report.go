@@ -0,0 +1,26 @@
+package importer
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/converter"
+)
+
+// writeConversionReport writes the references the converter could not resolve during
+// the named import step to the export directory. The directory is created if the export
+// is read from an archive or database.
+func writeConversionReport(conv *converter.Converter, config *config.Config, step string) {
+	unresolved := conv.Report().Entries()
+	if len(unresolved) == 0 {
+		return
+	}
+
+	reportFile := filepath.Join(config.ExportOptions.ExportDir, "conversion-report-"+step+".txt")
+	if err := conv.Report().WriteFile(reportFile); err != nil {
+		slog.Warn("Failed to write conversion report", "error", err)
+		return
+	}
+	slog.Warn("Some references could not be converted", "count", len(unresolved), "report", reportFile)
+}
@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+
+	gitlabClient "gitlab.com/gitlab-org/api/client-go"
+)
+
+// resolutionColor is the color of the resolution labels
+var resolutionColor = gitlab.Colors.CharcoalGrey.HexValue
+
+// ResolutionDuplicate is the resolution of tickets closed as a duplicate of another ticket
+const ResolutionDuplicate = "duplicate"
+
+// duplicateMark starts the system note GitLab adds when it marks an issue as a duplicate
+const duplicateMark = "marked this issue as a duplicate of "
+
+// ImportResolutions creates a scoped label for every resolution in the exported ticket
+// fields. Resolutions missing there get their label from GitLab when an issue uses it.
+func ImportResolutions(ctx context.Context, client *gitlab.Client, config *config.Config, export fs.FS) error {
+	project, err := client.GetProject(ctx, config.GitLab.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Starting resolution import...", "project", project.Name, "projectID", project.ID)
+
+	resolutions, err := fieldOptions(export, "resolution")
+	if err != nil {
+		return err
+	}
+	if len(resolutions) == 0 {
+		slog.Info("No resolutions found in the exported ticket fields, skipping resolution labels")
+		return nil
+	}
+
+	fmt.Printf("Importing resolutions for project: %s (ID: %d)\n", project.Name, project.ID)
+
+	options := config.ImportOptions.Resolutions
+	for _, resolution := range resolutions {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("resolution import interrupted: %w", err)
+		}
+
+		name := options.Label(resolution)
+		label, err := client.GetLabelbyName(ctx, project.ID, name)
+		if err != nil {
+			return fmt.Errorf("failed to look up label %q: %w", name, err)
+		}
+		if label != nil {
+			continue
+		}
+
+		slog.Debug("Creating resolution label", "resolution", resolution, "label", name)
+		if _, err := client.CreateLabel(ctx, project.ID, &gitlabClient.CreateLabelOptions{Name: &name, Color: &resolutionColor}); err != nil {
+			return fmt.Errorf("failed to create label %q: %w", name, err)
+		}
+	}
+
+	slog.Info("Resolution import completed", "resolutions", resolutions)
+	return nil
+}
+
+// ticketClose is the change that closed a ticket
+type ticketClose struct {
+	Closer   string
+	ClosedAt time.Time
+	// DuplicateOf is the ticket a duplicate refers to, 0 if its comments name none
+	DuplicateOf int
+}
+
+// closeOf returns the last close of a closed ticket, nil if the ticket is open or the
+// export has no status changes, e.g. because it was written with schema version 2
+func closeOf(ticket *schema.Ticket) *ticketClose {
+	if ticket.Status != trac.StatusClosed {
+		return nil
+	}
+
+	var closed *schema.Change
+	for i, change := range ticket.History {
+		if change.Field == "status" && change.NewValue != nil && *change.NewValue == trac.StatusClosed {
+			closed = &ticket.History[i]
+		}
+	}
+	if closed == nil {
+		slog.Warn("Closed ticket has no status change, importing it without closer and closing note", "ID", ticket.ID, "schemaVersion", ticket.SchemaVersion)
+		return nil
+	}
+
+	c := &ticketClose{Closer: closed.Author, ClosedAt: closed.Time}
+	if ticket.Resolution == ResolutionDuplicate {
+		c.DuplicateOf = duplicateOf(ticket, closed.Time)
+	}
+	return c
+}
+
+var (
+	// duplicateReference matches an explicit reference like "duplicate of #12" or "dup ticket:12"
+	duplicateReference = regexp.MustCompile(`(?i)\bdup(?:e|licate)?\s+(?:of\s+)?(?:#|ticket:|ticket\s+#?)(\d+)\b`)
+	// closingReference matches a closing comment naming nothing but a ticket, like "#12"
+	// or "see ticket:12", unlike "fixed by #12" it can only mean the original
+	closingReference = regexp.MustCompile(`(?i)^\s*(?:(?:same\s+as|see)\s+)?(?:#|ticket:)(\d+)\s*[.!]?\s*$`)
+)
+
+// duplicateOf finds the ticket a duplicate refers to: an explicit reference in the comment
+// made when the ticket was closed, then that comment if it names nothing but a ticket,
+// then an explicit reference in the other comments, latest first
+func duplicateOf(ticket *schema.Ticket, closedAt time.Time) int {
+	var closing string
+	var others []string
+	for _, comment := range slices.Backward(ticket.Comments) {
+		if comment.NewValue == nil {
+			continue
+		}
+		if comment.Time.Equal(closedAt) {
+			closing = *comment.NewValue
+		} else {
+			others = append(others, *comment.NewValue)
+		}
+	}
+
+	find := func(pattern *regexp.Regexp, text string) int {
+		for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+			if id, err := strconv.Atoi(m[1]); err == nil && id != int(ticket.ID) {
+				return id
+			}
+		}
+		return 0
+	}
+	if id := find(duplicateReference, closing); id != 0 {
+		return id
+	}
+	if id := find(closingReference, closing); id != 0 {
+		return id
+	}
+	for _, text := range others {
+		if id := find(duplicateReference, text); id != 0 {
+			return id
+		}
+	}
+	return 0
+}
+
+// closingNote returns the note telling how an issue was closed and the quick action
+// marking it as a duplicate, GitLab executes the action and keeps the rest as the note
+func closingNote(options config.ResolutionOptions, flat *IssueFlat) (body, action string) {
+	switch {
+	case flat.Resolution == ResolutionDuplicate && flat.Close.DuplicateOf != 0:
+		body = fmt.Sprintf("Closed as duplicate of #%d.", flat.Close.DuplicateOf)
+		if options.Duplicates {
+			action = fmt.Sprintf("/duplicate #%d", flat.Close.DuplicateOf)
+		}
+	case flat.Resolution != "":
+		body = fmt.Sprintf("Closed as %s.", flat.Resolution)
+	default:
+		body = "Closed."
+	}
+	return body, action
+}
+
+// importClosingNotes posts the closing notes of closed issues that lack them and marks
+// duplicates GitLab does not show as such yet. It runs after all issues exist, so
+// duplicates can refer to issues imported later, and marks them once a later run finds
+// an original that was missing before.
+func importClosingNotes(ctx context.Context, client *gitlab.Client, config *config.Config, users *tracUsers, cache *gitlab.UserSessionCache, projectID int, closed []*IssueFlat) error {
+	options := config.ImportOptions.Resolutions
+	for _, flat := range closed {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("closing note import interrupted: %w", err)
+		}
+
+		duplicate := options.Duplicates && flat.Resolution == ResolutionDuplicate && flat.Close.DuplicateOf != 0
+		if !options.ClosingNote && !duplicate {
+			continue
+		}
+
+		body, action := closingNote(options, flat)
+		notes, err := client.ListIssueNotes(ctx, projectID, flat.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list notes of issue %d: %w", flat.ID, err)
+		}
+		noted := !options.ClosingNote || slices.ContainsFunc(notes, func(note *gitlab.Note) bool { return !note.System && note.Body == body })
+		mark := action != "" && !slices.ContainsFunc(notes, func(note *gitlab.Note) bool { return note.System && strings.HasPrefix(note.Body, duplicateMark) })
+		if mark {
+			if _, err := client.GetIssue(ctx, projectID, flat.Close.DuplicateOf); err != nil {
+				slog.Warn("Original of a duplicate not found in GitLab, not marking the issue as duplicate", "ID", flat.ID, "duplicateOf", flat.Close.DuplicateOf, "error", err)
+				mark = false
+			}
+		}
+		if noted && !mark {
+			slog.Debug("Closing note already imported", "ID", flat.ID)
+			continue
+		}
+
+		switch {
+		case noted:
+			body = action
+		case mark:
+			body += "\n\n" + action
+		}
+
+		slog.Debug("Posting closing note", "ID", flat.ID, "resolution", flat.Resolution, "closer", flat.Close.Closer)
+		opts := &gitlab.CreateIssueNoteOptions{Body: &body, CreatedAt: &flat.Close.ClosedAt}
+		if closer, err := users.lookup(ctx, flat.Close.Closer); err != nil {
+			slog.Debug("Closer not found in GitLab, posting the closing note as the importing user", "ID", flat.ID, "closer", flat.Close.Closer)
+		} else if _, err := client.CreateIssueNoteAsGitLabUser(ctx, config, cache, projectID, flat.ID, closer, opts); err != nil {
+			slog.Warn("Failed to post closing note as the closer, posting it as the importing user", "ID", flat.ID, "closer", flat.Close.Closer, "error", err)
+		} else {
+			continue
+		}
+		if _, err := client.CreateIssueNote(ctx, projectID, flat.ID, opts); err != nil {
+			return fmt.Errorf("failed to post closing note of issue %d: %w", flat.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+
+	gitlabClient "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestDuplicateReference(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Duplicate of #12", "12"},
+		{"duplicate #12", "12"},
+		{"dup of ticket:12", "12"},
+		{"Dupe ticket #12.", "12"},
+		{"Closing, DUPLICATE OF TICKET 12", "12"},
+		{"Fixed by #12", ""},
+		{"See #12, a duplicate", ""},
+		{"duplicate of r12", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var got string
+			if m := duplicateReference.FindStringSubmatch(tt.text); m != nil {
+				got = m[1]
+			}
+			if got != tt.want {
+				t.Errorf("duplicateReference(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosingReference(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"#12", "12"},
+		{" ticket:12. ", "12"},
+		{"See #12", "12"},
+		{"same as #12!", "12"},
+		{"Fixed by #12", ""},
+		{"Fixed in #12", ""},
+		{"#12 and #13", ""},
+		{"&#12;", ""},
+		{"See #12 for the fix", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var got string
+			if m := closingReference.FindStringSubmatch(tt.text); m != nil {
+				got = m[1]
+			}
+			if got != tt.want {
+				t.Errorf("closingReference(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateOf(t *testing.T) {
+	closedAt := time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+	earlier := closedAt.Add(-time.Hour)
+	comment := func(at time.Time, text string) schema.Change {
+		return schema.Change{Time: at, Field: "comment", NewValue: &text}
+	}
+
+	tests := []struct {
+		name     string
+		comments []schema.Change
+		want     int
+	}{
+		{"explicit in closing comment", []schema.Change{comment(earlier, "duplicate of #3"), comment(closedAt, "Duplicate of #4, see #5")}, 4},
+		{"closing comment naming a ticket", []schema.Change{comment(earlier, "duplicate of #3"), comment(closedAt, "#4")}, 4},
+		{"explicit in earlier comments, latest first", []schema.Change{comment(earlier.Add(-time.Hour), "dup #3"), comment(earlier, "dup #6"), comment(closedAt, "Fixed by #4")}, 6},
+		{"reference to itself", []schema.Change{comment(closedAt, "duplicate of #2")}, 0},
+		{"no reference", []schema.Change{comment(closedAt, "Closing")}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := &schema.Ticket{ID: 2, Comments: tt.comments}
+			if got := duplicateOf(ticket, closedAt); got != tt.want {
+				t.Errorf("duplicateOf = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloseOf(t *testing.T) {
+	closedAt := time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+	closed, reopened := trac.StatusClosed, "reopened"
+	text := "dup #1"
+	ticket := &schema.Ticket{
+		ID: 2, Status: trac.StatusClosed, Resolution: ResolutionDuplicate,
+		History: []schema.Change{
+			{Time: closedAt.Add(-2 * time.Hour), Author: "alice", Field: "status", NewValue: &closed},
+			{Time: closedAt.Add(-time.Hour), Author: "bob", Field: "status", NewValue: &reopened},
+			{Time: closedAt, Author: "carol", Field: "status", NewValue: &closed},
+		},
+		Comments: []schema.Change{{Time: closedAt, Field: "comment", NewValue: &text}},
+	}
+
+	got := closeOf(ticket)
+	if got == nil || got.Closer != "carol" || !got.ClosedAt.Equal(closedAt) || got.DuplicateOf != 1 {
+		t.Errorf("expected the last close by carol as duplicate of #1, got %+v", got)
+	}
+
+	ticket.History = nil
+	if got := closeOf(ticket); got != nil {
+		t.Errorf("expected no close without status changes, got %+v", got)
+	}
+}
+
+func TestImportClosingNotes_MarksDuplicateOnceOriginalExists(t *testing.T) {
+	client, cfg, state := newFakeGitLab(t)
+	cfg.ImportOptions.Resolutions = config.ResolutionOptions{ClosingNote: true, Duplicates: true}
+	ctx := context.Background()
+
+	createIssue := func(iid int) {
+		t.Helper()
+		if _, err := client.CreateIssue(ctx, 1, &gitlabClient.CreateIssueOptions{IID: &iid, Title: gitlabClient.Ptr("Crash")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notes := func() (closing []string, marks int) {
+		t.Helper()
+		for _, note := range state().Notes {
+			switch {
+			case note.System:
+				marks++
+			case note.IssueIID == 2:
+				closing = append(closing, note.Body)
+			}
+		}
+		return closing, marks
+	}
+
+	createIssue(2)
+	flat := &IssueFlat{ID: 2, Status: trac.StatusClosed, Resolution: ResolutionDuplicate, Close: &ticketClose{Closer: "alice", ClosedAt: time.Now(), DuplicateOf: 1}}
+	cache := gitlab.NewUserSessionCache()
+	users, err := newTracUsers(client, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The original is missing, only the closing note is posted
+	if err := importClosingNotes(ctx, client, cfg, users, cache, 1, []*IssueFlat{flat}); err != nil {
+		t.Fatalf("importClosingNotes failed: %v", err)
+	}
+	if closing, marks := notes(); !slices.Equal(closing, []string{"Closed as duplicate of #1."}) || marks != 0 {
+		t.Fatalf("expected only the closing note, got %v and %d marks", closing, marks)
+	}
+
+	// Once the original exists a later run marks the duplicate without repeating the note
+	createIssue(1)
+	for range 2 {
+		if err := importClosingNotes(ctx, client, cfg, users, cache, 1, []*IssueFlat{flat}); err != nil {
+			t.Fatalf("importClosingNotes failed: %v", err)
+		}
+	}
+	if closing, marks := notes(); !slices.Equal(closing, []string{"Closed as duplicate of #1."}) || marks != 1 {
+		t.Errorf("expected the closing note and one duplicate mark, got %v and %d marks", closing, marks)
+	}
+	for _, issue := range state().Issues {
+		if issue.IID == 2 && issue.DuplicatedToIID != 1 {
+			t.Errorf("expected issue 2 to be marked as duplicate of 1, got %+v", issue)
+		}
+	}
+}
+
+func TestImportClosingNotes_Closer(t *testing.T) {
+	client, cfg, state := newFakeGitLab(t)
+	cfg.ImportOptions.Resolutions = config.ResolutionOptions{ClosingNote: true}
+	cfg.UserMapping.Users = map[string]string{"bob": "robert"}
+	ctx := context.Background()
+
+	robert, err := client.CreateUser(ctx, "robert", "Robert", "robert@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var closed []*IssueFlat
+	for iid, closer := range map[int]string{1: "bob", 2: "carol"} {
+		if _, err := client.CreateIssue(ctx, 1, &gitlabClient.CreateIssueOptions{IID: &iid, Title: gitlabClient.Ptr("Crash")}); err != nil {
+			t.Fatal(err)
+		}
+		closed = append(closed, &IssueFlat{ID: iid, Status: trac.StatusClosed, Resolution: "fixed", Close: &ticketClose{Closer: closer, ClosedAt: time.Now()}})
+	}
+
+	users, err := newTracUsers(client, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := importClosingNotes(ctx, client, cfg, users, gitlab.NewUserSessionCache(), 1, closed); err != nil {
+		t.Fatalf("importClosingNotes failed: %v", err)
+	}
+
+	got := state()
+	authors := make(map[int]int)
+	for _, note := range got.Notes {
+		authors[note.IssueIID] = note.AuthorID
+	}
+	// The mapped closer posts the note, an unknown closer leaves it to the importing user
+	if authors[1] != robert.ID {
+		t.Errorf("expected the note of issue 1 by robert (%d), got %d", robert.ID, authors[1])
+	}
+	if authors[2] == 0 || authors[2] == robert.ID {
+		t.Errorf("expected the note of issue 2 by the importing user, got %d", authors[2])
+	}
+	// Trac usernames are not taken for email addresses of users to create
+	if len(got.Users) != 2 {
+		t.Errorf("expected no users to be created, got %+v", got.Users)
+	}
+}
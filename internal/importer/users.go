@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+
+	gitlabClient "gitlab.com/gitlab-org/api/client-go"
+)
+
+// tracUsers resolves Trac users, usernames or email addresses, to GitLab users
+type tracUsers struct {
+	client *gitlab.Client
+	// mapping is the user mapping from Trac users to GitLab usernames
+	mapping map[string]string
+	// resolved caches the lookups of Trac users
+	resolved map[string]userLookup
+	// createUsers creates users given by email address that do not exist yet
+	createUsers bool
+}
+
+// userLookup is the cached result of looking up a Trac user
+type userLookup struct {
+	user *gitlabClient.User
+	err  error
+}
+
+// newTracUsers loads the user mapping of the configuration
+func newTracUsers(client *gitlab.Client, config *config.Config) (*tracUsers, error) {
+	mapping, err := config.UserMapping.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &tracUsers{
+		client:      client,
+		mapping:     mapping,
+		resolved:    make(map[string]userLookup),
+		createUsers: config.ImportOptions.CreateUsers,
+	}, nil
+}
+
+// lookup returns the GitLab user of a Trac user: the mapped username, the user with the
+// email address, created if enabled, or the user with the same username. A user that is
+// not found is logged once.
+func (u *tracUsers) lookup(ctx context.Context, name string) (*gitlabClient.User, error) {
+	if cached, ok := u.resolved[name]; ok {
+		return cached.user, cached.err
+	}
+
+	var user *gitlabClient.User
+	var err error
+	switch {
+	case u.mapping[name] != "":
+		user, err = u.client.GetUserByUsername(ctx, u.mapping[name])
+	case strings.Contains(name, "@"):
+		user, err = u.client.GetUserByEmail(ctx, name)
+		if errors.Is(err, gitlab.ErrUserNotFound) && u.createUsers {
+			user, err = u.client.CreateUserFromEmail(ctx, name)
+		}
+	default:
+		user, err = u.client.GetUserByUsername(ctx, name)
+	}
+	if err == nil && user == nil {
+		err = gitlab.ErrUserNotFound
+	}
+	if err != nil {
+		slog.Warn("Trac user not found in GitLab", "user", name, "error", err)
+	}
+
+	u.resolved[name] = userLookup{user: user, err: err}
+	return user, err
+}
@@ -0,0 +1,198 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+
+	gitlabClient "gitlab.com/gitlab-org/api/client-go"
+)
+
+// Colors of the status labels, closed tickets are gray
+var (
+	statusColor       = gitlab.Colors.GreenCyan.HexValue
+	closedStatusColor = gitlab.Colors.Gray.HexValue
+)
+
+// ImportWorkflow creates a scoped label for every ticket status and, if configured, an
+// issue board with one list per status in workflow order. Closed issues are shown in
+// the built-in closed list of the board, so "closed" gets no list.
+func ImportWorkflow(ctx context.Context, client *gitlab.Client, config *config.Config, export fs.FS) error {
+	project, err := client.GetProject(ctx, config.GitLab.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Starting workflow import...", "project", project.Name, "projectID", project.ID)
+
+	statuses, err := workflowStatuses(config, export)
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		slog.Info("No ticket statuses found in the export, skipping status labels")
+		return nil
+	}
+
+	fmt.Printf("Importing workflow for project: %s (ID: %d)\n", project.Name, project.ID)
+
+	options := config.ImportOptions.Workflow
+	labelIDs := make(map[string]int, len(statuses))
+	for _, status := range statuses {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("workflow import interrupted: %w", err)
+		}
+
+		name := options.Label(status)
+		label, err := client.GetLabelbyName(ctx, project.ID, name)
+		if err != nil {
+			return fmt.Errorf("failed to look up label %q: %w", name, err)
+		}
+		if label == nil {
+			slog.Debug("Creating status label", "status", status, "label", name)
+			color := statusColor
+			if status == trac.StatusClosed {
+				color = closedStatusColor
+			}
+			label, err = client.CreateLabel(ctx, project.ID, &gitlabClient.CreateLabelOptions{Name: &name, Color: &color})
+			if err != nil {
+				return fmt.Errorf("failed to create label %q: %w", name, err)
+			}
+		}
+		labelIDs[status] = label.ID
+	}
+
+	if options.Board != "" {
+		if err := importBoard(ctx, client, project.ID, options.Board, statuses, labelIDs); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Workflow import completed", "statuses", statuses)
+	return nil
+}
+
+// importBoard creates the board unless it exists and puts the lists of the statuses in
+// workflow order in front of any other lists
+func importBoard(ctx context.Context, client *gitlab.Client, projectID int, name string, statuses []string, labelIDs map[string]int) error {
+	board, err := client.GetIssueBoardByName(ctx, projectID, name)
+	if err != nil {
+		return fmt.Errorf("failed to look up board %q: %w", name, err)
+	}
+	if board == nil {
+		slog.Debug("Creating issue board", "name", name)
+		if board, err = client.CreateIssueBoard(ctx, projectID, name); err != nil {
+			return fmt.Errorf("failed to create board %q: %w", name, err)
+		}
+	}
+
+	lists, err := client.GetIssueBoardLists(ctx, projectID, board.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list the lists of board %q: %w", name, err)
+	}
+	slices.SortFunc(lists, func(a, b *gitlab.BoardList) int { return a.Position - b.Position })
+
+	// order holds the label IDs of the lists by position
+	order := make([]int, 0, len(lists))
+	listIDs := make(map[int]int, len(lists))
+	for _, list := range lists {
+		if list.Label != nil {
+			order = append(order, list.Label.ID)
+			listIDs[list.Label.ID] = list.ID
+		} else {
+			order = append(order, 0)
+		}
+	}
+
+	position := 0
+	for _, status := range statuses {
+		if status == trac.StatusClosed {
+			continue
+		}
+		labelID := labelIDs[status]
+
+		if _, ok := listIDs[labelID]; !ok {
+			slog.Debug("Adding board list", "board", name, "status", status)
+			list, err := client.CreateIssueBoardLabelList(ctx, projectID, board.ID, labelID)
+			if err != nil {
+				return fmt.Errorf("failed to add list of status %q to board %q: %w", status, name, err)
+			}
+			listIDs[labelID] = list.ID
+			order = append(order, labelID)
+		}
+
+		if current := slices.Index(order, labelID); current != position {
+			slog.Debug("Moving board list", "board", name, "status", status, "position", position)
+			if _, err := client.MoveIssueBoardList(ctx, projectID, board.ID, listIDs[labelID], position); err != nil {
+				return fmt.Errorf("failed to move list of status %q on board %q: %w", status, name, err)
+			}
+			order = slices.Insert(slices.Delete(order, current, current+1), position, labelID)
+		}
+		position++
+	}
+	return nil
+}
+
+// workflowStatuses returns the ticket statuses in workflow order: as configured, from
+// the exported workflow or from the options of the exported status field
+func workflowStatuses(config *config.Config, export fs.FS) ([]string, error) {
+	if statuses := config.ImportOptions.Workflow.Statuses; len(statuses) > 0 {
+		return statuses, nil
+	}
+
+	var workflow trac.Workflow
+	found, err := readExportJSON(export, schema.WorkflowFile, &workflow)
+	if err != nil {
+		return nil, err
+	}
+	if found && len(workflow.Statuses) > 0 {
+		return workflow.Order(), nil
+	}
+
+	statuses, err := fieldOptions(export, "status")
+	if err != nil || len(statuses) == 0 {
+		return nil, err
+	}
+	return (&trac.Workflow{Statuses: statuses}).Order(), nil
+}
+
+// fieldOptions returns the options of an exported ticket field, none if the field was not exported
+func fieldOptions(export fs.FS, name string) ([]string, error) {
+	var fields []struct {
+		Name    string   `json:"name"`
+		Options []string `json:"options"`
+	}
+	if _, err := readExportJSON(export, schema.TicketFieldsFile, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		if field.Name == name {
+			return field.Options, nil
+		}
+	}
+	return nil, nil
+}
+
+// readExportJSON decodes a file of the export and reports whether it exists
+func readExportJSON(export fs.FS, name string, v any) (bool, error) {
+	data, err := fs.ReadFile(export, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return true, nil
+}
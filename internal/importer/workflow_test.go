@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+	"github.com/bnidev/trac2gitlab/pkg/fakegitlab"
+)
+
+func TestWorkflowStatuses(t *testing.T) {
+	workflow := `{"statuses": ["new", "closed", "accepted"], "actions": [
+		{"name": "accept", "from": ["new"], "to": "accepted"},
+		{"name": "resolve", "from": ["accepted"], "to": "closed"}
+	]}`
+	fields := `[{"name": "status", "options": ["closed", "new", "reopened"]}]`
+
+	tests := []struct {
+		name       string
+		configured []string
+		export     fstest.MapFS
+		want       []string
+	}{
+		{"configured", []string{"open", "done"}, fstest.MapFS{schema.WorkflowFile: {Data: []byte(workflow)}}, []string{"open", "done"}},
+		{"exported workflow", nil, fstest.MapFS{schema.WorkflowFile: {Data: []byte(workflow)}, schema.TicketFieldsFile: {Data: []byte(fields)}}, []string{"new", "accepted", "closed"}},
+		{"ticket fields", nil, fstest.MapFS{schema.TicketFieldsFile: {Data: []byte(fields)}}, []string{"new", "reopened", "closed"}},
+		{"nothing exported", nil, fstest.MapFS{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.ImportOptions.Workflow.Statuses = tt.configured
+			got, err := workflowStatuses(cfg, tt.export)
+			if err != nil {
+				t.Fatalf("workflowStatuses failed: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("workflowStatuses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportWorkflow_Board(t *testing.T) {
+	// The board exists with a list of another label, a list without label and the list
+	// of "assigned" behind them
+	initial := fakegitlab.NewState()
+	initial.Sequences["labels"] = 2
+	initial.Sequences["boards"] = 1
+	initial.Sequences["board_lists"] = 3
+	initial.Labels = []*fakegitlab.Label{
+		{ID: 1, ProjectID: 1, Name: "triage", Color: "#ff0000"},
+		{ID: 2, ProjectID: 1, Name: "status::assigned", Color: "#00ff00"},
+	}
+	initial.Boards = []*fakegitlab.Board{{ID: 1, ProjectID: 1, Name: "Workflow", Lists: []*fakegitlab.BoardList{
+		{ID: 1, LabelID: 1},
+		{ID: 2},
+		{ID: 3, LabelID: 2},
+	}}}
+
+	client, cfg, state := newFakeGitLabWith(t, initial)
+	cfg.ImportOptions.Workflow = config.WorkflowOptions{Board: "Workflow", Statuses: []string{"new", "assigned", "reopened", "closed"}}
+	ctx := context.Background()
+
+	// A second run finds everything in place
+	for range 2 {
+		if err := ImportWorkflow(ctx, client, cfg, fstest.MapFS{}); err != nil {
+			t.Fatalf("ImportWorkflow failed: %v", err)
+		}
+	}
+
+	got := state()
+	names := make(map[int]string)
+	colors := make(map[string]string)
+	for _, label := range got.Labels {
+		names[label.ID] = label.Name
+		colors[label.Name] = label.Color
+	}
+	if len(got.Labels) != 5 {
+		t.Errorf("expected a label per status besides triage, got %v", names)
+	}
+	if colors["status::closed"] != closedStatusColor || colors["status::new"] != statusColor || colors["status::assigned"] != "#00ff00" {
+		t.Errorf("unexpected label colors %v", colors)
+	}
+
+	if len(got.Boards) != 1 {
+		t.Fatalf("expected the existing board to be used, got %+v", got.Boards)
+	}
+	var lists []string
+	for _, list := range got.Boards[0].Lists {
+		lists = append(lists, names[list.LabelID])
+	}
+	// The status lists come first in workflow order, closed issues have the built-in list
+	if want := []string{"status::new", "status::assigned", "status::reopened", "triage", ""}; !slices.Equal(lists, want) {
+		t.Errorf("expected lists %q, got %q", want, lists)
+	}
+	if got.Boards[0].Lists[1].ID != 3 {
+		t.Errorf("expected the existing list of assigned to be moved, got %+v", got.Boards[0].Lists[1])
+	}
+}
+
+func TestImportWorkflow_CreatesBoard(t *testing.T) {
+	client, cfg, state := newFakeGitLab(t)
+	cfg.ImportOptions.Workflow = config.WorkflowOptions{Board: "Workflow", Scope: "state"}
+	export := fstest.MapFS{schema.TicketFieldsFile: {Data: []byte(`[{"name": "status", "options": ["closed", "new", "accepted"]}]`)}}
+
+	if err := ImportWorkflow(context.Background(), client, cfg, export); err != nil {
+		t.Fatalf("ImportWorkflow failed: %v", err)
+	}
+
+	got := state()
+	names := make(map[int]string)
+	for _, label := range got.Labels {
+		names[label.ID] = label.Name
+	}
+	if len(got.Boards) != 1 || got.Boards[0].Name != "Workflow" {
+		t.Fatalf("expected the board to be created, got %+v", got.Boards)
+	}
+	var lists []string
+	for _, list := range got.Boards[0].Lists {
+		lists = append(lists, names[list.LabelID])
+	}
+	if want := []string{"state::new", "state::accepted"}; !slices.Equal(lists, want) {
+		t.Errorf("expected lists %q, got %q", want, lists)
+	}
+}
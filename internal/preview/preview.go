@@ -0,0 +1,96 @@
+package preview
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/converter"
+	"github.com/bnidev/trac2gitlab/internal/schema"
+)
+
+// Preview holds the raw Trac markup of a document next to its Markdown rendering
+type Preview struct {
+	Title      string
+	Source     string
+	Rendered   string
+	Unresolved []converter.Unresolved
+}
+
+// Ticket builds a preview of an exported ticket including its comments
+func Ticket(conv *converter.Converter, exportDir string, id int) (*Preview, error) {
+	filename := filepath.Join(exportDir, "tickets", fmt.Sprintf("ticket-%d.json", id))
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ticket #%d: %w", id, err)
+	}
+
+	ticket, err := schema.ParseTicket(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ticket #%d: %w", id, err)
+	}
+
+	var source strings.Builder
+	fmt.Fprintf(&source, "= #%d: %s =\n\n", ticket.ID, ticket.Summary)
+	if ticket.Description != "" {
+		source.WriteString(ticket.Description)
+		source.WriteString("\n")
+	}
+	for i, comment := range ticket.Comments {
+		if comment.NewValue == nil || *comment.NewValue == "" {
+			continue
+		}
+		fmt.Fprintf(&source, "\n== Comment %d by %s (%s) ==\n\n", i+1, comment.Author, comment.Time.Format("2006-01-02 15:04"))
+		source.WriteString(*comment.NewValue)
+		source.WriteString("\n")
+	}
+
+	return &Preview{
+		Title:      fmt.Sprintf("Ticket #%d: %s", ticket.ID, ticket.Summary),
+		Source:     source.String(),
+		Rendered:   converter.RenderTicket(conv, ticket),
+		Unresolved: conv.Report().Entries(),
+	}, nil
+}
+
+// Wiki builds a preview of an exported wiki page version, version 0 selects the latest one
+func Wiki(conv *converter.Converter, exportDir, pageName string, version int64) (*Preview, error) {
+	wikiDir := filepath.Join(exportDir, "wiki")
+
+	if version == 0 {
+		files, err := filepath.Glob(filepath.Join(wikiDir, filepath.FromSlash(pageName)+".v*.wiki"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions of wiki page %q: %w", pageName, err)
+		}
+		for _, file := range files {
+			rel, err := filepath.Rel(wikiDir, file)
+			if err != nil {
+				continue
+			}
+			name, v := converter.WikiPageFromFile(rel)
+			if name == pageName && v > version {
+				version = v
+			}
+		}
+		if version == 0 {
+			return nil, fmt.Errorf("wiki page %q not found in export", pageName)
+		}
+	}
+
+	filename := filepath.Join(wikiDir, filepath.FromSlash(pageName)+".v"+strconv.FormatInt(version, 10)+".wiki")
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wiki page %q version %d: %w", pageName, version, err)
+	}
+
+	rendered := conv.Convert(converter.Document{Kind: converter.KindWiki, Name: pageName}, string(content))
+
+	return &Preview{
+		Title:      fmt.Sprintf("Wiki page %s (version %d)", pageName, version),
+		Source:     string(content),
+		Rendered:   rendered,
+		Unresolved: conv.Report().Entries(),
+	}, nil
+}
@@ -0,0 +1,121 @@
+package preview
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/internal/converter"
+)
+
+func writeExport(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", rel, err)
+		}
+	}
+	return dir
+}
+
+func renderHTML(t *testing.T, p *Preview) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "preview.html")
+	if err := p.WriteHTML(path); err != nil {
+		t.Fatalf("WriteHTML returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read HTML preview: %v", err)
+	}
+	return string(data)
+}
+
+func TestTicket_HTML(t *testing.T) {
+	dir := writeExport(t, map[string]string{
+		"tickets/ticket-7.json": `{
+			"schema_version": 3, "id": 7, "summary": "Crash <on> start", "status": "new",
+			"description": "It fails with '''bold''' text and {{{x < y}}}.",
+			"comments": [{"time": "2020-01-02T03:04:00Z", "author": "alice", "field": "comment", "new_value": " * first\n * second"}]
+		}`,
+	})
+	conv, err := converter.New(&config.Config{}, os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("failed to create converter: %v", err)
+	}
+
+	p, err := Ticket(conv, dir, 7)
+	if err != nil {
+		t.Fatalf("Ticket returned error: %v", err)
+	}
+	if p.Title != "Ticket #7: Crash <on> start" {
+		t.Errorf("unexpected title %q", p.Title)
+	}
+	if !strings.Contains(p.Source, "'''bold'''") || !strings.Contains(p.Source, "== Comment 1 by alice (2020-01-02 03:04) ==") {
+		t.Errorf("expected the Trac markup as source, got %q", p.Source)
+	}
+
+	page := renderHTML(t, p)
+	for _, want := range []string{
+		"<title>Ticket #7: Crash &lt;on&gt; start</title>",
+		"It fails with &#39;&#39;&#39;bold&#39;&#39;&#39; text",
+		"<strong>bold</strong>",
+		"<code>x &lt; y</code>",
+		"<h2>Comment 1 by alice (2020-01-02 03:04)</h2>",
+		"<li>first</li>",
+		"<h2>Unresolved links and macros (0)</h2>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, page)
+		}
+	}
+
+	if _, err := Ticket(conv, dir, 8); err == nil {
+		t.Error("expected an error for a ticket missing in the export")
+	}
+}
+
+func TestWiki_HTML(t *testing.T) {
+	dir := writeExport(t, map[string]string{
+		"wiki/Guide/Start.v1.wiki": "= Old =\n",
+		"wiki/Guide/Start.v2.wiki": "= Getting started =\n\nSee ''the guide'' for details.\n",
+	})
+	conv, err := converter.New(&config.Config{}, os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("failed to create converter: %v", err)
+	}
+
+	// Version 0 selects the latest version
+	p, err := Wiki(conv, dir, "Guide/Start", 0)
+	if err != nil {
+		t.Fatalf("Wiki returned error: %v", err)
+	}
+	if p.Title != "Wiki page Guide/Start (version 2)" {
+		t.Errorf("unexpected title %q", p.Title)
+	}
+
+	page := renderHTML(t, p)
+	for _, want := range []string{
+		"<pre>= Getting started =",
+		"<h1>Getting started",
+		"<p>See <em>the guide</em> for details.</p>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", want, page)
+		}
+	}
+
+	if p, err := Wiki(conv, dir, "Guide/Start", 1); err != nil || !strings.Contains(renderHTML(t, p), "<h1>Old") {
+		t.Errorf("expected version 1 to be rendered, got %v", err)
+	}
+	if _, err := Wiki(conv, dir, "Missing", 0); err == nil {
+		t.Error("expected an error for a wiki page missing in the export")
+	}
+}
@@ -0,0 +1,109 @@
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	headerStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("8"))
+	warnStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+	paneStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8")).Padding(0, 1)
+)
+
+// Terminal renders the preview with source and Markdown side by side for a terminal of the given width
+func (p *Preview) Terminal(width int) string {
+	// Each pane has a border and padding of two columns on either side
+	paneWidth := width/2 - 4
+	if paneWidth < 20 {
+		paneWidth = 20
+	}
+
+	left := lipgloss.JoinVertical(lipgloss.Left,
+		headerStyle.Render("Trac"),
+		paneStyle.Width(paneWidth).Render(p.Source),
+	)
+	right := lipgloss.JoinVertical(lipgloss.Left,
+		headerStyle.Render("GitLab Markdown"),
+		paneStyle.Width(paneWidth).Render(p.Rendered),
+	)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(p.Title))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, left, " ", right))
+	b.WriteString("\n")
+
+	if len(p.Unresolved) == 0 {
+		b.WriteString("\nNo unresolved links or macros.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", warnStyle.Render(fmt.Sprintf("Unresolved links and macros (%d)", len(p.Unresolved))))
+	for _, entry := range p.Unresolved {
+		fmt.Fprintf(&b, "  %s  %s  %s\n", entry.Document, entry.Reference, headerStyle.Render(entry.Reason))
+	}
+	return b.String()
+}
+
+// WriteHTML writes a standalone HTML file with the source and the rendered Markdown side by side
+func (p *Preview) WriteHTML(path string) error {
+	var rendered bytes.Buffer
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	if err := md.Convert([]byte(p.Rendered), &rendered); err != nil {
+		return fmt.Errorf("failed to render Markdown: %w", err)
+	}
+
+	var unresolved strings.Builder
+	for _, entry := range p.Unresolved {
+		fmt.Fprintf(&unresolved, "<li><code>%s</code> in %s: %s</li>\n",
+			html.EscapeString(entry.Reference), html.EscapeString(entry.Document.String()), html.EscapeString(entry.Reason))
+	}
+
+	page := fmt.Sprintf(htmlTemplate,
+		html.EscapeString(p.Title),
+		html.EscapeString(p.Title),
+		html.EscapeString(p.Source),
+		rendered.String(),
+		len(p.Unresolved),
+		unresolved.String(),
+	)
+
+	if err := os.WriteFile(path, []byte(page), 0644); err != nil {
+		return fmt.Errorf("failed to write HTML preview: %w", err)
+	}
+	return nil
+}
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+.panes { display: flex; gap: 1em; }
+.pane { flex: 1; min-width: 0; border: 1px solid #ccc; border-radius: 4px; padding: 0 1em; overflow-x: auto; }
+pre { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<div class="panes">
+<div class="pane"><h2>Trac</h2><pre>%s</pre></div>
+<div class="pane"><h2>GitLab Markdown</h2>%s</div>
+</div>
+<h2>Unresolved links and macros (%d)</h2>
+<ul>
+%s</ul>
+</body>
+</html>
+`
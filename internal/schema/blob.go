@@ -0,0 +1,32 @@
+package schema
+
+import (
+	"path"
+	"strconv"
+)
+
+// BlobDir is the content-addressed attachment store inside an export. Every distinct
+// attachment is stored once, named by the SHA-256 of its content.
+const BlobDir = "attachments/blobs"
+
+// BlobPath returns the slash separated path of a blob relative to the export directory
+func BlobPath(sha256 string) string {
+	if len(sha256) < 2 {
+		return path.Join(BlobDir, sha256)
+	}
+	return path.Join(BlobDir, sha256[:2], sha256)
+}
+
+// AttachmentPath returns the path of a ticket attachment relative to the export directory.
+// Attachments exported before the blob store are read from tickets/attachments/<id>/<name>.
+func AttachmentPath(ticketID int64, att Attachment) string {
+	if att.SHA256 != "" {
+		return BlobPath(att.SHA256)
+	}
+	return path.Join("tickets", "attachments", strconv.FormatInt(ticketID, 10), path.Base(att.Filename))
+}
+
+// PageAttachmentsPath returns the path of the attachment list of a wiki page relative to the export directory
+func PageAttachmentsPath(page string) string {
+	return path.Join("wiki", "attachments", page+".json")
+}
@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bnidev/trac2gitlab/internal/utils"
+)
+
+// Invalid is an exported file that does not match its JSON Schema
+type Invalid struct {
+	File string
+	Err  error
+}
+
+// CheckResult summarizes the validation of an export directory. File paths are
+// relative to the export directory.
+type CheckResult struct {
+	Checked  int
+	Outdated []string
+	Upgraded []string
+	// Stale are tickets an upgrade cannot complete, they have to be exported again
+	Stale   []string
+	Invalid []Invalid
+}
+
+// CheckExport validates the tickets and milestones of an export directory against the
+// JSON Schemas of the current version. Files written by older versions are reported as
+// outdated, or rewritten in the current version first if upgrade is set. Tickets exported
+// without status changes are reported as stale, no upgrade can add them.
+func CheckExport(exportDir string, upgrade bool) (*CheckResult, error) {
+	result := &CheckResult{}
+
+	for _, set := range []struct {
+		kind    Kind
+		pattern string
+	}{
+		{KindTicket, filepath.Join("tickets", "ticket-*.json")},
+		{KindMilestone, filepath.Join("milestones", "milestone-*.json")},
+	} {
+		files, err := filepath.Glob(filepath.Join(exportDir, set.pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s files: %w", set.kind, err)
+		}
+		sort.Strings(files)
+
+		for _, path := range files {
+			rel, err := filepath.Rel(exportDir, path)
+			if err != nil {
+				return nil, err
+			}
+			rel = filepath.ToSlash(rel)
+
+			if err := checkFile(result, set.kind, path, rel, upgrade); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// checkFile validates a single exported file, upgrading it if requested
+func checkFile(result *CheckResult, kind Kind, path, rel string, upgrade bool) error {
+	result.Checked++
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", rel, err)
+	}
+
+	version, err := DetectVersion(data)
+	if err != nil {
+		result.Invalid = append(result.Invalid, Invalid{File: rel, Err: err})
+		return nil
+	}
+
+	if kind == KindTicket && version < Version {
+		result.Stale = append(result.Stale, rel)
+	}
+
+	if version < upgradeVersion(kind) {
+		if !upgrade {
+			result.Outdated = append(result.Outdated, rel)
+			return nil
+		}
+
+		data, err = upgradeData(kind, data)
+		if err != nil {
+			result.Invalid = append(result.Invalid, Invalid{File: rel, Err: err})
+			return nil
+		}
+		if err := utils.WriteFileAtomic(path, data); err != nil {
+			return fmt.Errorf("failed to write upgraded %s: %w", rel, err)
+		}
+		result.Upgraded = append(result.Upgraded, rel)
+	}
+
+	if err := Validate(kind, data); err != nil {
+		result.Invalid = append(result.Invalid, Invalid{File: rel, Err: err})
+	}
+	return nil
+}
+
+// upgradeVersion returns the version older files of a kind are upgraded to
+func upgradeVersion(kind Kind) int {
+	if kind == KindTicket {
+		return ticketUpgradeVersion
+	}
+	return Version
+}
+
+// upgradeData converts an exported file to the current schema version
+func upgradeData(kind Kind, data []byte) ([]byte, error) {
+	var upgraded any
+	var err error
+	switch kind {
+	case KindTicket:
+		upgraded, err = ParseTicket(data)
+	case KindMilestone:
+		upgraded, err = ParseMilestone(data)
+	default:
+		return nil, fmt.Errorf("no upgrade for %s", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.MarshalIndent(upgraded, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upgraded %s: %w", kind, err)
+	}
+	return append(out, '\n'), nil
+}
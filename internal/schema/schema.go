@@ -0,0 +1,153 @@
+// Package schema defines the versioned on-disk format of exported tickets and milestones.
+//
+// Every file carries a schema_version. Files without one were written before the format
+// was versioned and are treated as version 1, they are upgraded when read.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Version is the schema version written by this release. Version 3 added the status
+// and resolution changes to the ticket history.
+const Version = 3
+
+// ticketUpgradeVersion is the version older tickets are upgraded to. Upgrading cannot
+// add the status changes, so tickets stay at version 2 until they are exported again.
+const ticketUpgradeVersion = 2
+
+// Files of the export the Trac components, the ticket workflow and the ticket fields
+// are written to
+const (
+	ComponentsFile   = "components.json"
+	WorkflowFile     = "workflow.json"
+	TicketFieldsFile = "ticket-fields.json"
+)
+
+// Ticket is an exported Trac ticket with typed core fields. Fields Trac does not
+// define itself are kept as strings in CustomFields.
+type Ticket struct {
+	SchemaVersion int               `json:"schema_version"`
+	ID            int64             `json:"id"`
+	Created       time.Time         `json:"created"`
+	Changed       time.Time         `json:"changed"`
+	Summary       string            `json:"summary"`
+	Description   string            `json:"description"`
+	Reporter      string            `json:"reporter"`
+	Owner         string            `json:"owner,omitempty"`
+	Type          string            `json:"type,omitempty"`
+	Status        string            `json:"status"`
+	Resolution    string            `json:"resolution,omitempty"`
+	Priority      string            `json:"priority,omitempty"`
+	Severity      string            `json:"severity,omitempty"`
+	Component     string            `json:"component,omitempty"`
+	Version       string            `json:"version,omitempty"`
+	Milestone     string            `json:"milestone,omitempty"`
+	Keywords      string            `json:"keywords,omitempty"`
+	CC            string            `json:"cc,omitempty"`
+	CustomFields  map[string]string `json:"custom_fields,omitempty"`
+	Attachments   []Attachment      `json:"attachments,omitempty"`
+	History       []Change          `json:"history,omitempty"`
+	Comments      []Change          `json:"comments,omitempty"`
+	// Markdown is set if the description and comments were converted to Markdown when
+	// the ticket was exported, as releases did before the schema was versioned
+	Markdown bool `json:"markdown,omitempty"`
+}
+
+// Attachment describes a file attached to a ticket. Skipped holds the reason an
+// attachment was not downloaded, its content is then missing from the export.
+type Attachment struct {
+	Filename    string    `json:"filename"`
+	Description string    `json:"description,omitempty"`
+	Size        int64     `json:"size"`
+	Time        time.Time `json:"time"`
+	Author      string    `json:"author,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Skipped     string    `json:"skipped,omitempty"`
+}
+
+// PageAttachments lists the attachments of a wiki page, it is stored as
+// wiki/attachments/<page>.json
+type PageAttachments struct {
+	SchemaVersion int          `json:"schema_version"`
+	Page          string       `json:"page"`
+	Attachments   []Attachment `json:"attachments"`
+}
+
+// Change is an entry of the ticket change log
+type Change struct {
+	Time      time.Time `json:"time"`
+	Author    string    `json:"author"`
+	Field     string    `json:"field"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	Permanent bool      `json:"permanent"`
+}
+
+// Milestone is an exported Trac milestone
+type Milestone struct {
+	SchemaVersion int        `json:"schema_version"`
+	Name          string     `json:"name"`
+	Description   *string    `json:"description,omitempty"`
+	DueDate       *time.Time `json:"due_date,omitempty"`
+	CompletedDate *time.Time `json:"completed_date,omitempty"`
+}
+
+// DetectVersion returns the schema version of an exported file
+func DetectVersion(data []byte) (int, error) {
+	var header struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("failed to decode schema version: %w", err)
+	}
+	if header.SchemaVersion == nil {
+		return 1, nil
+	}
+	return *header.SchemaVersion, nil
+}
+
+// ParseTicket decodes an exported ticket of any supported schema version. The ticket
+// keeps the version it was exported with, see ticketUpgradeVersion.
+func ParseTicket(data []byte) (*Ticket, error) {
+	version, err := DetectVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case version == 1:
+		return upgradeTicketV1(data)
+	case version == ticketUpgradeVersion, version == Version:
+		var ticket Ticket
+		if err := json.Unmarshal(data, &ticket); err != nil {
+			return nil, fmt.Errorf("failed to decode ticket: %w", err)
+		}
+		return &ticket, nil
+	case version > Version:
+		return nil, fmt.Errorf("ticket uses schema version %d, this release supports up to %d", version, Version)
+	default:
+		return nil, fmt.Errorf("unknown ticket schema version %d", version)
+	}
+}
+
+// ParseMilestone decodes an exported milestone of any supported schema version
+func ParseMilestone(data []byte) (*Milestone, error) {
+	version, err := DetectVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version > Version {
+		return nil, fmt.Errorf("milestone uses schema version %d, this release supports up to %d", version, Version)
+	}
+
+	// Version 1 only lacks the schema version
+	var milestone Milestone
+	if err := json.Unmarshal(data, &milestone); err != nil {
+		return nil, fmt.Errorf("failed to decode milestone: %w", err)
+	}
+	milestone.SchemaVersion = Version
+	return &milestone, nil
+}
@@ -0,0 +1,171 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const legacyTicket = `{
+  "ID": 42,
+  "TimeCreated": "2020-01-02T03:04:05Z",
+  "TimeChanged": "2020-02-03T04:05:06Z",
+  "Attributes": {
+    "summary": "Crash on start",
+    "description": "It {{{crashes}}}",
+    "reporter": "alice",
+    "status": "closed",
+    "resolution": "fixed",
+    "milestone": "1.0",
+    "changetime": "2020-02-03T04:05:06Z",
+    "_ts": "1580702706000000",
+    "customer": "ACME"
+  },
+  "Attachments": [
+    {"Filename": "trace.txt", "Description": "", "Size": 12, "Time": "2020-01-02T03:05:00Z", "Author": "alice"}
+  ],
+  "History": null,
+  "Comments": [
+    {"Time": "2020-01-03T00:00:00Z", "Author": "bob", "Field": "comment", "OldValue": "1", "NewValue": "Fixed in r12", "Permanent": 1}
+  ]
+}`
+
+func TestParseTicket_UpgradesVersion1(t *testing.T) {
+	ticket, err := ParseTicket([]byte(legacyTicket))
+	if err != nil {
+		t.Fatalf("failed to parse legacy ticket: %v", err)
+	}
+
+	if ticket.SchemaVersion != ticketUpgradeVersion || ticket.ID != 42 || ticket.Summary != "Crash on start" || ticket.Resolution != "fixed" {
+		t.Errorf("unexpected core fields: %+v", ticket)
+	}
+	if !ticket.Changed.Equal(time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC)) {
+		t.Errorf("unexpected change time %v", ticket.Changed)
+	}
+	if len(ticket.CustomFields) != 1 || ticket.CustomFields["customer"] != "ACME" {
+		t.Errorf("expected only the customer custom field, got %v", ticket.CustomFields)
+	}
+	if len(ticket.Comments) != 1 || !ticket.Comments[0].Permanent || *ticket.Comments[0].NewValue != "Fixed in r12" {
+		t.Errorf("unexpected comments %+v", ticket.Comments)
+	}
+
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		t.Fatalf("failed to encode ticket: %v", err)
+	}
+	if err := Validate(KindTicket, data); err != nil {
+		t.Errorf("upgraded ticket does not match the schema: %v", err)
+	}
+}
+
+func TestValidate_RejectsInvalidTicket(t *testing.T) {
+	data := []byte(`{"schema_version": 2, "id": 1, "summary": "Missing times", "description": "", "reporter": "alice", "status": "new", "Attributes": {}}`)
+	if err := Validate(KindTicket, data); err == nil {
+		t.Error("expected validation error")
+	}
+}
+
+func TestParseTicket_RejectsNewerVersion(t *testing.T) {
+	if _, err := ParseTicket([]byte(`{"schema_version": 99}`)); err == nil {
+		t.Error("expected error for newer schema version")
+	}
+}
+
+// baselineTicket is a ticket as written by the first release, which converted the
+// description and the change log values to Markdown during the export
+const baselineTicket = `{
+  "ID": 7,
+  "TimeCreated": "2021-05-06T07:08:09Z",
+  "TimeChanged": "2021-05-07T07:08:09Z",
+  "Attributes": {
+    "summary": "Overflow in the parser",
+    "description": "## Steps\n\nParse **large** input, ` + "`a ^ b ^ c`" + ` fails",
+    "reporter": "alice",
+    "status": "new",
+    "_ts": "1620371289000000"
+  },
+  "Attachments": null,
+  "History": [
+    {"Time": "2021-05-07T07:08:09Z", "Author": "bob", "Field": "description", "OldValue": "", "NewValue": "## Steps", "Permanent": 1}
+  ],
+  "Comments": [
+    {"Time": "2021-05-07T07:08:09Z", "Author": "bob", "Field": "comment", "OldValue": "1", "NewValue": "*Confirmed*", "Permanent": 1}
+  ]
+}`
+
+func TestParseTicket_Version1IsMarkdown(t *testing.T) {
+	ticket, err := ParseTicket([]byte(baselineTicket))
+	if err != nil {
+		t.Fatalf("failed to parse baseline ticket: %v", err)
+	}
+
+	if !ticket.Markdown {
+		t.Error("expected a version 1 ticket to be marked as Markdown")
+	}
+	if ticket.Description != "## Steps\n\nParse **large** input, `a ^ b ^ c` fails" {
+		t.Errorf("unexpected description %q", ticket.Description)
+	}
+
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		t.Fatalf("failed to encode ticket: %v", err)
+	}
+	if err := Validate(KindTicket, data); err != nil {
+		t.Errorf("upgraded ticket does not match the schema: %v", err)
+	}
+
+	// The upgraded file keeps the mark, so upgrading it on disk is safe
+	upgraded, err := ParseTicket(data)
+	if err != nil {
+		t.Fatalf("failed to parse upgraded ticket: %v", err)
+	}
+	if !upgraded.Markdown {
+		t.Error("expected the upgraded ticket to stay marked as Markdown")
+	}
+}
+
+func TestCheckExport_StaleTickets(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"tickets/ticket-1.json":         legacyTicket,
+		"tickets/ticket-2.json":         `{"schema_version": 2, "id": 2, "created": "2020-01-02T03:04:05Z", "changed": "2020-01-02T03:04:05Z", "summary": "Old", "description": "", "reporter": "alice", "status": "closed"}`,
+		"tickets/ticket-3.json":         `{"schema_version": 3, "id": 3, "created": "2020-01-02T03:04:05Z", "changed": "2020-01-02T03:04:05Z", "summary": "New", "description": "", "reporter": "alice", "status": "new"}`,
+		"milestones/milestone-1.0.json": `{"schema_version": 2, "name": "1.0"}`,
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := CheckExport(dir, true)
+	if err != nil {
+		t.Fatalf("CheckExport failed: %v", err)
+	}
+	if len(result.Invalid) != 0 {
+		t.Fatalf("unexpected invalid files %+v", result.Invalid)
+	}
+	// Version 2 tickets cannot be upgraded, only the layout of version 1 is
+	if want := []string{"tickets/ticket-1.json", "milestones/milestone-1.0.json"}; !slices.Equal(result.Upgraded, want) {
+		t.Errorf("expected upgraded %v, got %v", want, result.Upgraded)
+	}
+	if want := []string{"tickets/ticket-1.json", "tickets/ticket-2.json"}; !slices.Equal(result.Stale, want) {
+		t.Errorf("expected stale %v, got %v", want, result.Stale)
+	}
+
+	// A second run finds nothing left to upgrade
+	result, err = CheckExport(dir, false)
+	if err != nil {
+		t.Fatalf("CheckExport failed: %v", err)
+	}
+	if len(result.Outdated) != 0 || len(result.Stale) != 2 {
+		t.Errorf("expected only the stale tickets, got %+v", result)
+	}
+}
@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// internalAttributes are ticket attributes Trac returns that duplicate typed fields
+var internalAttributes = map[string]bool{"time": true, "changetime": true, "_ts": true}
+
+// FromTrac converts a ticket fetched from Trac into the export schema
+func FromTrac(t *trac.Ticket) *Ticket {
+	ticket := &Ticket{
+		SchemaVersion: Version,
+		ID:            t.ID,
+		Created:       t.TimeCreated,
+		Changed:       t.TimeChanged,
+	}
+
+	core := map[string]*string{
+		"summary":     &ticket.Summary,
+		"description": &ticket.Description,
+		"reporter":    &ticket.Reporter,
+		"owner":       &ticket.Owner,
+		"type":        &ticket.Type,
+		"status":      &ticket.Status,
+		"resolution":  &ticket.Resolution,
+		"priority":    &ticket.Priority,
+		"severity":    &ticket.Severity,
+		"component":   &ticket.Component,
+		"version":     &ticket.Version,
+		"milestone":   &ticket.Milestone,
+		"keywords":    &ticket.Keywords,
+		"cc":          &ticket.CC,
+	}
+
+	for name, value := range t.Attributes {
+		if internalAttributes[name] {
+			continue
+		}
+		if field, ok := core[name]; ok {
+			*field = attributeString(value)
+			continue
+		}
+		if ticket.CustomFields == nil {
+			ticket.CustomFields = make(map[string]string)
+		}
+		ticket.CustomFields[name] = attributeString(value)
+	}
+
+	for _, att := range t.Attachments {
+		ticket.Attachments = append(ticket.Attachments, Attachment{
+			Filename:    att.Filename,
+			Description: att.Description,
+			Size:        att.Size,
+			Time:        att.Time,
+			Author:      att.Author,
+		})
+	}
+	ticket.History = changesFromTrac(t.History)
+	ticket.Comments = changesFromTrac(t.Comments)
+
+	return ticket
+}
+
+// MilestoneFromTrac converts a milestone fetched from Trac into the export schema
+func MilestoneFromTrac(m *trac.Milestone) *Milestone {
+	return &Milestone{
+		SchemaVersion: Version,
+		Name:          m.Name,
+		Description:   m.Description,
+		DueDate:       m.DueDate,
+		CompletedDate: m.CompletedDate,
+	}
+}
+
+// changesFromTrac converts ticket change log entries
+func changesFromTrac(entries []trac.ChangeLogEntry) []Change {
+	if len(entries) == 0 {
+		return nil
+	}
+	changes := make([]Change, 0, len(entries))
+	for _, entry := range entries {
+		changes = append(changes, Change{
+			Time:      entry.Time,
+			Author:    entry.Author,
+			Field:     entry.Field,
+			OldValue:  entry.OldValue,
+			NewValue:  entry.NewValue,
+			Permanent: entry.Permanent != 0,
+		})
+	}
+	return changes
+}
+
+// attributeString formats a ticket attribute value, which Trac returns as a string for all text fields
+func attributeString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// upgradeTicketV1 converts a ticket written before the schema was versioned. Version 1
+// files are a plain dump of trac.Ticket with Go field names and untyped attributes, their
+// description and change log values were already converted to Markdown.
+func upgradeTicketV1(data []byte) (*Ticket, error) {
+	var legacy trac.Ticket
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to decode version 1 ticket: %w", err)
+	}
+	ticket := FromTrac(&legacy)
+	ticket.SchemaVersion = ticketUpgradeVersion
+	ticket.Markdown = true
+	return ticket, nil
+}
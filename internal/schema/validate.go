@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Kind selects the JSON Schema an exported file is validated against
+type Kind string
+
+const (
+	KindTicket    Kind = "ticket"
+	KindMilestone Kind = "milestone"
+)
+
+// Files holds the published JSON Schemas of the current version
+//
+//go:embed ticket.schema.json milestone.schema.json
+var Files embed.FS
+
+// schemaPrefix is the base of the $id of the published schemas
+const schemaPrefix = "https://github.com/bnidev/trac2gitlab/internal/schema/"
+
+var (
+	compiled    map[Kind]*jsonschema.Schema
+	compileErr  error
+	compileOnce sync.Once
+)
+
+// compile compiles the embedded JSON Schemas once
+func compile() (map[Kind]*jsonschema.Schema, error) {
+	compileOnce.Do(func() {
+		compiler := jsonschema.NewCompiler()
+		compiler.AssertFormat()
+
+		compiled = make(map[Kind]*jsonschema.Schema)
+		for _, kind := range []Kind{KindTicket, KindMilestone} {
+			name := string(kind) + ".schema.json"
+			data, err := Files.ReadFile(name)
+			if err != nil {
+				compileErr = err
+				return
+			}
+			doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+			if err != nil {
+				compileErr = fmt.Errorf("failed to parse %s: %w", name, err)
+				return
+			}
+			if err := compiler.AddResource(schemaPrefix+name, doc); err != nil {
+				compileErr = fmt.Errorf("failed to add %s: %w", name, err)
+				return
+			}
+			schema, err := compiler.Compile(schemaPrefix + name)
+			if err != nil {
+				compileErr = fmt.Errorf("failed to compile %s: %w", name, err)
+				return
+			}
+			compiled[kind] = schema
+		}
+	})
+	return compiled, compileErr
+}
+
+// Validate checks an exported file of the current schema version against its JSON Schema
+func Validate(kind Kind, data []byte) error {
+	schemas, err := compile()
+	if err != nil {
+		return err
+	}
+
+	schema, ok := schemas[kind]
+	if !ok {
+		return fmt.Errorf("no schema for %s", kind)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return schema.Validate(doc)
+}
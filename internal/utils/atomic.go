@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AtomicFile is written to a temporary file next to its destination and renamed
+// into place on Commit, so an interrupted write never leaves a truncated file behind.
+type AtomicFile struct {
+	*os.File
+	path string
+	done bool
+}
+
+// CreateAtomic creates a temporary file that replaces path once committed
+func CreateAtomic(path string) (*AtomicFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file for %q: %w", path, err)
+	}
+	return &AtomicFile{File: tmp, path: path}, nil
+}
+
+// Commit flushes the temporary file to disk and moves it to its destination
+func (f *AtomicFile) Commit() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to sync %q: %w", f.path, err)
+	}
+	if err := f.Chmod(0644); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to set permissions of %q: %w", f.path, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to close %q: %w", f.path, err)
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to move %q into place: %w", f.path, err)
+	}
+	return nil
+}
+
+// CommitTo is Commit with a destination chosen after the content was written, such as
+// a name derived from its checksum. The destination must be on the same file system.
+func (f *AtomicFile) CommitTo(path string) error {
+	f.path = path
+	return f.Commit()
+}
+
+// Abort discards the temporary file, it does nothing after Commit
+func (f *AtomicFile) Abort() {
+	if f.done {
+		return
+	}
+	f.done = true
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+}
+
+// WriteFileAtomic writes data to path through a temporary file
+func WriteFileAtomic(path string, data []byte) error {
+	f, err := CreateAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write %q: %w", path, err)
+	}
+	return f.Commit()
+}
@@ -0,0 +1,59 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bnidev/trac2gitlab/internal/utils"
+)
+
+func TestAtomicFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ticket-1.json")
+
+	t.Run("Abort keeps the previous content", func(t *testing.T) {
+		if err := utils.WriteFileAtomic(path, []byte("old")); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+
+		f, err := utils.CreateAtomic(path)
+		if err != nil {
+			t.Fatalf("failed to create atomic file: %v", err)
+		}
+		if _, err := f.WriteString("partial"); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		f.Abort()
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		if string(content) != "old" {
+			t.Errorf("expected previous content, got %q", content)
+		}
+	})
+
+	t.Run("Commit replaces the file and leaves no temporary files", func(t *testing.T) {
+		if err := utils.WriteFileAtomic(path, []byte("new")); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		if string(content) != "new" {
+			t.Errorf("expected new content, got %q", content)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("failed to read directory: %v", err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("expected only the committed file, got %d entries", len(entries))
+		}
+	})
+}
@@ -3,13 +3,26 @@ package utils
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
 // ReadFilesFromDir reads all files with the given extension from the specified directory.
 func ReadFilesFromDir(dirPath string, fileType string, warnWriter io.Writer) ([][]byte, error) {
+	return readFiles(os.DirFS(dirPath), ".", dirPath, fileType, warnWriter)
+}
+
+// ReadFilesFromFS reads all files with the given extension from a directory of fsys,
+// such as an export archive.
+func ReadFilesFromFS(fsys fs.FS, dir string, fileType string, warnWriter io.Writer) ([][]byte, error) {
+	return readFiles(fsys, dir, dir, fileType, warnWriter)
+}
+
+// readFiles reads the files of dir in fsys, warnings name files relative to displayDir
+func readFiles(fsys fs.FS, dir string, displayDir string, fileType string, warnWriter io.Writer) ([][]byte, error) {
 	allowedTypes := map[string]bool{".json": true, ".md": true}
 	if fileType == "" {
 		fileType = ".json"
@@ -21,7 +34,7 @@ func ReadFilesFromDir(dirPath string, fileType string, warnWriter io.Writer) ([]
 		return nil, fmt.Errorf("unsupported file type: %s", fileType)
 	}
 
-	entries, err := os.ReadDir(dirPath)
+	entries, err := fs.ReadDir(fsys, dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
@@ -36,10 +49,10 @@ func ReadFilesFromDir(dirPath string, fileType string, warnWriter io.Writer) ([]
 			continue
 		}
 		if strings.HasSuffix(entry.Name(), fileType) {
-			fullPath := filepath.Join(dirPath, entry.Name())
-			content, err := os.ReadFile(fullPath)
+			content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
 			if err != nil {
 				if warnWriter != nil {
+					fullPath := filepath.Join(displayDir, entry.Name())
 					if _, err = fmt.Fprintf(warnWriter, "Warning: failed to read file %s: %v\n", fullPath, err); err != nil {
 						return nil, fmt.Errorf("failed to write warning: %w", err)
 					}
@@ -0,0 +1,155 @@
+package fakegitlab
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func (s *Server) listBoards(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var boards []*gitlab.IssueBoard
+	for _, b := range s.state.Boards {
+		if b.ProjectID == p.ID {
+			boards = append(boards, s.renderBoard(p, b))
+		}
+	}
+	return paginate(w, r, boards), http.StatusOK, nil
+}
+
+func (s *Server) getBoard(w http.ResponseWriter, r *request) (any, int, error) {
+	p, b, err := s.pathBoard(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.renderBoard(p, b), http.StatusOK, nil
+}
+
+func (s *Server) createBoard(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.CreateIssueBoardOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Name == nil || *opts.Name == "" {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - name is missing")
+	}
+
+	b := &Board{ID: s.state.nextID("boards"), ProjectID: p.ID, Name: *opts.Name}
+	s.state.Boards = append(s.state.Boards, b)
+	return s.renderBoard(p, b), http.StatusCreated, nil
+}
+
+func (s *Server) listBoardLists(w http.ResponseWriter, r *request) (any, int, error) {
+	_, b, err := s.pathBoard(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return paginate(w, r, s.renderBoardLists(b)), http.StatusOK, nil
+}
+
+// createBoardList appends a list of the issues with a label, each label once per board
+func (s *Server) createBoardList(w http.ResponseWriter, r *request) (any, int, error) {
+	p, b, err := s.pathBoard(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.CreateIssueBoardListOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.LabelID == nil {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - label_id is missing")
+	}
+	if s.findLabel(p, strconv.Itoa(*opts.LabelID)) == nil {
+		return nil, 0, notFound("Label")
+	}
+	if slices.ContainsFunc(b.Lists, func(l *BoardList) bool { return l.LabelID == *opts.LabelID }) {
+		return nil, 0, errorf(http.StatusBadRequest, "Label has already been taken")
+	}
+
+	list := &BoardList{ID: s.state.nextID("board_lists"), LabelID: *opts.LabelID}
+	b.Lists = append(b.Lists, list)
+	return s.renderBoardList(b, len(b.Lists)-1), http.StatusCreated, nil
+}
+
+// moveBoardList moves a list to a position, the lists in between shift by one
+func (s *Server) moveBoardList(w http.ResponseWriter, r *request) (any, int, error) {
+	_, b, err := s.pathBoard(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	id, err := r.intParam("list")
+	if err != nil {
+		return nil, 0, err
+	}
+	index := slices.IndexFunc(b.Lists, func(l *BoardList) bool { return l.ID == id })
+	if index < 0 {
+		return nil, 0, notFound("List")
+	}
+	var opts gitlab.UpdateIssueBoardListOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Position == nil || *opts.Position < 0 || *opts.Position >= len(b.Lists) {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - position is invalid")
+	}
+
+	list := b.Lists[index]
+	b.Lists = slices.Insert(slices.Delete(b.Lists, index, index+1), *opts.Position, list)
+	return s.renderBoardList(b, *opts.Position), http.StatusOK, nil
+}
+
+// pathBoard returns the project and board of the path
+func (s *Server) pathBoard(r *request) (*Project, *Board, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := r.intParam("board")
+	if err != nil {
+		return nil, nil, err
+	}
+	b := s.state.board(p.ID, id)
+	if b == nil {
+		return nil, nil, notFound("Board")
+	}
+	return p, b, nil
+}
+
+func (s *Server) renderBoard(p *Project, b *Board) *gitlab.IssueBoard {
+	return &gitlab.IssueBoard{
+		ID:      b.ID,
+		Name:    b.Name,
+		Project: &gitlab.Project{ID: p.ID, Name: p.Name, Path: p.Path},
+		Lists:   s.renderBoardLists(b),
+	}
+}
+
+func (s *Server) renderBoardLists(b *Board) []*gitlab.BoardList {
+	lists := make([]*gitlab.BoardList, 0, len(b.Lists))
+	for i := range b.Lists {
+		lists = append(lists, s.renderBoardList(b, i))
+	}
+	return lists
+}
+
+// renderBoardList renders the list at an index, its position on the board
+func (s *Server) renderBoardList(b *Board, index int) *gitlab.BoardList {
+	list := &gitlab.BoardList{ID: b.Lists[index].ID, Position: index}
+	for _, l := range s.state.Labels {
+		if l.ProjectID == b.ProjectID && l.ID == b.Lists[index].LabelID {
+			list.Label = s.renderLabel(l)
+		}
+	}
+	return list
+}
@@ -0,0 +1,400 @@
+package fakegitlab
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// listIssues filters by state, labels and IIDs, newest first
+func (s *Server) listIssues(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := r.URL.Query()
+	state := query.Get("state")
+	var labels []string
+	if query.Get("labels") != "" {
+		labels = strings.Split(query.Get("labels"), ",")
+	}
+	iids := query["iids[]"]
+
+	var issues []*gitlab.Issue
+	for i := len(s.state.Issues) - 1; i >= 0; i-- {
+		issue := s.state.Issues[i]
+		if issue.ProjectID != p.ID || (state != "" && state != "all" && issue.State != state) {
+			continue
+		}
+		if len(iids) > 0 && !slices.Contains(iids, strconv.Itoa(issue.IID)) {
+			continue
+		}
+		if slices.ContainsFunc(labels, func(l string) bool { return !slices.Contains(issue.Labels, l) }) {
+			continue
+		}
+		issues = append(issues, s.renderIssue(r, p, issue))
+	}
+	return paginate(w, r, issues), http.StatusOK, nil
+}
+
+func (s *Server) getIssue(w http.ResponseWriter, r *request) (any, int, error) {
+	p, issue, err := s.pathIssue(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.renderIssue(r, p, issue), http.StatusOK, nil
+}
+
+// createIssue creates an issue as the user of the token. Like GitLab, it accepts an IID
+// and a creation time, and creates missing labels.
+func (s *Server) createIssue(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.CreateIssueOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Title == nil || *opts.Title == "" {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - title is missing")
+	}
+
+	issue := &Issue{
+		ID:        s.state.nextID("issues"),
+		ProjectID: p.ID,
+		Title:     *opts.Title,
+		State:     "opened",
+		AuthorID:  r.user.ID,
+		CreatedAt: now(),
+	}
+	if opts.IID != nil {
+		if s.state.issue(p.ID, *opts.IID) != nil {
+			return nil, 0, errorf(http.StatusConflict, "Issue with IID %d already exists", *opts.IID)
+		}
+		issue.IID = *opts.IID
+	} else {
+		for _, other := range s.state.Issues {
+			if other.ProjectID == p.ID {
+				issue.IID = max(issue.IID, other.IID)
+			}
+		}
+		issue.IID++
+	}
+	if opts.Description != nil {
+		issue.Description = *opts.Description
+	}
+	if opts.CreatedAt != nil {
+		issue.CreatedAt = opts.CreatedAt.UTC()
+	}
+	issue.UpdatedAt = issue.CreatedAt
+	if err := s.setMilestone(p, issue, opts.MilestoneID); err != nil {
+		return nil, 0, err
+	}
+	if opts.AssigneeIDs != nil {
+		issue.AssigneeIDs = *opts.AssigneeIDs
+	}
+	if opts.Labels != nil {
+		issue.Labels = s.ensureLabels(p, *opts.Labels)
+	}
+
+	s.state.Issues = append(s.state.Issues, issue)
+	return s.renderIssue(r, p, issue), http.StatusCreated, nil
+}
+
+func (s *Server) updateIssue(w http.ResponseWriter, r *request) (any, int, error) {
+	p, issue, err := s.pathIssue(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.UpdateIssueOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+
+	updatedAt := now()
+	if opts.UpdatedAt != nil {
+		updatedAt = opts.UpdatedAt.UTC()
+	}
+	if opts.Title != nil {
+		issue.Title = *opts.Title
+	}
+	if opts.Description != nil {
+		issue.Description = *opts.Description
+	}
+	if err := s.setMilestone(p, issue, opts.MilestoneID); err != nil {
+		return nil, 0, err
+	}
+	if opts.AssigneeIDs != nil {
+		issue.AssigneeIDs = *opts.AssigneeIDs
+	}
+	if opts.Labels != nil {
+		issue.Labels = s.ensureLabels(p, *opts.Labels)
+	}
+	if opts.AddLabels != nil {
+		issue.Labels = s.ensureLabels(p, append(issue.Labels, *opts.AddLabels...))
+	}
+	if opts.RemoveLabels != nil {
+		remove := splitLabels(*opts.RemoveLabels)
+		issue.Labels = slices.DeleteFunc(issue.Labels, func(l string) bool { return slices.Contains(remove, l) })
+	}
+
+	if opts.StateEvent != nil {
+		switch *opts.StateEvent {
+		case "close":
+			if issue.State != "closed" {
+				issue.State = "closed"
+				issue.ClosedAt = &updatedAt
+				issue.ClosedByID = r.user.ID
+			}
+		case "reopen":
+			issue.State = "opened"
+			issue.ClosedAt = nil
+			issue.ClosedByID = 0
+		default:
+			return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - state_event does not have a valid value")
+		}
+	}
+	issue.UpdatedAt = updatedAt
+
+	return s.renderIssue(r, p, issue), http.StatusOK, nil
+}
+
+// setMilestone sets the milestone of an issue, 0 removes it
+func (s *Server) setMilestone(p *Project, issue *Issue, id *int) error {
+	if id == nil {
+		return nil
+	}
+	if *id != 0 && s.state.milestone(p.ID, *id) == nil {
+		return notFound("Milestone")
+	}
+	issue.MilestoneID = *id
+	return nil
+}
+
+// ensureLabels creates the labels of an issue that do not exist yet and returns them without duplicates
+func (s *Server) ensureLabels(p *Project, names []string) []string {
+	var labels []string
+	for _, name := range splitLabels(names) {
+		if slices.Contains(labels, name) {
+			continue
+		}
+		if s.state.label(p.ID, name) == nil {
+			s.state.Labels = append(s.state.Labels, &Label{ID: s.state.nextID("labels"), ProjectID: p.ID, Name: name, Color: "#6699cc"})
+		}
+		labels = append(labels, name)
+	}
+	return labels
+}
+
+// splitLabels splits label options, which the client sends as one comma separated string
+func splitLabels(options []string) []string {
+	var labels []string
+	for _, option := range options {
+		for _, name := range strings.Split(option, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				labels = append(labels, name)
+			}
+		}
+	}
+	return labels
+}
+
+// listNotes returns the notes of an issue, newest first unless sort=asc
+func (s *Server) listNotes(w http.ResponseWriter, r *request) (any, int, error) {
+	p, issue, err := s.pathIssue(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var notes []*gitlab.Note
+	for _, n := range s.state.Notes {
+		if n.ProjectID == p.ID && n.IssueIID == issue.IID {
+			notes = append(notes, s.renderNote(r, issue, n))
+		}
+	}
+	asc := r.URL.Query().Get("sort") == "asc"
+	sort.SliceStable(notes, func(i, j int) bool {
+		if asc {
+			return notes[i].CreatedAt.Before(*notes[j].CreatedAt)
+		}
+		return notes[i].CreatedAt.After(*notes[j].CreatedAt)
+	})
+	return paginate(w, r, notes), http.StatusOK, nil
+}
+
+// createNote adds a note to an issue. The /duplicate quick action is executed and
+// removed from the body, a note of only quick actions is not kept.
+func (s *Server) createNote(w http.ResponseWriter, r *request) (any, int, error) {
+	p, issue, err := s.pathIssue(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.CreateIssueNoteOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Body == nil || *opts.Body == "" {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - body is missing")
+	}
+
+	createdAt := now()
+	if opts.CreatedAt != nil {
+		createdAt = opts.CreatedAt.UTC()
+	}
+
+	body := *opts.Body
+	for _, m := range duplicateAction.FindAllStringSubmatch(body, -1) {
+		iid, _ := strconv.Atoi(m[1])
+		if original := s.state.issue(p.ID, iid); original != nil && original != issue {
+			s.markDuplicate(r, issue, original, createdAt)
+		}
+	}
+	body = strings.TrimSpace(duplicateAction.ReplaceAllString(body, ""))
+	if body == "" {
+		return map[string]any{"commands_changes": map[string]any{}}, http.StatusAccepted, nil
+	}
+
+	n := &Note{
+		ID:        s.state.nextID("notes"),
+		ProjectID: p.ID,
+		IssueIID:  issue.IID,
+		Body:      body,
+		AuthorID:  r.user.ID,
+		Internal:  opts.Internal != nil && *opts.Internal,
+		CreatedAt: createdAt,
+	}
+	s.state.Notes = append(s.state.Notes, n)
+	return s.renderNote(r, issue, n), http.StatusCreated, nil
+}
+
+// duplicateAction matches the /duplicate quick action on a line of its own
+var duplicateAction = regexp.MustCompile(`(?m)^/duplicate[ \t]+#(\d+)[ \t]*$`)
+
+// markDuplicate closes an issue as a duplicate of the original and links them, with a
+// system note like GitLab's
+func (s *Server) markDuplicate(r *request, issue, original *Issue, at time.Time) {
+	if issue.State != "closed" {
+		issue.State = "closed"
+		issue.ClosedAt = &at
+		issue.ClosedByID = r.user.ID
+	}
+	issue.DuplicatedToIID = original.IID
+	if !slices.Contains(issue.RelatedIIDs, original.IID) {
+		issue.RelatedIIDs = append(issue.RelatedIIDs, original.IID)
+		original.RelatedIIDs = append(original.RelatedIIDs, issue.IID)
+	}
+	s.state.Notes = append(s.state.Notes, &Note{
+		ID:        s.state.nextID("notes"),
+		ProjectID: issue.ProjectID,
+		IssueIID:  issue.IID,
+		Body:      fmt.Sprintf("marked this issue as a duplicate of #%d", original.IID),
+		AuthorID:  r.user.ID,
+		System:    true,
+		CreatedAt: at,
+	})
+}
+
+// pathIssue returns the project and issue of the path
+func (s *Server) pathIssue(r *request) (*Project, *Issue, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	iid, err := r.intParam("issue")
+	if err != nil {
+		return nil, nil, err
+	}
+	issue := s.state.issue(p.ID, iid)
+	if issue == nil {
+		return nil, nil, notFound("Issue")
+	}
+	return p, issue, nil
+}
+
+func (s *Server) renderIssue(r *request, p *Project, issue *Issue) *gitlab.Issue {
+	createdAt, updatedAt := issue.CreatedAt, issue.UpdatedAt
+	rendered := &gitlab.Issue{
+		ID:          issue.ID,
+		IID:         issue.IID,
+		ProjectID:   issue.ProjectID,
+		Title:       issue.Title,
+		Description: issue.Description,
+		State:       issue.State,
+		Labels:      gitlab.Labels(issue.Labels),
+		CreatedAt:   &createdAt,
+		UpdatedAt:   &updatedAt,
+		ClosedAt:    issue.ClosedAt,
+		WebURL:      fmt.Sprintf("%s/%s/-/issues/%d", r.base, p.Path, issue.IID),
+	}
+	if rendered.Labels == nil {
+		rendered.Labels = gitlab.Labels{}
+	}
+
+	author := s.userSummary(r, issue.AuthorID)
+	rendered.Author = &gitlab.IssueAuthor{ID: author.ID, Username: author.Username, Name: author.Name, State: author.State, WebURL: author.WebURL}
+	if issue.ClosedByID != 0 {
+		closer := s.userSummary(r, issue.ClosedByID)
+		rendered.ClosedBy = &gitlab.IssueCloser{ID: closer.ID, Username: closer.Username, Name: closer.Name, State: closer.State, WebURL: closer.WebURL}
+	}
+	for _, id := range issue.AssigneeIDs {
+		a := s.userSummary(r, id)
+		rendered.Assignees = append(rendered.Assignees, &gitlab.IssueAssignee{ID: a.ID, Username: a.Username, Name: a.Name, State: a.State, WebURL: a.WebURL})
+	}
+	if len(rendered.Assignees) > 0 {
+		rendered.Assignee = rendered.Assignees[0]
+	}
+	if m := s.state.milestone(p.ID, issue.MilestoneID); m != nil {
+		rendered.Milestone = renderMilestone(r, p, m)
+	}
+	for _, n := range s.state.Notes {
+		if n.ProjectID == p.ID && n.IssueIID == issue.IID && !n.System {
+			rendered.UserNotesCount++
+		}
+	}
+	return rendered
+}
+
+func (s *Server) renderNote(r *request, issue *Issue, n *Note) *gitlab.Note {
+	createdAt := n.CreatedAt
+	author := s.userSummary(r, n.AuthorID)
+	note := &gitlab.Note{
+		ID:           n.ID,
+		Body:         n.Body,
+		CreatedAt:    &createdAt,
+		UpdatedAt:    &createdAt,
+		NoteableID:   issue.ID,
+		NoteableIID:  issue.IID,
+		NoteableType: "Issue",
+		ProjectID:    n.ProjectID,
+		Internal:     n.Internal,
+		System:       n.System,
+	}
+	note.Author = gitlab.NoteAuthor{ID: author.ID, Username: author.Username, Name: author.Name, State: author.State, WebURL: author.WebURL}
+	return note
+}
+
+// userSummary is the user shown as author, assignee or closer
+type userSummary struct {
+	ID       int
+	Username string
+	Name     string
+	State    string
+	WebURL   string
+}
+
+// userSummary returns the user with the ID, or the ghost user GitLab shows for deleted users
+func (s *Server) userSummary(r *request, id int) userSummary {
+	u := s.state.user(id)
+	if u == nil {
+		return userSummary{ID: id, Username: "ghost", Name: "Ghost User", State: "active", WebURL: r.base + "/ghost"}
+	}
+	return userSummary{ID: u.ID, Username: u.Username, Name: u.Name, State: u.State, WebURL: r.base + "/" + u.Username}
+}
@@ -0,0 +1,151 @@
+package fakegitlab
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// listLabels filters by a case-insensitive search in the name
+func (s *Server) listLabels(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	search := strings.ToLower(r.URL.Query().Get("search"))
+
+	var labels []*gitlab.Label
+	for _, l := range s.state.Labels {
+		if l.ProjectID != p.ID || (search != "" && !strings.Contains(strings.ToLower(l.Name), search)) {
+			continue
+		}
+		labels = append(labels, s.renderLabel(l))
+	}
+	return paginate(w, r, labels), http.StatusOK, nil
+}
+
+func (s *Server) getLabel(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	l := s.findLabel(p, r.PathValue("label"))
+	if l == nil {
+		return nil, 0, notFound("Label")
+	}
+	return s.renderLabel(l), http.StatusOK, nil
+}
+
+func (s *Server) createLabel(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.CreateLabelOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Name == nil || *opts.Name == "" || opts.Color == nil {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - name and color are required")
+	}
+	if s.state.label(p.ID, *opts.Name) != nil {
+		return nil, 0, errorf(http.StatusConflict, "Label already exists")
+	}
+
+	l := &Label{ID: s.state.nextID("labels"), ProjectID: p.ID, Name: *opts.Name, Color: *opts.Color, Priority: opts.Priority}
+	if opts.Description != nil {
+		l.Description = *opts.Description
+	}
+	s.state.Labels = append(s.state.Labels, l)
+	return s.renderLabel(l), http.StatusCreated, nil
+}
+
+// updateLabel changes a label given by ID or name in the path or by name in the body.
+// Issues keep their labels across renames.
+func (s *Server) updateLabel(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.UpdateLabelOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+
+	ref := r.PathValue("label")
+	if ref == "" && opts.Name != nil {
+		ref = *opts.Name
+	}
+	l := s.findLabel(p, ref)
+	if l == nil {
+		return nil, 0, notFound("Label")
+	}
+
+	if opts.NewName != nil && *opts.NewName != l.Name {
+		if s.state.label(p.ID, *opts.NewName) != nil {
+			return nil, 0, errorf(http.StatusConflict, "Label already exists")
+		}
+		for _, issue := range s.state.Issues {
+			for i, name := range issue.Labels {
+				if issue.ProjectID == p.ID && name == l.Name {
+					issue.Labels[i] = *opts.NewName
+				}
+			}
+		}
+		l.Name = *opts.NewName
+	}
+	if opts.Color != nil {
+		l.Color = *opts.Color
+	}
+	if opts.Description != nil {
+		l.Description = *opts.Description
+	}
+	if opts.Priority != nil {
+		l.Priority = opts.Priority
+	}
+	return s.renderLabel(l), http.StatusOK, nil
+}
+
+// findLabel returns the label with the ID or name
+func (s *Server) findLabel(p *Project, ref string) *Label {
+	if id, err := strconv.Atoi(ref); err == nil {
+		for _, l := range s.state.Labels {
+			if l.ProjectID == p.ID && l.ID == id {
+				return l
+			}
+		}
+	}
+	return s.state.label(p.ID, ref)
+}
+
+func (s *Server) renderLabel(l *Label) *gitlab.Label {
+	label := &gitlab.Label{
+		ID:             l.ID,
+		Name:           l.Name,
+		Color:          l.Color,
+		TextColor:      "#FFFFFF",
+		Description:    l.Description,
+		IsProjectLabel: true,
+	}
+	if l.Priority != nil {
+		label.Priority = *l.Priority
+	}
+	for _, issue := range s.state.Issues {
+		if issue.ProjectID != l.ProjectID {
+			continue
+		}
+		for _, name := range issue.Labels {
+			if name != l.Name {
+				continue
+			}
+			if issue.State == "closed" {
+				label.ClosedIssuesCount++
+			} else {
+				label.OpenIssuesCount++
+			}
+		}
+	}
+	return label
+}
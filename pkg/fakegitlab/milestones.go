@@ -0,0 +1,158 @@
+package fakegitlab
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// listMilestones filters by exact title, a case-insensitive search in the title, state and IIDs
+func (s *Server) listMilestones(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := r.URL.Query()
+	title := query.Get("title")
+	search := strings.ToLower(query.Get("search"))
+	state := query.Get("state")
+	iids := query["iids[]"]
+
+	var milestones []*gitlab.Milestone
+	for _, m := range s.state.Milestones {
+		if m.ProjectID != p.ID || (title != "" && m.Title != title) || (state != "" && m.State != state) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(m.Title), search) {
+			continue
+		}
+		if len(iids) > 0 && !slices.Contains(iids, strconv.Itoa(m.IID)) {
+			continue
+		}
+		milestones = append(milestones, renderMilestone(r, p, m))
+	}
+	return paginate(w, r, milestones), http.StatusOK, nil
+}
+
+func (s *Server) getMilestone(w http.ResponseWriter, r *request) (any, int, error) {
+	p, m, err := s.pathMilestone(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return renderMilestone(r, p, m), http.StatusOK, nil
+}
+
+func (s *Server) createMilestone(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.CreateMilestoneOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Title == nil || *opts.Title == "" {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - title is missing")
+	}
+	if slices.ContainsFunc(s.state.Milestones, func(m *Milestone) bool { return m.ProjectID == p.ID && m.Title == *opts.Title }) {
+		return nil, 0, errorf(http.StatusBadRequest, "Milestone title %q has already been taken", *opts.Title)
+	}
+
+	m := &Milestone{
+		ID:        s.state.nextID("milestones"),
+		ProjectID: p.ID,
+		Title:     *opts.Title,
+		StartDate: opts.StartDate,
+		DueDate:   opts.DueDate,
+		State:     "active",
+		CreatedAt: now(),
+	}
+	for _, other := range s.state.Milestones {
+		if other.ProjectID == p.ID {
+			m.IID = max(m.IID, other.IID)
+		}
+	}
+	m.IID++
+	if opts.Description != nil {
+		m.Description = *opts.Description
+	}
+	m.UpdatedAt = m.CreatedAt
+
+	s.state.Milestones = append(s.state.Milestones, m)
+	return renderMilestone(r, p, m), http.StatusCreated, nil
+}
+
+func (s *Server) updateMilestone(w http.ResponseWriter, r *request) (any, int, error) {
+	p, m, err := s.pathMilestone(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.UpdateMilestoneOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+
+	if opts.Title != nil {
+		m.Title = *opts.Title
+	}
+	if opts.Description != nil {
+		m.Description = *opts.Description
+	}
+	if opts.StartDate != nil {
+		m.StartDate = opts.StartDate
+	}
+	if opts.DueDate != nil {
+		m.DueDate = opts.DueDate
+	}
+	if opts.StateEvent != nil {
+		switch *opts.StateEvent {
+		case "close":
+			m.State = "closed"
+		case "activate":
+			m.State = "active"
+		default:
+			return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - state_event does not have a valid value")
+		}
+	}
+	m.UpdatedAt = now()
+
+	return renderMilestone(r, p, m), http.StatusOK, nil
+}
+
+// pathMilestone returns the project and milestone of the path
+func (s *Server) pathMilestone(r *request) (*Project, *Milestone, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := r.intParam("milestone")
+	if err != nil {
+		return nil, nil, err
+	}
+	m := s.state.milestone(p.ID, id)
+	if m == nil {
+		return nil, nil, notFound("Milestone")
+	}
+	return p, m, nil
+}
+
+func renderMilestone(r *request, p *Project, m *Milestone) *gitlab.Milestone {
+	createdAt, updatedAt := m.CreatedAt, m.UpdatedAt
+	return &gitlab.Milestone{
+		ID:          m.ID,
+		IID:         m.IID,
+		ProjectID:   m.ProjectID,
+		Title:       m.Title,
+		Description: m.Description,
+		StartDate:   m.StartDate,
+		DueDate:     m.DueDate,
+		State:       m.State,
+		WebURL:      fmt.Sprintf("%s/%s/-/milestones/%d", r.base, p.Path, m.IID),
+		CreatedAt:   &createdAt,
+		UpdatedAt:   &updatedAt,
+	}
+}
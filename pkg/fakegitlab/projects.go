@@ -0,0 +1,218 @@
+package fakegitlab
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func (s *Server) listProjects(w http.ResponseWriter, r *request) (any, int, error) {
+	projects := make([]*gitlab.Project, 0, len(s.state.Projects))
+	for _, p := range s.state.Projects {
+		projects = append(projects, renderProject(r, p))
+	}
+	return paginate(w, r, projects), http.StatusOK, nil
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return renderProject(r, p), http.StatusOK, nil
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *request) (any, int, error) {
+	var opts gitlab.CreateProjectOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Name == nil && opts.Path == nil {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - name or path is required")
+	}
+
+	p := &Project{ID: s.state.nextID("projects")}
+	if opts.Name != nil {
+		p.Name = *opts.Name
+	}
+	if opts.Path != nil {
+		p.Path = *opts.Path
+	} else {
+		p.Path = strings.ToLower(strings.ReplaceAll(p.Name, " ", "-"))
+	}
+	if p.Name == "" {
+		p.Name = p.Path
+	}
+	p.Members = []*Member{{UserID: r.user.ID, AccessLevel: int(gitlab.OwnerPermissions)}}
+	s.state.Projects = append(s.state.Projects, p)
+	return renderProject(r, p), http.StatusCreated, nil
+}
+
+func (s *Server) listMembers(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	members := make([]*gitlab.ProjectMember, 0, len(p.Members))
+	for _, m := range p.Members {
+		members = append(members, s.renderMember(r, m))
+	}
+	return paginate(w, r, members), http.StatusOK, nil
+}
+
+func (s *Server) getMember(w http.ResponseWriter, r *request) (any, int, error) {
+	_, m, err := s.pathMember(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.renderMember(r, m), http.StatusOK, nil
+}
+
+func (s *Server) addMember(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts struct {
+		UserID      int `json:"user_id"`
+		AccessLevel int `json:"access_level"`
+	}
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if s.state.user(opts.UserID) == nil {
+		return nil, 0, notFound("User")
+	}
+	if slices.ContainsFunc(p.Members, func(m *Member) bool { return m.UserID == opts.UserID }) {
+		return nil, 0, errorf(http.StatusConflict, "Member already exists")
+	}
+
+	m := &Member{UserID: opts.UserID, AccessLevel: opts.AccessLevel}
+	p.Members = append(p.Members, m)
+	return s.renderMember(r, m), http.StatusCreated, nil
+}
+
+func (s *Server) editMember(w http.ResponseWriter, r *request) (any, int, error) {
+	_, m, err := s.pathMember(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.EditProjectMemberOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.AccessLevel != nil {
+		m.AccessLevel = int(*opts.AccessLevel)
+	}
+	return s.renderMember(r, m), http.StatusOK, nil
+}
+
+// pathMember returns the project and member of the path
+func (s *Server) pathMember(r *request) (*Project, *Member, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	userID, err := r.intParam("user")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range p.Members {
+		if m.UserID == userID {
+			return p, m, nil
+		}
+	}
+	return nil, nil, notFound("Member")
+}
+
+// upload keeps the checksum of a file uploaded for Markdown and returns its link
+func (s *Server) upload(w http.ResponseWriter, r *request) (any, int, error) {
+	p, err := s.project(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - file is missing")
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read upload: %w", err)
+	}
+
+	secret := make([]byte, 16)
+	_, _ = rand.Read(secret)
+	u := &Upload{
+		ID:        s.state.nextID("uploads"),
+		ProjectID: p.ID,
+		Filename:  header.Filename,
+		Size:      size,
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		URL:       "/uploads/" + hex.EncodeToString(secret) + "/" + header.Filename,
+	}
+	s.state.Uploads = append(s.state.Uploads, u)
+
+	markdown := fmt.Sprintf("[%s](%s)", u.Filename, u.URL)
+	if isImage(u.Filename) {
+		markdown = "!" + markdown
+	}
+	return &gitlab.MarkdownUploadedFile{
+		ID:       u.ID,
+		Alt:      u.Filename,
+		URL:      u.URL,
+		FullPath: fmt.Sprintf("/-/project/%d%s", p.ID, u.URL),
+		Markdown: markdown,
+	}, http.StatusCreated, nil
+}
+
+// isImage reports whether GitLab would embed an upload as an image
+func isImage(filename string) bool {
+	i := strings.LastIndex(filename, ".")
+	if i < 0 {
+		return false
+	}
+	switch strings.ToLower(filename[i+1:]) {
+	case "png", "jpg", "jpeg", "gif", "bmp", "tiff", "ico", "webp":
+		return true
+	}
+	return false
+}
+
+func renderProject(r *request, p *Project) *gitlab.Project {
+	return &gitlab.Project{
+		ID:                p.ID,
+		Name:              p.Name,
+		Path:              p.Path,
+		PathWithNamespace: p.Path,
+		WebURL:            r.base + "/" + p.Path,
+	}
+}
+
+func (s *Server) renderMember(r *request, m *Member) *gitlab.ProjectMember {
+	member := &gitlab.ProjectMember{ID: m.UserID, AccessLevel: gitlab.AccessLevelValue(m.AccessLevel), State: "active"}
+	if u := s.state.user(m.UserID); u != nil {
+		createdAt := u.CreatedAt
+		member.Username, member.Name, member.Email, member.State = u.Username, u.Name, u.Email, u.State
+		member.CreatedAt = &createdAt
+		member.WebURL = r.base + "/" + u.Username
+	}
+	return member
+}
+
+// now returns the current time for objects created without a timestamp
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
@@ -0,0 +1,317 @@
+package fakegitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiPrefix is the path of the REST API, the default api_path of the configuration
+const apiPrefix = "/api/v4"
+
+// Options configure a fake GitLab server
+type Options struct {
+	// AdminToken authenticates as the administrator. When empty, every token that is
+	// not an impersonation token does.
+	AdminToken string
+	// StatePath is the file Save writes the state to, empty keeps it in memory only
+	StatePath string
+}
+
+// Server is a fake GitLab. Requests are handled one at a time.
+type Server struct {
+	mu      sync.Mutex
+	state   *State
+	options Options
+	mux     *http.ServeMux
+	// dirty is set when a request changed the state since it was last saved
+	dirty bool
+}
+
+// NewServer serves the state
+func NewServer(state *State, options Options) *Server {
+	s := &Server{state: state, options: options, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /_fake/state", s.inspect)
+
+	s.handle("GET /version", s.getVersion)
+	s.handle("GET /user", s.getCurrentUser)
+
+	s.handle("GET /users", s.listUsers)
+	s.handle("POST /users", s.createUser)
+	s.handle("GET /users/{user}", s.getUser)
+	s.handle("PUT /users/{user}", s.modifyUser)
+	s.handle("POST /users/{user}/{action}", s.setUserState)
+	s.handle("GET /users/{user}/impersonation_tokens", s.listTokens)
+	s.handle("POST /users/{user}/impersonation_tokens", s.createToken)
+	s.handle("GET /users/{user}/impersonation_tokens/{token}", s.getToken)
+	s.handle("DELETE /users/{user}/impersonation_tokens/{token}", s.revokeToken)
+
+	s.handle("GET /projects", s.listProjects)
+	s.handle("POST /projects", s.createProject)
+	s.handle("GET /projects/{project}", s.getProject)
+	s.handle("GET /projects/{project}/members", s.listMembers)
+	s.handle("GET /projects/{project}/members/all", s.listMembers)
+	s.handle("POST /projects/{project}/members", s.addMember)
+	s.handle("GET /projects/{project}/members/{user}", s.getMember)
+	s.handle("GET /projects/{project}/members/all/{user}", s.getMember)
+	s.handle("PUT /projects/{project}/members/{user}", s.editMember)
+
+	s.handle("GET /projects/{project}/issues", s.listIssues)
+	s.handle("POST /projects/{project}/issues", s.createIssue)
+	s.handle("GET /projects/{project}/issues/{issue}", s.getIssue)
+	s.handle("PUT /projects/{project}/issues/{issue}", s.updateIssue)
+	s.handle("GET /projects/{project}/issues/{issue}/notes", s.listNotes)
+	s.handle("POST /projects/{project}/issues/{issue}/notes", s.createNote)
+
+	s.handle("GET /projects/{project}/milestones", s.listMilestones)
+	s.handle("POST /projects/{project}/milestones", s.createMilestone)
+	s.handle("GET /projects/{project}/milestones/{milestone}", s.getMilestone)
+	s.handle("PUT /projects/{project}/milestones/{milestone}", s.updateMilestone)
+
+	s.handle("GET /projects/{project}/labels", s.listLabels)
+	s.handle("POST /projects/{project}/labels", s.createLabel)
+	s.handle("GET /projects/{project}/labels/{label}", s.getLabel)
+	s.handle("PUT /projects/{project}/labels", s.updateLabel)
+	s.handle("PUT /projects/{project}/labels/{label}", s.updateLabel)
+
+	s.handle("GET /projects/{project}/boards", s.listBoards)
+	s.handle("POST /projects/{project}/boards", s.createBoard)
+	s.handle("GET /projects/{project}/boards/{board}", s.getBoard)
+	s.handle("GET /projects/{project}/boards/{board}/lists", s.listBoardLists)
+	s.handle("POST /projects/{project}/boards/{board}/lists", s.createBoardList)
+	s.handle("PUT /projects/{project}/boards/{board}/lists/{list}", s.moveBoardList)
+
+	s.handle("POST /projects/{project}/uploads", s.upload)
+
+	return s
+}
+
+// request is an authenticated API request
+type request struct {
+	*http.Request
+	user *User
+	// base is the URL of the server, for web URLs in responses
+	base string
+}
+
+// apiError is an error answered with a status and a GitLab error message
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// errorf returns an API error with a formatted message
+func errorf(status int, format string, args ...any) error {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// notFound returns the error GitLab answers for missing objects, e.g. "404 Issue Not Found"
+func notFound(what string) error {
+	return errorf(http.StatusNotFound, "404 %s Not Found", what)
+}
+
+// handler handles an API request and returns the response body and status
+type handler func(w http.ResponseWriter, r *request) (any, int, error)
+
+// handle registers an API handler below the API prefix
+func (s *Server) handle(pattern string, h handler) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.HandleFunc(method+" "+apiPrefix+path, func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.authenticate(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		req := &request{Request: r, user: user, base: "http://" + r.Host}
+		body, status, err := h(w, req)
+		if err != nil {
+			slog.Debug("Fake GitLab request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+			writeError(w, err)
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			s.dirty = true
+		}
+		if status == http.StatusNoContent {
+			w.WriteHeader(status)
+			return
+		}
+		writeJSON(w, status, body)
+	})
+}
+
+// ServeHTTP handles a request, one at a time
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	slog.Debug("Fake GitLab request", "method", r.Method, "url", r.URL.String())
+	s.mux.ServeHTTP(w, r)
+}
+
+// State returns the state of the server. It must not be used while requests are served.
+func (s *Server) State() *State {
+	return s.state
+}
+
+// inspect answers the complete state, for assertions after a rehearsal
+func (s *Server) inspect(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.state)
+}
+
+// Save writes the state to its file if it changed since it was last saved. Saving after
+// every request would rewrite the whole state each time, so callers save periodically
+// and on shutdown.
+func (s *Server) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.options.StatePath == "" || !s.dirty {
+		return nil
+	}
+	if err := s.state.Save(s.options.StatePath); err != nil {
+		return fmt.Errorf("failed to save fake GitLab state: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// authenticate returns the user of the token sent with the request
+func (s *Server) authenticate(r *http.Request) (*User, error) {
+	value := r.Header.Get("PRIVATE-TOKEN")
+	if value == "" {
+		value = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if value == "" {
+		return nil, errorf(http.StatusUnauthorized, "401 Unauthorized")
+	}
+
+	var user *User
+	if t := s.state.token(value); t != nil {
+		if t.Revoked || (t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now())) {
+			return nil, errorf(http.StatusUnauthorized, "401 Unauthorized")
+		}
+		user = s.state.user(t.UserID)
+	} else if s.options.AdminToken == "" || value == s.options.AdminToken {
+		user = s.state.user(RootUserID)
+	}
+	if user == nil {
+		return nil, errorf(http.StatusUnauthorized, "401 Unauthorized")
+	}
+	if user.State != "active" {
+		return nil, errorf(http.StatusForbidden, "403 Forbidden - Your account has been %s.", user.State)
+	}
+	return user, nil
+}
+
+// requireAdmin fails unless the request was sent by an administrator
+func (r *request) requireAdmin() error {
+	if !r.user.IsAdmin {
+		return errorf(http.StatusForbidden, "403 Forbidden")
+	}
+	return nil
+}
+
+// decode reads the JSON body of a request into v
+func (r *request) decode(v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "400 Bad request - %v", err)
+	}
+	return nil
+}
+
+// intParam returns a numeric path parameter
+func (r *request) intParam(name string) (int, error) {
+	value, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		return 0, errorf(http.StatusNotFound, "404 Not Found")
+	}
+	return value, nil
+}
+
+// project returns the project of the path, given by ID or by path
+func (s *Server) project(r *request) (*Project, error) {
+	ref := r.PathValue("project")
+	if id, err := strconv.Atoi(ref); err == nil {
+		if p := s.state.project(id); p != nil {
+			return p, nil
+		}
+		return nil, notFound("Project")
+	}
+	for _, p := range s.state.Projects {
+		if p.Path == ref {
+			return p, nil
+		}
+	}
+	return nil, notFound("Project")
+}
+
+// pathUser returns the user of the path
+func (s *Server) pathUser(r *request) (*User, error) {
+	id, err := r.intParam("user")
+	if err != nil {
+		return nil, err
+	}
+	if u := s.state.user(id); u != nil {
+		return u, nil
+	}
+	return nil, notFound("User")
+}
+
+// paginate returns the page of items requested and sets the pagination headers
+func paginate[T any](w http.ResponseWriter, r *request, items []T) []T {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage < 1 {
+		perPage = 20
+	}
+	perPage = min(perPage, 100)
+
+	totalPages := max((len(items)+perPage-1)/perPage, 1)
+	h := w.Header()
+	h.Set("X-Page", strconv.Itoa(page))
+	h.Set("X-Per-Page", strconv.Itoa(perPage))
+	h.Set("X-Total", strconv.Itoa(len(items)))
+	h.Set("X-Total-Pages", strconv.Itoa(totalPages))
+	if page < totalPages {
+		h.Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+	if page > 1 {
+		h.Set("X-Prev-Page", strconv.Itoa(page-1))
+	}
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+	return items[start:end]
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(body)
+}
+
+// writeError writes an error the way GitLab does, as a JSON object with a message
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if apiErr, ok := err.(*apiError); ok {
+		status = apiErr.status
+	}
+	writeJSON(w, status, map[string]string{"message": err.Error()})
+}
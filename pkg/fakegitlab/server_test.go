@@ -0,0 +1,166 @@
+package fakegitlab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/pkg/gitlab"
+
+	client "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestServer_GitLabClient(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	state := NewState()
+	state.EnsureProject(7, "trac")
+	fake := NewServer(state, Options{AdminToken: "admin", StatePath: statePath})
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.GitLab.BaseURL = srv.URL
+	cfg.GitLab.APIPath = "/api/v4"
+	cfg.GitLab.Token = "admin"
+	cfg.ImportOptions.CreateUsers = true
+	gl, err := gitlab.NewGitLabClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := gl.ValidateGitLab(ctx); err != nil {
+		t.Fatalf("ValidateGitLab failed: %v", err)
+	}
+
+	milestone, err := gl.CreateMilestone(ctx, 7, &gitlab.MilestoneOptions{Title: "1.0", Description: "First release"})
+	if err != nil {
+		t.Fatalf("CreateMilestone failed: %v", err)
+	}
+	if found, err := gl.GetMilestoneByName(ctx, 7, "1.0"); err != nil || found == nil || found.ID != milestone.ID {
+		t.Errorf("GetMilestoneByName = %+v, %v", found, err)
+	}
+
+	if _, err := gl.CreateLabel(ctx, 7, &client.CreateLabelOptions{Name: client.Ptr("component::core")}); err != nil {
+		t.Fatalf("CreateLabel failed: %v", err)
+	}
+	if label, err := gl.GetLabelbyName(ctx, 7, "component::core"); err != nil || label == nil || label.Color == "" {
+		t.Errorf("GetLabelbyName = %+v, %v", label, err)
+	}
+
+	board, err := gl.CreateIssueBoard(ctx, 7, "Workflow")
+	if err != nil {
+		t.Fatalf("CreateIssueBoard failed: %v", err)
+	}
+	for _, name := range []string{"status::new", "status::testing"} {
+		label, err := gl.CreateLabel(ctx, 7, &client.CreateLabelOptions{Name: client.Ptr(name)})
+		if err != nil {
+			t.Fatalf("CreateLabel failed: %v", err)
+		}
+		if _, err := gl.CreateIssueBoardLabelList(ctx, 7, board.ID, label.ID); err != nil {
+			t.Fatalf("CreateIssueBoardLabelList failed: %v", err)
+		}
+	}
+	lists, err := gl.GetIssueBoardLists(ctx, 7, board.ID)
+	if err != nil || len(lists) != 2 {
+		t.Fatalf("GetIssueBoardLists = %+v, %v", lists, err)
+	}
+	if moved, err := gl.MoveIssueBoardList(ctx, 7, board.ID, lists[1].ID, 0); err != nil || moved.Position != 0 || moved.Label.Name != "status::testing" {
+		t.Errorf("MoveIssueBoardList = %+v, %v", moved, err)
+	}
+	if found, err := gl.GetIssueBoardByName(ctx, 7, "Workflow"); err != nil || found == nil || found.Lists[1].Label.Name != "status::new" {
+		t.Errorf("GetIssueBoardByName = %+v, %v", found, err)
+	}
+
+	uploaded, err := gl.UploadProjectFile(ctx, 7, strings.NewReader("trace"), "trace.txt")
+	if err != nil || !strings.HasSuffix(uploaded.URL, "/trace.txt") || uploaded.Markdown != "[trace.txt]("+uploaded.URL+")" {
+		t.Fatalf("UploadProjectFile = %+v, %v", uploaded, err)
+	}
+
+	cache := gitlab.NewUserSessionCache()
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	issue, err := gl.CreateIssueAsUser(ctx, cfg, cache, 7, "alice@example.com", &gitlab.CreateIssueOptions{
+		IID:         client.Ptr(42),
+		Title:       client.Ptr("Crash on start"),
+		CreatedAt:   &created,
+		MilestoneID: &milestone.ID,
+		Labels:      &client.LabelOptions{"component::core", "type::defect"},
+	})
+	if err != nil {
+		t.Fatalf("CreateIssueAsUser failed: %v", err)
+	}
+	if issue.IID != 42 || issue.Author.Username != "alice" || !issue.CreatedAt.Equal(created) || issue.Milestone == nil || len(issue.Labels) != 2 {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+	if _, err := gl.CreateIssue(ctx, 7, &gitlab.CreateIssueOptions{IID: client.Ptr(42), Title: client.Ptr("again")}); err == nil {
+		t.Error("expected an error for a duplicate IID")
+	}
+
+	closed, err := gl.UpdateIssue(ctx, 7, 42, &gitlab.UpdateIssueOptions{StateEvent: client.Ptr("close")})
+	if err != nil || closed.State != "closed" || closed.ClosedBy == nil || closed.ClosedBy.Username != "root" {
+		t.Errorf("UpdateIssue = %+v, %v", closed, err)
+	}
+	if _, err := gl.GetIssue(ctx, 7, 43); err == nil {
+		t.Error("expected an error for a missing issue")
+	}
+
+	// The /duplicate quick action closes the issue and is removed from the note
+	if _, err := gl.CreateIssue(ctx, 7, &gitlab.CreateIssueOptions{IID: client.Ptr(44), Title: client.Ptr("Crash again")}); err != nil {
+		t.Fatalf("CreateIssue failed: %v", err)
+	}
+	note, err := gl.CreateIssueNoteAsUser(ctx, cfg, cache, 7, 44, "alice@example.com", &gitlab.CreateIssueNoteOptions{
+		Body:      client.Ptr("Closed as duplicate of #42.\n\n/duplicate #42"),
+		CreatedAt: &created,
+	})
+	if err != nil || note.Body != "Closed as duplicate of #42." || note.Author.Username != "alice" || !note.CreatedAt.Equal(created) {
+		t.Errorf("CreateIssueNoteAsUser = %+v, %v", note, err)
+	}
+	if notes, err := gl.ListIssueNotes(ctx, 7, 44); err != nil || len(notes) != 2 || !notes[0].System {
+		t.Errorf("ListIssueNotes = %+v, %v", notes, err)
+	}
+
+	cache.RevokeAll(ctx, gl)
+
+	// The state is inspected over HTTP and saved on request
+	resp, err := http.Get(srv.URL + "/_fake/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var inspected State
+	if err := json.NewDecoder(resp.Body).Decode(&inspected); err != nil {
+		t.Fatal(err)
+	}
+	if len(inspected.Users) != 2 || len(inspected.Tokens) != 1 || !inspected.Tokens[0].Revoked {
+		t.Errorf("unexpected users %+v and tokens %+v", inspected.Users, inspected.Tokens)
+	}
+	if len(inspected.Labels) != 4 || len(inspected.Uploads) != 1 || inspected.Uploads[0].Size != 5 {
+		t.Errorf("unexpected labels %+v and uploads %+v", inspected.Labels, inspected.Uploads)
+	}
+
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("expected the state to be saved only on request, got %v", err)
+	}
+	if err := fake.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, err := LoadState(statePath)
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if len(saved.Issues) != 2 || saved.Issues[0].State != "closed" || saved.Issues[0].AuthorID != inspected.Users[1].ID {
+		t.Errorf("unexpected saved issues %+v", saved.Issues)
+	}
+	if duplicate := saved.Issues[1]; duplicate.State != "closed" || duplicate.DuplicatedToIID != 42 || len(saved.Issues[0].RelatedIIDs) != 1 {
+		t.Errorf("unexpected duplicate %+v", duplicate)
+	}
+}
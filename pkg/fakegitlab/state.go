@@ -0,0 +1,284 @@
+// Package fakegitlab emulates the parts of the GitLab REST API used by the trac2gitlab
+// importer, for rehearsing a migration without a GitLab instance. All objects live in a
+// State that can be saved to a file and inspected at /_fake/state.
+package fakegitlab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/utils"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// RootUserID is the ID of the administrator the admin token authenticates as
+const RootUserID = 1
+
+// State is everything the fake GitLab knows, in creation order
+type State struct {
+	// Sequences holds the last ID handed out per kind of object
+	Sequences  map[string]int `json:"sequences"`
+	Users      []*User        `json:"users"`
+	Tokens     []*Token       `json:"impersonation_tokens"`
+	Projects   []*Project     `json:"projects"`
+	Issues     []*Issue       `json:"issues"`
+	Notes      []*Note        `json:"notes"`
+	Milestones []*Milestone   `json:"milestones"`
+	Labels     []*Label       `json:"labels"`
+	Boards     []*Board       `json:"boards"`
+	Uploads    []*Upload      `json:"uploads"`
+}
+
+// User is a GitLab user
+type User struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	IsAdmin   bool      `json:"is_admin"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Token is an impersonation token of a user
+type Token struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Name      string     `json:"name"`
+	Token     string     `json:"token"`
+	Scopes    []string   `json:"scopes"`
+	Revoked   bool       `json:"revoked"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Project is a GitLab project with its members
+type Project struct {
+	ID      int       `json:"id"`
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Members []*Member `json:"members,omitempty"`
+}
+
+// Member is the access of a user to a project
+type Member struct {
+	UserID      int `json:"user_id"`
+	AccessLevel int `json:"access_level"`
+}
+
+// Issue is an issue of a project
+type Issue struct {
+	ID          int        `json:"id"`
+	ProjectID   int        `json:"project_id"`
+	IID         int        `json:"iid"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	State       string     `json:"state"`
+	AuthorID    int        `json:"author_id"`
+	AssigneeIDs []int      `json:"assignee_ids,omitempty"`
+	MilestoneID int        `json:"milestone_id,omitempty"`
+	Labels      []string   `json:"labels,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	ClosedByID  int        `json:"closed_by_id,omitempty"`
+	// DuplicatedToIID is the issue this one was marked as a duplicate of
+	DuplicatedToIID int `json:"duplicated_to_iid,omitempty"`
+	// RelatedIIDs are the linked issues of the project
+	RelatedIIDs []int `json:"related_iids,omitempty"`
+}
+
+// Note is a comment on an issue
+type Note struct {
+	ID        int       `json:"id"`
+	ProjectID int       `json:"project_id"`
+	IssueIID  int       `json:"issue_iid"`
+	Body      string    `json:"body"`
+	AuthorID  int       `json:"author_id"`
+	Internal  bool      `json:"internal,omitempty"`
+	System    bool      `json:"system,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Milestone is a milestone of a project
+type Milestone struct {
+	ID          int             `json:"id"`
+	ProjectID   int             `json:"project_id"`
+	IID         int             `json:"iid"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	StartDate   *gitlab.ISOTime `json:"start_date,omitempty"`
+	DueDate     *gitlab.ISOTime `json:"due_date,omitempty"`
+	State       string          `json:"state"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Label is a label of a project
+type Label struct {
+	ID          int    `json:"id"`
+	ProjectID   int    `json:"project_id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description,omitempty"`
+	Priority    *int   `json:"priority,omitempty"`
+}
+
+// Board is an issue board of a project
+type Board struct {
+	ID        int          `json:"id"`
+	ProjectID int          `json:"project_id"`
+	Name      string       `json:"name"`
+	Lists     []*BoardList `json:"lists,omitempty"`
+}
+
+// BoardList is a list of the open issues with a label, in the order of the board
+type BoardList struct {
+	ID      int `json:"id"`
+	LabelID int `json:"label_id"`
+}
+
+// Upload is a file uploaded to a project. Only its checksum is kept.
+type Upload struct {
+	ID        int    `json:"id"`
+	ProjectID int    `json:"project_id"`
+	Filename  string `json:"filename"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	URL       string `json:"url"`
+}
+
+// NewState returns a state with the administrator as the only user
+func NewState() *State {
+	return &State{
+		Sequences: map[string]int{"users": RootUserID},
+		Users: []*User{{
+			ID:        RootUserID,
+			Username:  "root",
+			Name:      "Administrator",
+			Email:     "admin@example.com",
+			IsAdmin:   true,
+			State:     "active",
+			CreatedAt: now(),
+		}},
+	}
+}
+
+// LoadState reads a state saved with Save, a missing file gives a new state
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	st := NewState()
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to decode state %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// Save writes the state to a file atomically
+func (st *State) Save(path string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := utils.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// EnsureProject adds a project with the given ID unless it exists
+func (st *State) EnsureProject(id int, path string) *Project {
+	if p := st.project(id); p != nil {
+		return p
+	}
+	p := &Project{ID: id, Name: path, Path: path}
+	st.Projects = append(st.Projects, p)
+	if id > st.Sequences["projects"] {
+		st.Sequences["projects"] = id
+	}
+	return p
+}
+
+// nextID hands out the next ID for a kind of object
+func (st *State) nextID(kind string) int {
+	if st.Sequences == nil {
+		st.Sequences = make(map[string]int)
+	}
+	st.Sequences[kind]++
+	return st.Sequences[kind]
+}
+
+func (st *State) user(id int) *User {
+	for _, u := range st.Users {
+		if u.ID == id {
+			return u
+		}
+	}
+	return nil
+}
+
+func (st *State) project(id int) *Project {
+	for _, p := range st.Projects {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (st *State) issue(projectID, iid int) *Issue {
+	for _, i := range st.Issues {
+		if i.ProjectID == projectID && i.IID == iid {
+			return i
+		}
+	}
+	return nil
+}
+
+func (st *State) milestone(projectID, id int) *Milestone {
+	for _, m := range st.Milestones {
+		if m.ProjectID == projectID && m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+func (st *State) label(projectID int, name string) *Label {
+	for _, l := range st.Labels {
+		if l.ProjectID == projectID && l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+func (st *State) board(projectID, id int) *Board {
+	for _, b := range st.Boards {
+		if b.ProjectID == projectID && b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (st *State) token(value string) *Token {
+	for _, t := range st.Tokens {
+		if t.Token == value {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package fakegitlab
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func (s *Server) getVersion(w http.ResponseWriter, r *request) (any, int, error) {
+	return gitlab.Version{Version: "17.0.0-fake", Revision: "fake"}, http.StatusOK, nil
+}
+
+func (s *Server) getCurrentUser(w http.ResponseWriter, r *request) (any, int, error) {
+	return renderUser(r, r.user), http.StatusOK, nil
+}
+
+// listUsers filters by exact username or by a search in username, name and email
+func (s *Server) listUsers(w http.ResponseWriter, r *request) (any, int, error) {
+	query := r.URL.Query()
+	username := query.Get("username")
+	search := strings.ToLower(query.Get("search"))
+
+	var users []*gitlab.User
+	for _, u := range s.state.Users {
+		if username != "" && !strings.EqualFold(u.Username, username) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(u.Username+"\n"+u.Name+"\n"+u.Email), search) {
+			continue
+		}
+		users = append(users, renderUser(r, u))
+	}
+	return paginate(w, r, users), http.StatusOK, nil
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *request) (any, int, error) {
+	u, err := s.pathUser(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return renderUser(r, u), http.StatusOK, nil
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *request) (any, int, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.CreateUserOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Username == nil || opts.Email == nil || opts.Name == nil {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - name, username and email are required")
+	}
+	if err := s.checkUserUnique(0, *opts.Username, *opts.Email); err != nil {
+		return nil, 0, err
+	}
+
+	u := &User{
+		ID:        s.state.nextID("users"),
+		Username:  *opts.Username,
+		Name:      *opts.Name,
+		Email:     *opts.Email,
+		IsAdmin:   opts.Admin != nil && *opts.Admin,
+		State:     "active",
+		CreatedAt: now(),
+	}
+	s.state.Users = append(s.state.Users, u)
+	return renderUser(r, u), http.StatusCreated, nil
+}
+
+func (s *Server) modifyUser(w http.ResponseWriter, r *request) (any, int, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, 0, err
+	}
+	u, err := s.pathUser(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.ModifyUserOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+
+	username, email := u.Username, u.Email
+	if opts.Username != nil {
+		username = *opts.Username
+	}
+	if opts.Email != nil {
+		email = *opts.Email
+	}
+	if err := s.checkUserUnique(u.ID, username, email); err != nil {
+		return nil, 0, err
+	}
+	u.Username, u.Email = username, email
+	if opts.Name != nil {
+		u.Name = *opts.Name
+	}
+	if opts.Admin != nil {
+		u.IsAdmin = *opts.Admin
+	}
+	return renderUser(r, u), http.StatusOK, nil
+}
+
+// checkUserUnique fails if another user has the username or email
+func (s *Server) checkUserUnique(id int, username, email string) error {
+	for _, other := range s.state.Users {
+		if other.ID == id {
+			continue
+		}
+		if strings.EqualFold(other.Username, username) {
+			return errorf(http.StatusConflict, "Username has already been taken")
+		}
+		if strings.EqualFold(other.Email, email) {
+			return errorf(http.StatusConflict, "Email has already been taken")
+		}
+	}
+	return nil
+}
+
+// setUserState blocks, unblocks, deactivates or activates a user
+func (s *Server) setUserState(w http.ResponseWriter, r *request) (any, int, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, 0, err
+	}
+	u, err := s.pathUser(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch r.PathValue("action") {
+	case "block":
+		u.State = "blocked"
+	case "deactivate":
+		u.State = "deactivated"
+	case "unblock", "activate":
+		u.State = "active"
+	default:
+		return nil, 0, errorf(http.StatusNotFound, "404 Not Found")
+	}
+	return true, http.StatusCreated, nil
+}
+
+func (s *Server) listTokens(w http.ResponseWriter, r *request) (any, int, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, 0, err
+	}
+	u, err := s.pathUser(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	state := r.URL.Query().Get("state")
+	var tokens []*gitlab.ImpersonationToken
+	for _, t := range s.state.Tokens {
+		if t.UserID != u.ID || (state == "active" && t.Revoked) || (state == "inactive" && !t.Revoked) {
+			continue
+		}
+		rendered := renderToken(t)
+		rendered.Token = ""
+		tokens = append(tokens, rendered)
+	}
+	return paginate(w, r, tokens), http.StatusOK, nil
+}
+
+func (s *Server) createToken(w http.ResponseWriter, r *request) (any, int, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, 0, err
+	}
+	u, err := s.pathUser(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	var opts gitlab.CreateImpersonationTokenOptions
+	if err := r.decode(&opts); err != nil {
+		return nil, 0, err
+	}
+	if opts.Name == nil || opts.Scopes == nil {
+		return nil, 0, errorf(http.StatusBadRequest, "400 Bad request - name and scopes are required")
+	}
+
+	id := s.state.nextID("impersonation_tokens")
+	t := &Token{
+		ID:        id,
+		UserID:    u.ID,
+		Name:      *opts.Name,
+		Token:     fmt.Sprintf("glpat-fake-%d-%d", u.ID, id),
+		Scopes:    *opts.Scopes,
+		CreatedAt: now(),
+		ExpiresAt: opts.ExpiresAt,
+	}
+	s.state.Tokens = append(s.state.Tokens, t)
+	return renderToken(t), http.StatusCreated, nil
+}
+
+func (s *Server) getToken(w http.ResponseWriter, r *request) (any, int, error) {
+	t, err := s.pathToken(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	rendered := renderToken(t)
+	rendered.Token = ""
+	return rendered, http.StatusOK, nil
+}
+
+func (s *Server) revokeToken(w http.ResponseWriter, r *request) (any, int, error) {
+	t, err := s.pathToken(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	t.Revoked = true
+	return nil, http.StatusNoContent, nil
+}
+
+// pathToken returns the impersonation token of the path, for administrators only
+func (s *Server) pathToken(r *request) (*Token, error) {
+	if err := r.requireAdmin(); err != nil {
+		return nil, err
+	}
+	u, err := s.pathUser(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := r.intParam("token")
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(s.state.Tokens, func(t *Token) bool { return t.ID == id && t.UserID == u.ID })
+	if i < 0 {
+		return nil, notFound("Impersonation Token")
+	}
+	return s.state.Tokens[i], nil
+}
+
+// renderUser converts a user to its API representation
+func renderUser(r *request, u *User) *gitlab.User {
+	createdAt := u.CreatedAt
+	return &gitlab.User{
+		ID:        u.ID,
+		Username:  u.Username,
+		Name:      u.Name,
+		Email:     u.Email,
+		State:     u.State,
+		IsAdmin:   u.IsAdmin,
+		CreatedAt: &createdAt,
+		WebURL:    r.base + "/" + u.Username,
+	}
+}
+
+func renderToken(t *Token) *gitlab.ImpersonationToken {
+	createdAt := t.CreatedAt
+	token := &gitlab.ImpersonationToken{
+		ID:        t.ID,
+		Name:      t.Name,
+		Active:    !t.Revoked,
+		Token:     t.Token,
+		Scopes:    t.Scopes,
+		Revoked:   t.Revoked,
+		CreatedAt: &createdAt,
+	}
+	if t.ExpiresAt != nil {
+		expires := gitlab.ISOTime(*t.ExpiresAt)
+		token.ExpiresAt = &expires
+	}
+	return token
+}
@@ -0,0 +1,212 @@
+// Package faketrac serves the XmlRpcPlugin methods used by trac2gitlab from fixture
+// data, for trying the export without a Trac instance and for end-to-end tests.
+//
+// A fixture directory contains any of these files:
+//
+//	tickets.json        tickets with attributes, attachments and change log
+//	milestones.json     milestones
+//	components.json     components with their owners
+//	workflow.json       workflow actions, the basic Trac workflow if missing
+//	ticket_fields.json  ticket field definitions
+//	wiki.json           wiki pages with their versions
+//	users.json          names and email addresses of users
+//	attachments/<ticket|wiki>/<ticket ID or page>/<filename>  attachment content
+package faketrac
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+// Ticket is a ticket of the fixtures
+type Ticket struct {
+	ID          int64             `json:"id"`
+	Created     time.Time         `json:"created"`
+	Changed     time.Time         `json:"changed"`
+	Attributes  map[string]string `json:"attributes"`
+	Attachments []Attachment      `json:"attachments,omitempty"`
+	ChangeLog   []Change          `json:"changelog,omitempty"`
+}
+
+// Attachment describes an attachment of a fixture ticket, its content is read from the attachments directory
+type Attachment struct {
+	Filename    string    `json:"filename"`
+	Description string    `json:"description,omitempty"`
+	Time        time.Time `json:"time"`
+	Author      string    `json:"author"`
+}
+
+// Change is an entry of the change log of a fixture ticket
+type Change struct {
+	Time     time.Time `json:"time"`
+	Author   string    `json:"author"`
+	Field    string    `json:"field"`
+	OldValue *string   `json:"oldvalue,omitempty"`
+	NewValue *string   `json:"newvalue,omitempty"`
+}
+
+// WikiPage is a wiki page of the fixtures with its versions, oldest first
+type WikiPage struct {
+	Name     string        `json:"name"`
+	Versions []WikiVersion `json:"versions"`
+}
+
+// WikiVersion is a version of a fixture wiki page
+type WikiVersion struct {
+	Author  string    `json:"author"`
+	Comment string    `json:"comment,omitempty"`
+	Time    time.Time `json:"time"`
+	Text    string    `json:"text"`
+}
+
+// LoadFixtures reads a fixture directory into an in-memory Trac
+func LoadFixtures(dir string) (*trac.Memory, error) {
+	src := trac.NewMemory()
+
+	var tickets []Ticket
+	if err := readFixture(dir, "tickets.json", &tickets); err != nil {
+		return nil, err
+	}
+	for _, t := range tickets {
+		ticket := trac.Ticket{ID: t.ID, TimeCreated: t.Created, TimeChanged: t.Changed, Attributes: make(map[string]any, len(t.Attributes))}
+		if ticket.TimeChanged.IsZero() {
+			ticket.TimeChanged = ticket.TimeCreated
+		}
+		for name, value := range t.Attributes {
+			ticket.Attributes[name] = value
+		}
+
+		for _, a := range t.Attachments {
+			content, err := os.ReadFile(filepath.Join(dir, "attachments", "ticket", fmt.Sprint(t.ID), a.Filename))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read attachment %q of ticket %d: %w", a.Filename, t.ID, err)
+			}
+			att := trac.Attachment{Filename: a.Filename, Description: a.Description, Size: int64(len(content)), Time: a.Time, Author: a.Author}
+			ticket.Attachments = append(ticket.Attachments, att)
+			src.AddAttachment(trac.ResourceTicket, int(t.ID), att, content)
+		}
+
+		for _, c := range t.ChangeLog {
+			entry := trac.ChangeLogEntry{Time: c.Time, Author: c.Author, Field: c.Field, OldValue: c.OldValue, NewValue: c.NewValue, Permanent: 1}
+			switch {
+			case trac.IsHistoryField(c.Field):
+				ticket.History = append(ticket.History, entry)
+			case c.Field == "comment":
+				ticket.Comments = append(ticket.Comments, entry)
+			}
+		}
+		src.AddTicket(ticket)
+	}
+
+	var milestones []trac.Milestone
+	if err := readFixture(dir, "milestones.json", &milestones); err != nil {
+		return nil, err
+	}
+	for _, m := range milestones {
+		src.AddMilestone(m)
+	}
+
+	var components []trac.Component
+	if err := readFixture(dir, "components.json", &components); err != nil {
+		return nil, err
+	}
+	for _, c := range components {
+		src.AddComponent(c)
+	}
+
+	var workflow []trac.WorkflowAction
+	if err := readFixture(dir, "workflow.json", &workflow); err != nil {
+		return nil, err
+	}
+	if workflow != nil {
+		src.SetWorkflow(workflow)
+	}
+
+	var fields []trac.TicketField
+	if err := readFixture(dir, "ticket_fields.json", &fields); err != nil {
+		return nil, err
+	}
+	src.SetTicketFields(fields)
+
+	var pages []WikiPage
+	if err := readFixture(dir, "wiki.json", &pages); err != nil {
+		return nil, err
+	}
+	for _, p := range pages {
+		for _, v := range p.Versions {
+			src.AddWikiVersion(p.Name, v.Author, v.Comment, v.Text, v.Time)
+		}
+	}
+	if err := loadWikiAttachments(src, filepath.Join(dir, "attachments", "wiki")); err != nil {
+		return nil, err
+	}
+
+	var users []trac.User
+	if err := readFixture(dir, "users.json", &users); err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		src.AddUser(u)
+	}
+
+	return src, nil
+}
+
+// readFixture decodes a fixture file into v, a missing file leaves v empty
+func readFixture(dir, name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// loadWikiAttachments adds the files below attachments/wiki, the directory is the page name
+func loadWikiAttachments(src *trac.Memory, root string) error {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		page, filename := filepath.Split(filepath.ToSlash(rel))
+		page = strings.TrimSuffix(page, "/")
+		if page == "" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		src.AddAttachment(trac.ResourceWiki, page, trac.Attachment{Filename: filename, Size: int64(len(content)), Time: info.ModTime().UTC()}, content)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read wiki attachments: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,526 @@
+package faketrac
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+	"github.com/bnidev/trac2gitlab/pkg/xmlrpc"
+)
+
+// APIVersion is the XmlRpcPlugin version reported by the server
+var APIVersion = []int64{1, 1, 9}
+
+// Backend is the Trac data served by the server, implemented by *trac.Memory
+type Backend interface {
+	GetAllTicketIDs(ctx context.Context, query string) ([]int, error)
+	GetRecentTicketChanges(ctx context.Context, since time.Time) ([]int, error)
+	GetTicket(ctx context.Context, id int) (*trac.Ticket, error)
+	GetTicketFields(ctx context.Context) ([]trac.TicketField, error)
+	GetTicketActions(ctx context.Context, id int) ([]trac.TicketAction, error)
+	GetMilestoneNames(ctx context.Context) ([]string, error)
+	GetMilestoneByName(ctx context.Context, name string) (*trac.Milestone, error)
+	GetComponentNames(ctx context.Context) ([]string, error)
+	GetComponentByName(ctx context.Context, name string) (*trac.Component, error)
+	GetWikiPageNames(ctx context.Context) ([]string, error)
+	GetWikiPageInfo(ctx context.Context, pageName string) (*trac.WikiPage, error)
+	GetWikiPageInfoVersion(ctx context.Context, pageName string, version int64) (*trac.WikiPage, error)
+	GetWikiPageVersion(ctx context.Context, pageName string, version int64) (*string, error)
+	ListAttachments(ctx context.Context, resType trac.ResourceType, id any) ([]trac.Attachment, error)
+	DownloadAttachment(ctx context.Context, resType trac.ResourceType, id any, filename string, w io.Writer) (int64, error)
+}
+
+var _ Backend = (*trac.Memory)(nil)
+
+// faultNotFound is the fault code Trac uses for missing resources
+const faultNotFound = 404
+
+// method handles an XML-RPC call and returns its result
+type method func(ctx context.Context, b Backend, args []any) (any, error)
+
+// methods are the XML-RPC methods served, by name
+var methods = map[string]method{
+	"system.getAPIVersion": func(ctx context.Context, b Backend, args []any) (any, error) {
+		return APIVersion, nil
+	},
+	"ticket.query":            ticketQuery,
+	"ticket.getRecentChanges": ticketRecentChanges,
+	"ticket.get":              ticketGet,
+	"ticket.changeLog":        ticketChangeLog,
+	"ticket.listAttachments":  ticketListAttachments,
+	"ticket.getAttachment":    ticketGetAttachment,
+	"ticket.getTicketFields":  ticketFields,
+	"ticket.getActions":       ticketActions,
+	"ticket.milestone.getAll": func(ctx context.Context, b Backend, args []any) (any, error) {
+		return b.GetMilestoneNames(ctx)
+	},
+	"ticket.milestone.get": milestoneGet,
+	"ticket.component.getAll": func(ctx context.Context, b Backend, args []any) (any, error) {
+		return b.GetComponentNames(ctx)
+	},
+	"ticket.component.get":    componentGet,
+	"wiki.getAllPages":        wikiAllPages,
+	"wiki.getPage":            wikiGetPage,
+	"wiki.getPageVersion":     wikiGetPage,
+	"wiki.getPageInfo":        wikiPageInfo,
+	"wiki.getPageInfoVersion": wikiPageInfo,
+	"wiki.listAttachments":    wikiListAttachments,
+	"wiki.getAttachment":      wikiGetAttachment,
+	"search.getSearchFilters": searchFilters,
+	"search.performSearch":    searchPerform,
+}
+
+// Server answers XmlRpcPlugin requests from a backend
+type Server struct {
+	backend Backend
+}
+
+// NewServer returns a server for the backend. It answers on every path, so any rpc_path works.
+func NewServer(backend Backend) *Server {
+	return &Server{backend: backend}
+}
+
+// Methods returns the names of the served methods, sorted
+func (s *Server) Methods() []string {
+	names := []string{"system.listMethods", "system.multicall"}
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeHTTP handles an XML-RPC request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "XML-RPC requests must use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name, args, err := xmlrpc.ParseMethodCall(body)
+	if err != nil {
+		s.writeFault(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	slog.Debug("XML-RPC call", "method", name, "args", args)
+	result, err := s.call(r.Context(), name, args)
+	if err != nil {
+		code := 1
+		if errors.Is(err, errNotFound) {
+			code = faultNotFound
+		}
+		slog.Debug("XML-RPC call failed", "method", name, "error", err)
+		s.writeFault(w, code, err.Error())
+		return
+	}
+
+	resp, err := xmlrpc.EncodeResponse(result)
+	if err != nil {
+		s.writeFault(w, 1, fmt.Sprintf("failed to encode result of %s: %v", name, err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	_, _ = w.Write(resp)
+}
+
+// writeFault writes an XML-RPC fault
+func (s *Server) writeFault(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "text/xml")
+	_, _ = w.Write(xmlrpc.EncodeFault(code, message))
+}
+
+// call runs a method, including the system methods that need the server
+func (s *Server) call(ctx context.Context, name string, args []any) (any, error) {
+	switch name {
+	case "system.listMethods":
+		return s.Methods(), nil
+	case "system.multicall":
+		return s.multicall(ctx, args)
+	}
+	m, ok := methods[name]
+	if !ok {
+		return nil, fmt.Errorf("RPC method %q not found", name)
+	}
+	return m(ctx, s.backend, args)
+}
+
+// multicall runs a list of {methodName, params} calls, failed calls return a fault struct
+func (s *Server) multicall(ctx context.Context, args []any) (any, error) {
+	var calls []any
+	if len(args) > 0 {
+		calls, _ = args[0].([]any)
+	}
+	results := make([]any, 0, len(calls))
+	for _, c := range calls {
+		call, _ := c.(map[string]any)
+		name, _ := call["methodName"].(string)
+		params, _ := call["params"].([]any)
+		result, err := s.call(ctx, name, params)
+		if err != nil {
+			results = append(results, map[string]any{"faultCode": 1, "faultString": err.Error()})
+			continue
+		}
+		results = append(results, []any{result})
+	}
+	return results, nil
+}
+
+// errNotFound marks errors for missing tickets, pages and attachments
+var errNotFound = errors.New("not found")
+
+// notFound wraps a backend error so it is reported with the not found fault code
+func notFound(err error) error {
+	return fmt.Errorf("%w: %v", errNotFound, err)
+}
+
+func ticketQuery(ctx context.Context, b Backend, args []any) (any, error) {
+	query := "status!=closed"
+	if len(args) > 0 {
+		query, _ = args[0].(string)
+	}
+	return b.GetAllTicketIDs(ctx, query)
+}
+
+func ticketRecentChanges(ctx context.Context, b Backend, args []any) (any, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("ticket.getRecentChanges needs a time")
+	}
+	since, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("unexpected time type: %T", args[0])
+	}
+	return b.GetRecentTicketChanges(ctx, since)
+}
+
+func ticketGet(ctx context.Context, b Backend, args []any) (any, error) {
+	t, err := ticketArg(ctx, b, args)
+	if err != nil {
+		return nil, err
+	}
+	attributes := make(map[string]any, len(t.Attributes))
+	for name, value := range t.Attributes {
+		attributes[name] = fmt.Sprint(value)
+	}
+	return []any{t.ID, t.TimeCreated.UTC(), t.TimeChanged.UTC(), attributes}, nil
+}
+
+func ticketChangeLog(ctx context.Context, b Backend, args []any) (any, error) {
+	t, err := ticketArg(ctx, b, args)
+	if err != nil {
+		return nil, err
+	}
+	entries := append(append([]trac.ChangeLogEntry{}, t.History...), t.Comments...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+
+	log := make([]any, 0, len(entries))
+	for _, e := range entries {
+		log = append(log, []any{e.Time.UTC(), e.Author, e.Field, stringValue(e.OldValue), stringValue(e.NewValue), e.Permanent})
+	}
+	return log, nil
+}
+
+func ticketListAttachments(ctx context.Context, b Backend, args []any) (any, error) {
+	id, err := ticketID(args)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := b.ListAttachments(ctx, trac.ResourceTicket, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	list := make([]any, 0, len(attachments))
+	for _, a := range attachments {
+		list = append(list, []any{a.Filename, a.Description, a.Size, a.Time.UTC(), a.Author})
+	}
+	return list, nil
+}
+
+func ticketGetAttachment(ctx context.Context, b Backend, args []any) (any, error) {
+	id, err := ticketID(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) < 2 {
+		return nil, fmt.Errorf("ticket.getAttachment needs a filename")
+	}
+	filename, _ := args[1].(string)
+	return attachmentContent(ctx, b, trac.ResourceTicket, id, filename)
+}
+
+func ticketFields(ctx context.Context, b Backend, args []any) (any, error) {
+	fields, err := b.GetTicketFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]any, 0, len(fields))
+	for _, f := range fields {
+		field := map[string]any{"label": f.Label, "name": f.Name, "type": f.InputType}
+		if f.Format != "" {
+			field["format"] = f.Format
+		}
+		if f.Options != nil {
+			field["options"] = f.Options
+		}
+		if f.TicketType != nil {
+			field["value"] = fmt.Sprint(f.TicketType)
+		}
+		if f.Optional {
+			field["optional"] = true
+		}
+		if f.Order != 0 {
+			field["order"] = f.Order
+		}
+		if f.Custom {
+			field["custom"] = true
+		}
+		list = append(list, field)
+	}
+	return list, nil
+}
+
+func milestoneGet(ctx context.Context, b Backend, args []any) (any, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("ticket.milestone.get needs a name")
+	}
+	name, _ := args[0].(string)
+	m, err := b.GetMilestoneByName(ctx, name)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	// Trac reports unset dates as 0
+	milestone := map[string]any{"name": m.Name, "description": stringValue(m.Description), "due": 0, "completed": 0}
+	if m.DueDate != nil {
+		milestone["due"] = m.DueDate.UTC()
+	}
+	if m.CompletedDate != nil {
+		milestone["completed"] = m.CompletedDate.UTC()
+	}
+	return milestone, nil
+}
+
+// ticketActions answers the actions like Trac, as [name, label, hints, input fields]
+func ticketActions(ctx context.Context, b Backend, args []any) (any, error) {
+	t, err := ticketArg(ctx, b, args)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := b.GetTicketActions(ctx, int(t.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]any, 0, len(actions))
+	for _, a := range actions {
+		result = append(result, []any{a.Name, a.Label, a.Hints, []any{}})
+	}
+	return result, nil
+}
+
+func componentGet(ctx context.Context, b Backend, args []any) (any, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("ticket.component.get needs a name")
+	}
+	name, _ := args[0].(string)
+	c, err := b.GetComponentByName(ctx, name)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return map[string]any{"name": c.Name, "owner": c.Owner, "description": c.Description}, nil
+}
+
+func wikiAllPages(ctx context.Context, b Backend, args []any) (any, error) {
+	return b.GetWikiPageNames(ctx)
+}
+
+func wikiGetPage(ctx context.Context, b Backend, args []any) (any, error) {
+	page, version, err := wikiArgs(ctx, b, args)
+	if err != nil {
+		return nil, err
+	}
+	text, err := b.GetWikiPageVersion(ctx, page, version)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return *text, nil
+}
+
+func wikiPageInfo(ctx context.Context, b Backend, args []any) (any, error) {
+	page, version, err := wikiArgs(ctx, b, args)
+	if err != nil {
+		return nil, err
+	}
+	info, err := b.GetWikiPageInfoVersion(ctx, page, version)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return map[string]any{
+		"name":         info.Name,
+		"version":      info.Version,
+		"author":       info.Author,
+		"comment":      stringValue(info.Comment),
+		"lastModified": info.LastModified.UTC(),
+	}, nil
+}
+
+func wikiListAttachments(ctx context.Context, b Backend, args []any) (any, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("wiki.listAttachments needs a page name")
+	}
+	page, _ := args[0].(string)
+	attachments, err := b.ListAttachments(ctx, trac.ResourceWiki, page)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	// The plugin lists wiki attachments as "<page>/<filename>"
+	paths := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		paths = append(paths, page+"/"+a.Filename)
+	}
+	return paths, nil
+}
+
+func wikiGetAttachment(ctx context.Context, b Backend, args []any) (any, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("wiki.getAttachment needs a path")
+	}
+	path, _ := args[0].(string)
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return nil, fmt.Errorf("%w: attachment path %q has no page", errNotFound, path)
+	}
+	return attachmentContent(ctx, b, trac.ResourceWiki, path[:i], path[i+1:])
+}
+
+func searchFilters(ctx context.Context, b Backend, args []any) (any, error) {
+	return [][]string{{"ticket", "Tickets"}, {"wiki", "Wiki"}}, nil
+}
+
+// searchPerform finds the query as a case-insensitive substring of ticket summaries and descriptions and of wiki pages
+func searchPerform(ctx context.Context, b Backend, args []any) (any, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("search.performSearch needs a query")
+	}
+	query, _ := args[0].(string)
+	query = strings.ToLower(query)
+
+	results := []any{}
+	ids, err := b.GetAllTicketIDs(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		t, err := b.GetTicket(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		summary := fmt.Sprint(t.Attributes["summary"])
+		description := fmt.Sprint(t.Attributes["description"])
+		if strings.Contains(strings.ToLower(summary+"\n"+description), query) {
+			results = append(results, []any{fmt.Sprintf("/ticket/%d", id), fmt.Sprintf("#%d: %s", id, summary), t.TimeChanged.UTC(), fmt.Sprint(t.Attributes["reporter"]), excerpt(description)})
+		}
+	}
+
+	pages, err := b.GetWikiPageNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		info, err := b.GetWikiPageInfo(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		text, err := b.GetWikiPageVersion(ctx, page, info.Version)
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(strings.ToLower(page+"\n"+*text), query) {
+			results = append(results, []any{"/wiki/" + page, page, info.LastModified.UTC(), info.Author, excerpt(*text)})
+		}
+	}
+	return results, nil
+}
+
+// excerpt returns the start of a text for search results
+func excerpt(text string) string {
+	const maxLen = 200
+	if len(text) <= maxLen {
+		return text
+	}
+	return text[:maxLen] + "..."
+}
+
+// ticketID returns the ticket ID of the first argument
+func ticketID(args []any) (int, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("missing ticket ID")
+	}
+	id, ok := args[0].(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected ticket ID type: %T", args[0])
+	}
+	return int(id), nil
+}
+
+// ticketArg returns the ticket of the first argument
+func ticketArg(ctx context.Context, b Backend, args []any) (*trac.Ticket, error) {
+	id, err := ticketID(args)
+	if err != nil {
+		return nil, err
+	}
+	t, err := b.GetTicket(ctx, id)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return t, nil
+}
+
+// wikiArgs returns the page name and version of a wiki call, the latest version if none is given
+func wikiArgs(ctx context.Context, b Backend, args []any) (string, int64, error) {
+	if len(args) == 0 {
+		return "", 0, fmt.Errorf("missing page name")
+	}
+	page, _ := args[0].(string)
+	if len(args) > 1 {
+		if version, ok := args[1].(int64); ok && version > 0 {
+			return page, version, nil
+		}
+	}
+	info, err := b.GetWikiPageInfo(ctx, page)
+	if err != nil {
+		return "", 0, notFound(err)
+	}
+	return page, info.Version, nil
+}
+
+// attachmentContent returns an attachment encoded as base64
+func attachmentContent(ctx context.Context, b Backend, resType trac.ResourceType, id any, filename string) (any, error) {
+	var content bytes.Buffer
+	if _, err := b.DownloadAttachment(ctx, resType, id, filename, &content); err != nil {
+		return nil, notFound(err)
+	}
+	return xmlrpc.Base64(base64.StdEncoding.EncodeToString(content.Bytes())), nil
+}
+
+// stringValue returns the string or an empty string for nil, as Trac has no null values
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
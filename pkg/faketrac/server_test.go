@@ -0,0 +1,141 @@
+package faketrac
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bnidev/trac2gitlab/internal/config"
+	"github.com/bnidev/trac2gitlab/pkg/trac"
+)
+
+var testFixtures = map[string]string{
+	"tickets.json": `[{
+		"id": 1, "created": "2020-01-02T03:04:05Z", "changed": "2020-01-03T00:00:00Z",
+		"attributes": {"summary": "Crash on start", "description": "It crashes", "status": "closed", "reporter": "alice"},
+		"attachments": [{"filename": "trace.txt", "description": "Stack trace", "time": "2020-01-02T03:05:00Z", "author": "alice"}],
+		"changelog": [
+			{"time": "2020-01-03T00:00:00Z", "author": "bob", "field": "comment", "oldvalue": "1", "newvalue": "Fixed"},
+			{"time": "2020-01-02T04:00:00Z", "author": "alice", "field": "description", "oldvalue": "crash", "newvalue": "It crashes"}
+		]
+	}, {
+		"id": 2, "created": "2020-02-01T00:00:00Z",
+		"attributes": {"summary": "Add logo", "status": "new", "reporter": "bob"}
+	}]`,
+	"milestones.json":                     `[{"name": "1.0", "description": "First release", "completed_date": "2020-02-01T00:00:00Z"}]`,
+	"components.json":                     `[{"name": "core", "owner": "alice", "description": "The engine"}]`,
+	"ticket_fields.json":                  `[{"label": "Priority", "name": "priority", "type": "select", "options": ["high", "low"]}]`,
+	"wiki.json":                           `[{"name": "WikiStart", "versions": [{"author": "alice", "time": "2020-01-01T00:00:00Z", "text": "= Start ="}, {"author": "bob", "comment": "typo", "time": "2020-01-05T00:00:00Z", "text": "= Start page ="}]}]`,
+	"attachments/ticket/1/trace.txt":      "trace",
+	"attachments/wiki/WikiStart/logo.png": "\x89PNG",
+}
+
+func TestServer_TracClient(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range testFixtures {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backend, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatalf("LoadFixtures failed: %v", err)
+	}
+	srv := httptest.NewServer(NewServer(backend))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Trac.BaseURL = srv.URL
+	cfg.Trac.RPCPath = "/login/rpc"
+	client, err := trac.NewTracClient(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	caps, err := client.DetectCapabilities(ctx)
+	if err != nil {
+		t.Fatalf("DetectCapabilities failed: %v", err)
+	}
+	for _, info := range caps.List() {
+		if !info.Supported {
+			t.Errorf("capability %s not supported, missing %v", info.Capability, info.Missing)
+		}
+	}
+	if caps.VersionString() != "1.1.9" {
+		t.Errorf("version = %s", caps.VersionString())
+	}
+
+	ids, err := client.GetAllTicketIDs(ctx, "status!=closed")
+	if err != nil || !reflect.DeepEqual(ids, []int{2}) {
+		t.Errorf("ticket.query = %v, %v", ids, err)
+	}
+
+	ticket, err := client.GetTicket(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetTicket failed: %v", err)
+	}
+	if ticket.Attributes["summary"] != "Crash on start" || !ticket.TimeCreated.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected ticket %+v", ticket)
+	}
+	if len(ticket.Comments) != 1 || *ticket.Comments[0].NewValue != "Fixed" || len(ticket.History) != 1 {
+		t.Errorf("unexpected change log: comments %+v, history %+v", ticket.Comments, ticket.History)
+	}
+	if len(ticket.Attachments) != 1 || ticket.Attachments[0].Size != 5 || ticket.Attachments[0].Author != "alice" {
+		t.Errorf("unexpected attachments %+v", ticket.Attachments)
+	}
+
+	var content bytes.Buffer
+	if _, err := client.DownloadAttachment(ctx, trac.ResourceTicket, 1, "trace.txt", &content); err != nil || content.String() != "trace" {
+		t.Errorf("ticket attachment = %q, %v", content.String(), err)
+	}
+
+	milestone, err := client.GetMilestoneByName(ctx, "1.0")
+	if err != nil {
+		t.Fatalf("GetMilestoneByName failed: %v", err)
+	}
+	if *milestone.Description != "First release" || milestone.DueDate != nil || milestone.CompletedDate == nil {
+		t.Errorf("unexpected milestone %+v", milestone)
+	}
+
+	component, err := client.GetComponentByName(ctx, "core")
+	if err != nil || *component != (trac.Component{Name: "core", Owner: "alice", Description: "The engine"}) {
+		t.Errorf("GetComponentByName = %+v, %v", component, err)
+	}
+
+	info, err := client.GetWikiPageInfo(ctx, "WikiStart")
+	if err != nil {
+		t.Fatalf("GetWikiPageInfo failed: %v", err)
+	}
+	if info.Version != 2 || info.Author != "bob" || len(info.Attachments) != 1 || info.Attachments[0].Filename != "logo.png" {
+		t.Errorf("unexpected wiki page %+v", info)
+	}
+	text, err := client.GetWikiPageVersion(ctx, "WikiStart", 1)
+	if err != nil || *text != "= Start =" {
+		t.Errorf("wiki.getPage = %v, %v", text, err)
+	}
+
+	content.Reset()
+	if _, err := client.DownloadAttachment(ctx, trac.ResourceWiki, "WikiStart", "logo.png", &content); err != nil || content.String() != "\x89PNG" {
+		t.Errorf("wiki attachment = %q, %v", content.String(), err)
+	}
+
+	results, err := client.Search(ctx, "crash")
+	if err != nil || len(results) != 1 || results[0].Href != "/ticket/1" {
+		t.Errorf("search = %+v, %v", results, err)
+	}
+
+	if _, err := client.GetTicket(ctx, 3); err == nil {
+		t.Error("expected a fault for a missing ticket")
+	}
+}
@@ -0,0 +1,79 @@
+package gitlab
+
+import (
+	"context"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// IssueBoard represents a GitLab issue board, here it is aliased to the GitLab client type for easier usage.
+type IssueBoard = gitlab.IssueBoard
+
+// BoardList represents a list of an issue board, here it is aliased to the GitLab client type for easier usage.
+type BoardList = gitlab.BoardList
+
+// ListIssueBoards retrieves the issue boards of the specified project.
+func (c *Client) ListIssueBoards(ctx context.Context, projectID any) ([]*IssueBoard, error) {
+	boards, _, err := c.git.Boards.ListIssueBoards(projectID, &gitlab.ListIssueBoardsOptions{}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return boards, nil
+}
+
+// GetIssueBoardByName retrieves an issue board by its name, nil if the project has none with that name.
+func (c *Client) GetIssueBoardByName(ctx context.Context, projectID any, name string) (*IssueBoard, error) {
+	boards, err := c.ListIssueBoards(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, board := range boards {
+		if board.Name == name {
+			return board, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// CreateIssueBoard creates a new issue board with the given name in the specified project.
+func (c *Client) CreateIssueBoard(ctx context.Context, projectID any, name string) (*IssueBoard, error) {
+	board, _, err := c.git.Boards.CreateIssueBoard(projectID, &gitlab.CreateIssueBoardOptions{Name: &name}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return board, nil
+}
+
+// GetIssueBoardLists retrieves the lists of an issue board, without the built-in open and closed lists.
+func (c *Client) GetIssueBoardLists(ctx context.Context, projectID any, boardID int) ([]*BoardList, error) {
+	lists, _, err := c.git.Boards.GetIssueBoardLists(projectID, boardID, &gitlab.GetIssueBoardListsOptions{}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+// CreateIssueBoardLabelList adds a list of the issues with a label to an issue board.
+func (c *Client) CreateIssueBoardLabelList(ctx context.Context, projectID any, boardID int, labelID int) (*BoardList, error) {
+	list, _, err := c.git.Boards.CreateIssueBoardList(projectID, boardID, &gitlab.CreateIssueBoardListOptions{LabelID: &labelID}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// MoveIssueBoardList moves a list of an issue board to the given position.
+func (c *Client) MoveIssueBoardList(ctx context.Context, projectID any, boardID int, listID int, position int) (*BoardList, error) {
+	list, _, err := c.git.Boards.UpdateIssueBoardList(projectID, boardID, listID, &gitlab.UpdateIssueBoardListOptions{Position: &position}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
@@ -1,6 +1,7 @@
 package gitlab
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 
@@ -34,9 +35,9 @@ type Version struct {
 }
 
 // GetVersion retrieves the GitLab version information.
-func (c *Client) GetVersion() (*Version, error) {
+func (c *Client) GetVersion(ctx context.Context) (*Version, error) {
 	var version *Version
-	versionRaw, _, err := c.git.Version.GetVersion()
+	versionRaw, _, err := c.git.Version.GetVersion(gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -48,16 +49,16 @@ func (c *Client) GetVersion() (*Version, error) {
 }
 
 // ValidateGitLab validates the GitLab connection by checking the version and current user.
-func (c *Client) ValidateGitLab() error {
+func (c *Client) ValidateGitLab(ctx context.Context) error {
 	slog.Debug("Validating GitLab connection...")
 
-	version, err := c.GetVersion()
+	version, err := c.GetVersion(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get GitLab version: %w", err)
 	}
 	slog.Debug("GitLab version retrieved", "version", version.Version, "revision", version.Revision)
 
-	user, _, err := c.git.Users.CurrentUser()
+	user, _, err := c.git.Users.CurrentUser(gitlab.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to get current user: %w", err)
 	}
@@ -1,6 +1,7 @@
 package gitlab
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,14 +9,14 @@ import (
 )
 
 // CreateImpersonationToken creates an impersonation token for the specified user with an optional expiration date.
-func (c *Client) CreateImpersonationToken(userID int, expireDate *time.Time) (*gitlab_client.ImpersonationToken, error) {
+func (c *Client) CreateImpersonationToken(ctx context.Context, userID int, expireDate *time.Time) (*gitlab_client.ImpersonationToken, error) {
 	opts := &gitlab_client.CreateImpersonationTokenOptions{
 		Name:      gitlab_client.Ptr("Trac Migration Token"),
 		Scopes:    &[]string{"api"},
 		ExpiresAt: expireDate,
 	}
 
-	token, _, err := c.git.Users.CreateImpersonationToken(userID, opts)
+	token, _, err := c.git.Users.CreateImpersonationToken(userID, opts, gitlab_client.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create impersonation token for user %d: %w", userID, err)
 	}
@@ -31,15 +32,15 @@ type ImpersonationTokenInfo struct {
 }
 
 // EnsureImpersonationToken checks if an impersonation token with the given name exists for the user.
-func (c *Client) EnsureImpersonationToken(userID int, tokenName string, scopes []string) (*ImpersonationTokenInfo, error) {
-	tokens, _, err := c.git.Users.GetAllImpersonationTokens(userID, nil)
+func (c *Client) EnsureImpersonationToken(ctx context.Context, userID int, tokenName string, scopes []string) (*ImpersonationTokenInfo, error) {
+	tokens, _, err := c.git.Users.GetAllImpersonationTokens(userID, nil, gitlab_client.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing impersonation tokens: %w", err)
 	}
 
 	for _, t := range tokens {
 		if t.Name == tokenName && !t.Revoked {
-			_, err := c.git.Users.RevokeImpersonationToken(userID, t.ID)
+			_, err := c.git.Users.RevokeImpersonationToken(userID, t.ID, gitlab_client.WithContext(ctx))
 			if err != nil {
 				return nil, fmt.Errorf("failed to revoke existing token %q (id: %d): %w", t.Name, t.ID, err)
 			}
@@ -50,7 +51,7 @@ func (c *Client) EnsureImpersonationToken(userID int, tokenName string, scopes [
 		Name:      &tokenName,
 		Scopes:    &scopes,
 		ExpiresAt: gitlab_client.Ptr(time.Now().Add(24 * time.Hour)),
-	})
+	}, gitlab_client.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new impersonation token: %w", err)
 	}
@@ -1,6 +1,7 @@
 package gitlab
 
 import (
+	"context"
 	gitlab_client "gitlab.com/gitlab-org/api/client-go"
 )
 
@@ -8,8 +9,8 @@ import (
 type Issue = gitlab_client.Issue
 
 // GetIssue retrieves a specific issue by its ID from the specified project.
-func (c *Client) GetIssue(projectID any, issueID int) (*Issue, error) {
-	issue, _, err := c.git.Issues.GetIssue(projectID, issueID)
+func (c *Client) GetIssue(ctx context.Context, projectID any, issueID int) (*Issue, error) {
+	issue, _, err := c.git.Issues.GetIssue(projectID, issueID, gitlab_client.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -20,8 +21,8 @@ func (c *Client) GetIssue(projectID any, issueID int) (*Issue, error) {
 type CreateIssueOptions = gitlab_client.CreateIssueOptions
 
 // CreateIssue creates a new issue in the specified project with the provided options.
-func (c *Client) CreateIssue(projectID any, opts *gitlab_client.CreateIssueOptions) (*Issue, error) {
-	issue, _, err := c.git.Issues.CreateIssue(projectID, opts)
+func (c *Client) CreateIssue(ctx context.Context, projectID any, opts *gitlab_client.CreateIssueOptions) (*Issue, error) {
+	issue, _, err := c.git.Issues.CreateIssue(projectID, opts, gitlab_client.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -32,8 +33,8 @@ func (c *Client) CreateIssue(projectID any, opts *gitlab_client.CreateIssueOptio
 type UpdateIssueOptions = gitlab_client.UpdateIssueOptions
 
 // UpdateIssue updates an existing issue in the specified project with the provided options.
-func (c *Client) UpdateIssue(projectID any, issueID int, opts *gitlab_client.UpdateIssueOptions) (*Issue, error) {
-	issue, _, err := c.git.Issues.UpdateIssue(projectID, issueID, opts)
+func (c *Client) UpdateIssue(ctx context.Context, projectID any, issueID int, opts *gitlab_client.UpdateIssueOptions) (*Issue, error) {
+	issue, _, err := c.git.Issues.UpdateIssue(projectID, issueID, opts, gitlab_client.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -41,8 +42,8 @@ func (c *Client) UpdateIssue(projectID any, issueID int, opts *gitlab_client.Upd
 }
 
 // ListProjectIssues retrieves a list of issues for the specified project.
-func (c *Client) ListProjectIssues(projectID any) ([]*Issue, error) {
-	issues, _, err := c.git.Issues.ListProjectIssues(projectID, &gitlab_client.ListProjectIssuesOptions{})
+func (c *Client) ListProjectIssues(ctx context.Context, projectID any) ([]*Issue, error) {
+	issues, _, err := c.git.Issues.ListProjectIssues(projectID, &gitlab_client.ListProjectIssuesOptions{}, gitlab_client.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
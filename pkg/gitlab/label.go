@@ -1,6 +1,7 @@
 package gitlab
 
 import (
+	"context"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
@@ -47,8 +48,8 @@ var Colors = struct {
 type Label = gitlab.Label
 
 // Client represents a GitLab API client.
-func (c *Client) GetProjectLabels(projectID int) ([]*Label, error) {
-	labels, _, err := c.git.Labels.ListLabels(projectID, &gitlab.ListLabelsOptions{})
+func (c *Client) GetProjectLabels(ctx context.Context, projectID int) ([]*Label, error) {
+	labels, _, err := c.git.Labels.ListLabels(projectID, &gitlab.ListLabelsOptions{}, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -57,11 +58,11 @@ func (c *Client) GetProjectLabels(projectID int) ([]*Label, error) {
 }
 
 // CreateLabel creates a new label in the specified project with the provided options.
-func (c *Client) CreateLabel(projectID int, opts *gitlab.CreateLabelOptions) (*Label, error) {
+func (c *Client) CreateLabel(ctx context.Context, projectID int, opts *gitlab.CreateLabelOptions) (*Label, error) {
 	if opts.Color == nil {
 		opts.Color = &Colors.Gray.HexValue
 	}
-	label, _, err := c.git.Labels.CreateLabel(projectID, opts)
+	label, _, err := c.git.Labels.CreateLabel(projectID, opts, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -70,8 +71,8 @@ func (c *Client) CreateLabel(projectID int, opts *gitlab.CreateLabelOptions) (*L
 }
 
 // GetLabelByID retrieves a label by its ID from the specified project.
-func (c *Client) GetLabelByID(projectID int, labelID int) (*Label, error) {
-	label, _, err := c.git.Labels.GetLabel(projectID, labelID)
+func (c *Client) GetLabelByID(ctx context.Context, projectID int, labelID int) (*Label, error) {
+	label, _, err := c.git.Labels.GetLabel(projectID, labelID, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -80,8 +81,8 @@ func (c *Client) GetLabelByID(projectID int, labelID int) (*Label, error) {
 }
 
 // GetLabelbyName retrieves a label by its name from the specified project.
-func (c *Client) GetLabelbyName(projectID int, name string) (*Label, error) {
-	labels, _, err := c.git.Labels.ListLabels(projectID, &gitlab.ListLabelsOptions{Search: &name})
+func (c *Client) GetLabelbyName(ctx context.Context, projectID int, name string) (*Label, error) {
+	labels, _, err := c.git.Labels.ListLabels(projectID, &gitlab.ListLabelsOptions{Search: &name}, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -100,8 +101,8 @@ func (c *Client) GetLabelbyName(projectID int, name string) (*Label, error) {
 }
 
 // UpdateLabel updates an existing label in the specified project with the provided options.
-func (c *Client) UpdateLabel(projectID int, labelID int, opts *gitlab.UpdateLabelOptions) (*Label, error) {
-	label, _, err := c.git.Labels.UpdateLabel(projectID, labelID, opts)
+func (c *Client) UpdateLabel(ctx context.Context, projectID int, labelID int, opts *gitlab.UpdateLabelOptions) (*Label, error) {
+	label, _, err := c.git.Labels.UpdateLabel(projectID, labelID, opts, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
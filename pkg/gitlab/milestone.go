@@ -1,6 +1,7 @@
 package gitlab
 
 import (
+	"context"
 	"time"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go"
@@ -13,8 +14,8 @@ type ISOTime time.Time
 type ListMilestonesOptions = gitlab.ListMilestonesOptions
 
 // ListMilestones retrieves a list of milestones for a given project.
-func (c *Client) ListMilestones(projectID any, opts *ListMilestonesOptions) ([]*Milestone, error) {
-	milestones, _, err := c.git.Milestones.ListMilestones(projectID, opts)
+func (c *Client) ListMilestones(ctx context.Context, projectID any, opts *ListMilestonesOptions) ([]*Milestone, error) {
+	milestones, _, err := c.git.Milestones.ListMilestones(projectID, opts, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -26,8 +27,8 @@ func (c *Client) ListMilestones(projectID any, opts *ListMilestonesOptions) ([]*
 type Milestone = gitlab.Milestone
 
 // GetMilestone retrieves a specific milestone by its ID.
-func (c *Client) GetMilestone(projectID any, milestoneID int) (*Milestone, error) {
-	milestone, _, err := c.git.Milestones.GetMilestone(projectID, milestoneID)
+func (c *Client) GetMilestone(ctx context.Context, projectID any, milestoneID int) (*Milestone, error) {
+	milestone, _, err := c.git.Milestones.GetMilestone(projectID, milestoneID, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -43,13 +44,13 @@ type MilestoneOptions struct {
 }
 
 // CreateMilestone creates a new milestone in the specified project.
-func (c *Client) CreateMilestone(projectID any, opts *MilestoneOptions) (*Milestone, error) {
+func (c *Client) CreateMilestone(ctx context.Context, projectID any, opts *MilestoneOptions) (*Milestone, error) {
 	gitlabOpts := &gitlab.CreateMilestoneOptions{
 		Title:       &opts.Title,
 		Description: &opts.Description,
 		DueDate:     (*gitlab.ISOTime)(opts.DueDate),
 	}
-	milestone, _, err := c.git.Milestones.CreateMilestone(projectID, gitlabOpts)
+	milestone, _, err := c.git.Milestones.CreateMilestone(projectID, gitlabOpts, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -60,8 +61,8 @@ func (c *Client) CreateMilestone(projectID any, opts *MilestoneOptions) (*Milest
 type UpdateMilestoneOptions = gitlab.UpdateMilestoneOptions
 
 // UpdateMilestone updates an existing milestone in the specified project.
-func (c *Client) UpdateMilestone(projectID any, milestoneID int, opts *UpdateMilestoneOptions) (*Milestone, error) {
-	milestone, _, err := c.git.Milestones.UpdateMilestone(projectID, milestoneID, opts)
+func (c *Client) UpdateMilestone(ctx context.Context, projectID any, milestoneID int, opts *UpdateMilestoneOptions) (*Milestone, error) {
+	milestone, _, err := c.git.Milestones.UpdateMilestone(projectID, milestoneID, opts, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -69,12 +70,12 @@ func (c *Client) UpdateMilestone(projectID any, milestoneID int, opts *UpdateMil
 }
 
 // GetMilestoneByName retrieves a milestone by its name from the specified project.
-func (c *Client) GetMilestoneByName(projectID any, name string) (*Milestone, error) {
+func (c *Client) GetMilestoneByName(ctx context.Context, projectID any, name string) (*Milestone, error) {
 	opts := &ListMilestonesOptions{
 		Search: &name,
 	}
 
-	milestones, _, err := c.git.Milestones.ListMilestones(projectID, opts)
+	milestones, _, err := c.git.Milestones.ListMilestones(projectID, opts, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
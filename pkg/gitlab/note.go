@@ -0,0 +1,44 @@
+package gitlab
+
+import (
+	"context"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// Note represents a comment on a GitLab issue, here it is aliased to the GitLab client type for easier usage.
+type Note = gitlab.Note
+
+// CreateIssueNoteOptions defines the options for creating a note on an issue, here it is aliased to the GitLab client type for easier usage.
+type CreateIssueNoteOptions = gitlab.CreateIssueNoteOptions
+
+// ListIssueNotes retrieves all notes of an issue, oldest first.
+func (c *Client) ListIssueNotes(ctx context.Context, projectID any, issueID int) ([]*Note, error) {
+	opts := &gitlab.ListIssueNotesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 100},
+		Sort:        gitlab.Ptr("asc"),
+	}
+
+	var notes []*Note
+	for {
+		page, resp, err := c.git.Notes.ListIssueNotes(projectID, issueID, opts, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, page...)
+		if resp.NextPage == 0 {
+			return notes, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// CreateIssueNote adds a note to an issue of the specified project.
+func (c *Client) CreateIssueNote(ctx context.Context, projectID any, issueID int, opts *CreateIssueNoteOptions) (*Note, error) {
+	note, _, err := c.git.Notes.CreateIssueNote(projectID, issueID, opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
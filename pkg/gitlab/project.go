@@ -1,6 +1,7 @@
 package gitlab
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 
@@ -8,8 +9,8 @@ import (
 )
 
 // GetProjectList retrieves a list of projects from GitLab and prints their IDs and names.
-func (c *Client) GetProjectList() error {
-	projects, _, err := c.git.Projects.ListProjects(&gitlab.ListProjectsOptions{})
+func (c *Client) GetProjectList(ctx context.Context) error {
+	projects, _, err := c.git.Projects.ListProjects(&gitlab.ListProjectsOptions{}, gitlab.WithContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -20,8 +21,8 @@ func (c *Client) GetProjectList() error {
 }
 
 // GetProject retrieves a specific project by its ID.
-func (c *Client) GetProject(id any) (*gitlab.Project, error) {
-	project, _, err := c.git.Projects.GetProject(id, &gitlab.GetProjectOptions{})
+func (c *Client) GetProject(ctx context.Context, id any) (*gitlab.Project, error) {
+	project, _, err := c.git.Projects.GetProject(id, &gitlab.GetProjectOptions{}, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project %v: %w", id, err)
 	}
@@ -29,8 +30,8 @@ func (c *Client) GetProject(id any) (*gitlab.Project, error) {
 }
 
 // GetProjectMembers retrieves the members of a specific project by its ID.
-func (c *Client) GetProjectMembers(projectID any) ([]*gitlab.ProjectMember, error) {
-	members, _, err := c.git.ProjectMembers.ListAllProjectMembers(projectID, &gitlab.ListProjectMembersOptions{})
+func (c *Client) GetProjectMembers(ctx context.Context, projectID any) ([]*gitlab.ProjectMember, error) {
+	members, _, err := c.git.ProjectMembers.ListAllProjectMembers(projectID, &gitlab.ListProjectMembersOptions{}, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get members for project %v: %w", projectID, err)
 	}
@@ -39,8 +40,8 @@ func (c *Client) GetProjectMembers(projectID any) ([]*gitlab.ProjectMember, erro
 }
 
 // GetProjectMember retrieves a specific member of a project by project ID and user ID.
-func (c *Client) GetProjectMember(projectID any, userID int) (*gitlab.ProjectMember, error) {
-	member, _, err := c.git.ProjectMembers.GetProjectMember(projectID, userID)
+func (c *Client) GetProjectMember(ctx context.Context, projectID any, userID int) (*gitlab.ProjectMember, error) {
+	member, _, err := c.git.ProjectMembers.GetProjectMember(projectID, userID, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get member %d for project %v: %w", userID, projectID, err)
 	}
@@ -49,14 +50,14 @@ func (c *Client) GetProjectMember(projectID any, userID int) (*gitlab.ProjectMem
 }
 
 // SetProjectMemberAccessLevel updates a project member's access level.
-func (c *Client) SetProjectMemberAccessLevel(projectID any, userID int, accessLevel gitlab.AccessLevelValue) error {
+func (c *Client) SetProjectMemberAccessLevel(ctx context.Context, projectID any, userID int, accessLevel gitlab.AccessLevelValue) error {
 	if !ValidateAccessLevel(int(accessLevel)) {
 		return fmt.Errorf("invalid access level: %d", accessLevel)
 	}
 
 	_, _, err := c.git.ProjectMembers.EditProjectMember(projectID, userID, &gitlab.EditProjectMemberOptions{
 		AccessLevel: &accessLevel,
-	})
+	}, gitlab.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to set access level for user %d in project %v: %w", userID, projectID, err)
 	}
@@ -65,7 +66,7 @@ func (c *Client) SetProjectMemberAccessLevel(projectID any, userID int, accessLe
 }
 
 // AddProjectMember adds a user to a project with a specified access level.
-func (c *Client) AddProjectMember(projectID any, userID int, accessLevel gitlab.AccessLevelValue) error {
+func (c *Client) AddProjectMember(ctx context.Context, projectID any, userID int, accessLevel gitlab.AccessLevelValue) error {
 	if !ValidateAccessLevel(int(accessLevel)) {
 		return fmt.Errorf("invalid access level: %d", accessLevel)
 	}
@@ -73,7 +74,7 @@ func (c *Client) AddProjectMember(projectID any, userID int, accessLevel gitlab.
 	_, _, err := c.git.ProjectMembers.AddProjectMember(projectID, &gitlab.AddProjectMemberOptions{
 		UserID:      &userID,
 		AccessLevel: &accessLevel,
-	})
+	}, gitlab.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to add user %d to project %v: %w", userID, projectID, err)
 	}
@@ -1,8 +1,11 @@
 package gitlab
 
 import (
+	"context"
 	"log"
 	"sync"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 // UserSession represents a user session in GitLab, including the user ID, impersonation token info, and the client used for API calls.
@@ -41,11 +44,11 @@ func (usc *UserSessionCache) Set(email string, sess *UserSession) {
 }
 
 // RevokeAll revokes all user sessions in the cache by iterating through each session and calling the RevokeImpersonationToken method on the provided client.
-func (usc *UserSessionCache) RevokeAll(c *Client) {
+func (usc *UserSessionCache) RevokeAll(ctx context.Context, c *Client) {
 	usc.mu.Lock()
 	defer usc.mu.Unlock()
 	for email, sess := range usc.sessions {
-		if _, err := c.git.Users.RevokeImpersonationToken(sess.UserID, sess.TokenInfo.ID); err != nil {
+		if _, err := c.git.Users.RevokeImpersonationToken(sess.UserID, sess.TokenInfo.ID, gitlab.WithContext(ctx)); err != nil {
 			log.Printf("failed to revoke token for user %s: %v", email, err)
 		}
 	}
@@ -0,0 +1,20 @@
+package gitlab
+
+import (
+	"context"
+	"io"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// UploadedFile is a file uploaded to a project, here it is aliased to the GitLab client type for easier usage.
+type UploadedFile = gitlab.MarkdownUploadedFile
+
+// UploadProjectFile uploads a file to a project so it can be linked from issue descriptions and notes.
+func (c *Client) UploadProjectFile(ctx context.Context, projectID any, content io.Reader, filename string) (*UploadedFile, error) {
+	file, _, err := c.git.ProjectMarkdownUploads.UploadProjectMarkdown(projectID, content, filename, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
@@ -1,6 +1,7 @@
 package gitlab
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -23,8 +24,8 @@ type User struct {
 var ErrUserNotFound = errors.New("user not found")
 
 // GetCurrentUser retrieves the currently authenticated user from GitLab.
-func (c *Client) GetCurrentUser() (*gitlab.User, error) {
-	user, _, err := c.git.Users.CurrentUser()
+func (c *Client) GetCurrentUser(ctx context.Context) (*gitlab.User, error) {
+	user, _, err := c.git.Users.CurrentUser(gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current user: %w", err)
 	}
@@ -32,7 +33,7 @@ func (c *Client) GetCurrentUser() (*gitlab.User, error) {
 }
 
 // CreateUser creates a new user in GitLab with the specified username, name, and email.
-func (c *Client) CreateUser(username, name, email string) (*gitlab.User, error) {
+func (c *Client) CreateUser(ctx context.Context, username, name, email string) (*gitlab.User, error) {
 	opts := &gitlab.CreateUserOptions{
 		Username:            &username,
 		Name:                &name,
@@ -40,7 +41,7 @@ func (c *Client) CreateUser(username, name, email string) (*gitlab.User, error)
 		ForceRandomPassword: gitlab.Ptr(true),
 	}
 
-	user, _, err := c.git.Users.CreateUser(opts)
+	user, _, err := c.git.Users.CreateUser(opts, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -49,19 +50,19 @@ func (c *Client) CreateUser(username, name, email string) (*gitlab.User, error)
 }
 
 // CreateUserFromEmail creates a new user in GitLab based on the provided email address.
-func (c *Client) CreateUserFromEmail(email string) (*gitlab.User, error) {
+func (c *Client) CreateUserFromEmail(ctx context.Context, email string) (*gitlab.User, error) {
 	parts := strings.Split(email, "@")
 
 	username := parts[0]
 	name := username
 	email = strings.ToLower(email)
 
-	return c.CreateUser(username, name, email)
+	return c.CreateUser(ctx, username, name, email)
 }
 
 // UpdateUser updates an existing user in GitLab with the specified options.
-func (c *Client) UpdateUser(userID int, opts *gitlab.ModifyUserOptions) (*gitlab.User, error) {
-	user, _, err := c.git.Users.ModifyUser(userID, opts)
+func (c *Client) UpdateUser(ctx context.Context, userID int, opts *gitlab.ModifyUserOptions) (*gitlab.User, error) {
+	user, _, err := c.git.Users.ModifyUser(userID, opts, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user %d: %w", userID, err)
 	}
@@ -70,8 +71,8 @@ func (c *Client) UpdateUser(userID int, opts *gitlab.ModifyUserOptions) (*gitlab
 }
 
 // DeactivateUser deactivates a user in GitLab by their ID.
-func (c *Client) DeactivateUser(userID int) error {
-	err := c.git.Users.DeactivateUser(userID)
+func (c *Client) DeactivateUser(ctx context.Context, userID int) error {
+	err := c.git.Users.DeactivateUser(userID, gitlab.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to deactivate user %d: %w", userID, err)
 	}
@@ -80,8 +81,8 @@ func (c *Client) DeactivateUser(userID int) error {
 }
 
 // ActivateUser activates a user in GitLab by their ID.
-func (c *Client) ActivateUser(userID int) error {
-	err := c.git.Users.ActivateUser(userID)
+func (c *Client) ActivateUser(ctx context.Context, userID int) error {
+	err := c.git.Users.ActivateUser(userID, gitlab.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to activate user %d: %w", userID, err)
 	}
@@ -90,8 +91,8 @@ func (c *Client) ActivateUser(userID int) error {
 }
 
 // BlockUser blocks a user in GitLab by their ID.
-func (c *Client) BlockUser(userID int) error {
-	err := c.git.Users.BlockUser(userID)
+func (c *Client) BlockUser(ctx context.Context, userID int) error {
+	err := c.git.Users.BlockUser(userID, gitlab.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to block user %d: %w", userID, err)
 	}
@@ -100,8 +101,8 @@ func (c *Client) BlockUser(userID int) error {
 }
 
 // UnblockUser unblocks a user in GitLab by their ID.
-func (c *Client) UnblockUser(userID int) error {
-	err := c.git.Users.UnblockUser(userID)
+func (c *Client) UnblockUser(ctx context.Context, userID int) error {
+	err := c.git.Users.UnblockUser(userID, gitlab.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to unblock user %d: %w", userID, err)
 	}
@@ -110,8 +111,8 @@ func (c *Client) UnblockUser(userID int) error {
 }
 
 // GetUserByID retrieves a user from GitLab by their ID.
-func (c *Client) GetUserByID(userID int) (*gitlab.User, error) {
-	user, _, err := c.git.Users.GetUser(userID, gitlab.GetUsersOptions{})
+func (c *Client) GetUserByID(ctx context.Context, userID int) (*gitlab.User, error) {
+	user, _, err := c.git.Users.GetUser(userID, gitlab.GetUsersOptions{}, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by ID %d: %w", userID, err)
 	}
@@ -120,8 +121,8 @@ func (c *Client) GetUserByID(userID int) (*gitlab.User, error) {
 }
 
 // GetUserByUsername retrieves a user from GitLab by their username.
-func (c *Client) GetUserByUsername(username string) (*gitlab.User, error) {
-	users, _, err := c.git.Users.ListUsers(&gitlab.ListUsersOptions{Username: &username})
+func (c *Client) GetUserByUsername(ctx context.Context, username string) (*gitlab.User, error) {
+	users, _, err := c.git.Users.ListUsers(&gitlab.ListUsersOptions{Username: &username}, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by username %s: %w", username, err)
 	}
@@ -140,8 +141,8 @@ func (c *Client) GetUserByUsername(username string) (*gitlab.User, error) {
 }
 
 // GetUserByEmail retrieves a user from GitLab by their email address.
-func (c *Client) GetUserByEmail(email string) (*gitlab.User, error) {
-	users, _, err := c.git.Users.ListUsers(&gitlab.ListUsersOptions{Search: &email})
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (*gitlab.User, error) {
+	users, _, err := c.git.Users.ListUsers(&gitlab.ListUsersOptions{Search: &email}, gitlab.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by email %s: %w", email, err)
 	}
@@ -156,18 +157,49 @@ func (c *Client) GetUserByEmail(email string) (*gitlab.User, error) {
 }
 
 // CreateIssueAsUser creates an issue in a GitLab project as a specific user identified by their email address.
-func (c *Client) CreateIssueAsUser(config *cfg.Config, cache *UserSessionCache, projectID any, email string, opts *gitlab.CreateIssueOptions) (*Issue, error) {
+func (c *Client) CreateIssueAsUser(ctx context.Context, config *cfg.Config, cache *UserSessionCache, projectID any, email string, opts *gitlab.CreateIssueOptions) (*Issue, error) {
+	sess, err := c.userSession(ctx, config, cache, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return sess.Client.CreateIssue(ctx, projectID, opts)
+}
+
+// CreateIssueNoteAsUser adds a note to an issue as a specific user identified by their email address.
+func (c *Client) CreateIssueNoteAsUser(ctx context.Context, config *cfg.Config, cache *UserSessionCache, projectID any, issueID int, email string, opts *CreateIssueNoteOptions) (*Note, error) {
+	sess, err := c.userSession(ctx, config, cache, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return sess.Client.CreateIssueNote(ctx, projectID, issueID, opts)
+}
+
+// CreateIssueNoteAsGitLabUser adds a note to an issue as a GitLab user that was looked up before.
+func (c *Client) CreateIssueNoteAsGitLabUser(ctx context.Context, config *cfg.Config, cache *UserSessionCache, projectID any, issueID int, user *gitlab.User, opts *CreateIssueNoteOptions) (*Note, error) {
+	sess, err := c.impersonate(ctx, config, cache, user.Username, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return sess.Client.CreateIssueNote(ctx, projectID, issueID, opts)
+}
+
+// userSession returns the cached session of a user or impersonates the user, creating
+// them if they do not exist and auto-creation is enabled
+func (c *Client) userSession(ctx context.Context, config *cfg.Config, cache *UserSessionCache, email string) (*UserSession, error) {
 	sess, ok := cache.Get(email)
 	if ok {
 		slog.Debug("Using cached impersonated client", "email", email)
-		return sess.Client.CreateIssue(projectID, opts)
+		return sess, nil
 	}
 
-	user, err := c.GetUserByEmail(email)
+	user, err := c.GetUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
 			if config.ImportOptions.CreateUsers {
-				user, err = c.CreateUserFromEmail(email)
+				user, err = c.CreateUserFromEmail(ctx, email)
 				if err != nil {
 					return nil, fmt.Errorf("failed to auto-create user %q: %w", email, err)
 				}
@@ -179,7 +211,16 @@ func (c *Client) CreateIssueAsUser(config *cfg.Config, cache *UserSessionCache,
 		}
 	}
 
-	tokenInfo, err := c.EnsureImpersonationToken(user.ID, "issue-import-token", []string{"api"})
+	return c.impersonate(ctx, config, cache, email, user)
+}
+
+// impersonate returns a session acting as the user and caches it under key
+func (c *Client) impersonate(ctx context.Context, config *cfg.Config, cache *UserSessionCache, key string, user *gitlab.User) (*UserSession, error) {
+	if sess, ok := cache.Get(key); ok {
+		return sess, nil
+	}
+
+	tokenInfo, err := c.EnsureImpersonationToken(ctx, user.ID, "issue-import-token", []string{"api"})
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize impersonation token: %w", err)
 	}
@@ -188,16 +229,16 @@ func (c *Client) CreateIssueAsUser(config *cfg.Config, cache *UserSessionCache,
 	impersonatedConfig.GitLab.Token = tokenInfo.Token
 	impersonatedClient, err := NewGitLabClient(&impersonatedConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create impersonated client for user %q: %w", email, err)
+		return nil, fmt.Errorf("failed to create impersonated client for user %q: %w", key, err)
 	}
 
-	sess = &UserSession{
+	sess := &UserSession{
 		TokenInfo: tokenInfo,
 		Client:    impersonatedClient,
 		UserID:    user.ID,
 	}
 
-	cache.Set(email, sess)
+	cache.Set(key, sess)
 
-	return impersonatedClient.CreateIssue(projectID, opts)
+	return sess, nil
 }
@@ -1,8 +1,11 @@
 package trac
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/bnidev/trac2gitlab/internal/utils"
@@ -26,7 +29,7 @@ const (
 )
 
 // ListAttachments retrieves all attachments for a given resource type and ID
-func ListAttachments(c *Client, resType ResourceType, id any) ([]Attachment, error) {
+func (c *Client) ListAttachments(ctx context.Context, resType ResourceType, id any) ([]Attachment, error) {
 	var method string
 	switch resType {
 	case ResourceTicket:
@@ -38,7 +41,7 @@ func ListAttachments(c *Client, resType ResourceType, id any) ([]Attachment, err
 	}
 
 	var raw []any
-	err := c.rpc.Call(method, []any{id}, &raw)
+	err := c.rpc.CallContext(ctx, method, []any{id}, &raw)
 	if err != nil {
 		return nil, err
 	}
@@ -46,12 +49,13 @@ func ListAttachments(c *Client, resType ResourceType, id any) ([]Attachment, err
 	attachments := make([]Attachment, 0, len(raw))
 	for i, item := range raw {
 		if resType == ResourceWiki {
-			// Wiki: only filename available
-			filename, ok := item.(string)
+			// Wiki: only the path "<page>/<filename>" is available
+			path, ok := item.(string)
 			if !ok {
 				fmt.Printf("skipping: expected string at index %d: %#v\n", i, item)
 				continue
 			}
+			filename := strings.TrimPrefix(path, fmt.Sprintf("%v/", id))
 			attachments = append(attachments, Attachment{
 				Filename: filename,
 				// No metadata available
@@ -98,23 +102,14 @@ func ListAttachments(c *Client, resType ResourceType, id any) ([]Attachment, err
 }
 
 // GetAttachment downloads an attachment by its resource type and identifiers
-func GetAttachment(c *Client, resType ResourceType, id any, filename string) ([]byte, error) {
-	var method string
-	var args []any
-
-	switch resType {
-	case ResourceTicket:
-		method = "ticket.getAttachment"
-		args = []any{id, filename}
-	case ResourceWiki:
-		method = "wiki.getAttachment"
-		args = []any{filename}
-	default:
-		return nil, fmt.Errorf("unsupported resource type: %s", resType)
+func GetAttachment(ctx context.Context, c *Client, resType ResourceType, id any, filename string) ([]byte, error) {
+	method, args, err := attachmentCall(resType, id, filename)
+	if err != nil {
+		return nil, err
 	}
 
 	var base64Str string
-	if err := c.rpc.Call(method, args, &base64Str); err != nil {
+	if err := c.rpc.CallContext(ctx, method, args, &base64Str); err != nil {
 		return nil, err
 	}
 
@@ -124,3 +119,25 @@ func GetAttachment(c *Client, resType ResourceType, id any, filename string) ([]
 	}
 	return content, nil
 }
+
+// DownloadAttachment streams an attachment to w while it is decoded, without holding it
+// in memory. It returns the number of bytes written.
+func (c *Client) DownloadAttachment(ctx context.Context, resType ResourceType, id any, filename string, w io.Writer) (int64, error) {
+	method, args, err := attachmentCall(resType, id, filename)
+	if err != nil {
+		return 0, err
+	}
+	return c.rpc.CallBase64(ctx, method, args, w)
+}
+
+// attachmentCall returns the XML-RPC method and arguments fetching an attachment
+func attachmentCall(resType ResourceType, id any, filename string) (string, []any, error) {
+	switch resType {
+	case ResourceTicket:
+		return "ticket.getAttachment", []any{id, filename}, nil
+	case ResourceWiki:
+		return "wiki.getAttachment", []any{fmt.Sprintf("%v/%s", id, filename)}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported resource type: %s", resType)
+	}
+}
@@ -0,0 +1,163 @@
+package trac
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// Capability is a feature of a Trac source that parts of the export depend on
+type Capability string
+
+const (
+	CapTickets           Capability = "tickets"
+	CapTicketChangeLog   Capability = "ticket_changelog"
+	CapTicketAttachments Capability = "ticket_attachments"
+	CapRecentChanges     Capability = "recent_changes"
+	CapTicketFields      Capability = "ticket_fields"
+	CapMilestones        Capability = "milestones"
+	CapComponents        Capability = "components"
+	CapWorkflow          Capability = "workflow"
+	CapWiki              Capability = "wiki"
+	CapWikiAttachments   Capability = "wiki_attachments"
+)
+
+// capabilityMethods lists the XML-RPC methods each capability needs, in the order capabilities are reported
+var capabilityMethods = []struct {
+	capability  Capability
+	methods     []string
+	description string
+}{
+	{CapTickets, []string{"ticket.query", "ticket.get"}, "list and read tickets"},
+	{CapTicketChangeLog, []string{"ticket.changeLog"}, "comments and ticket history"},
+	{CapTicketAttachments, []string{"ticket.listAttachments", "ticket.getAttachment"}, "ticket attachments"},
+	{CapRecentChanges, []string{"ticket.getRecentChanges"}, "resume only tickets changed since the last run"},
+	{CapTicketFields, []string{"ticket.getTicketFields"}, "ticket field definitions"},
+	{CapMilestones, []string{"ticket.milestone.getAll", "ticket.milestone.get"}, "milestones"},
+	{CapComponents, []string{"ticket.component.getAll", "ticket.component.get"}, "components and their owners"},
+	{CapWorkflow, []string{"ticket.getActions"}, "workflow actions for ordering statuses"},
+	{CapWiki, []string{"wiki.getAllPages", "wiki.getPage", "wiki.getPageInfo"}, "wiki pages and history"},
+	{CapWikiAttachments, []string{"wiki.listAttachments", "wiki.getAttachment"}, "wiki attachments"},
+}
+
+// Capabilities is the set of features a Trac source supports. A nil set supports everything.
+type Capabilities struct {
+	// APIVersion is the version of the XML-RPC plugin, empty for other sources
+	APIVersion []int64
+	missing    map[Capability][]string
+}
+
+// CapabilityInfo describes a capability and whether a source supports it
+type CapabilityInfo struct {
+	Capability  Capability
+	Description string
+	Supported   bool
+	// Missing lists the XML-RPC methods the source lacks for the capability
+	Missing []string
+}
+
+// CapabilitiesFromMethods builds the capabilities of an XML-RPC plugin offering the given methods
+func CapabilitiesFromMethods(methods []string, apiVersion []int64) *Capabilities {
+	caps := &Capabilities{APIVersion: apiVersion, missing: make(map[Capability][]string)}
+	for _, c := range capabilityMethods {
+		for _, method := range c.methods {
+			if !slices.Contains(methods, method) {
+				caps.missing[c.capability] = append(caps.missing[c.capability], method)
+			}
+		}
+	}
+	return caps
+}
+
+// AllCapabilitiesExcept returns the capabilities of a source supporting everything but the given ones
+func AllCapabilitiesExcept(unsupported ...Capability) *Capabilities {
+	caps := &Capabilities{missing: make(map[Capability][]string)}
+	for _, c := range unsupported {
+		caps.missing[c] = nil
+	}
+	return caps
+}
+
+// Has reports whether the capability is supported
+func (c *Capabilities) Has(capability Capability) bool {
+	if c == nil {
+		return true
+	}
+	_, missing := c.missing[capability]
+	return !missing
+}
+
+// List describes all capabilities in a fixed order
+func (c *Capabilities) List() []CapabilityInfo {
+	list := make([]CapabilityInfo, 0, len(capabilityMethods))
+	for _, m := range capabilityMethods {
+		info := CapabilityInfo{Capability: m.capability, Description: m.description, Supported: c.Has(m.capability)}
+		if c != nil {
+			info.Missing = c.missing[m.capability]
+		}
+		list = append(list, info)
+	}
+	return list
+}
+
+// VersionAtLeast reports whether the XML-RPC plugin has at least the given version.
+// Unknown versions are assumed to be recent enough.
+func (c *Capabilities) VersionAtLeast(version ...int64) bool {
+	if c == nil || len(c.APIVersion) == 0 {
+		return true
+	}
+	for i, want := range version {
+		have := int64(0)
+		if i < len(c.APIVersion) {
+			have = c.APIVersion[i]
+		}
+		if have != want {
+			return have > want
+		}
+	}
+	return true
+}
+
+// VersionString formats the XML-RPC plugin version, e.g. "1.1.9"
+func (c *Capabilities) VersionString() string {
+	if c == nil || len(c.APIVersion) == 0 {
+		return "unknown"
+	}
+	parts := make([]string, len(c.APIVersion))
+	for i, v := range c.APIVersion {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ".")
+}
+
+// DetectCapabilities asks the XML-RPC plugin for its methods and version. The client
+// then skips calls the plugin does not support instead of failing.
+func (c *Client) DetectCapabilities(ctx context.Context) (*Capabilities, error) {
+	methods, err := c.GetAvailableMethods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available methods: %w", err)
+	}
+
+	version, err := c.CheckPluginVersion(ctx)
+	if err != nil {
+		slog.Warn("Failed to get the XML-RPC plugin version", "error", err)
+		version = nil
+	}
+
+	c.caps = CapabilitiesFromMethods(methods, version)
+	slog.Debug("Detected Trac capabilities", "version", c.caps.VersionString(), "methods", len(methods))
+	return c.caps, nil
+}
+
+// Capabilities returns the detected capabilities of the client, nil before DetectCapabilities
+func (c *Client) Capabilities() *Capabilities {
+	return c.caps
+}
+
+// Capabilities returns the capabilities of the web pages, which have no component
+// details and no workflow
+func (w *Web) Capabilities() *Capabilities {
+	return AllCapabilitiesExcept(CapComponents, CapWorkflow)
+}
@@ -0,0 +1,43 @@
+package trac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCapabilitiesFromMethods(t *testing.T) {
+	caps := CapabilitiesFromMethods([]string{
+		"system.listMethods", "ticket.query", "ticket.get", "ticket.changeLog",
+		"wiki.getAllPages", "wiki.getPage", "wiki.getPageInfo", "wiki.listAttachments",
+	}, []int64{1, 1, 8})
+
+	for capability, want := range map[Capability]bool{
+		CapTickets:           true,
+		CapTicketChangeLog:   true,
+		CapTicketAttachments: false,
+		CapRecentChanges:     false,
+		CapWiki:              true,
+		CapWikiAttachments:   false,
+		CapWorkflow:          false,
+	} {
+		if got := caps.Has(capability); got != want {
+			t.Errorf("Has(%s) = %v, want %v", capability, got, want)
+		}
+	}
+
+	for _, info := range caps.List() {
+		if info.Capability == CapWikiAttachments && !reflect.DeepEqual(info.Missing, []string{"wiki.getAttachment"}) {
+			t.Errorf("expected wiki.getAttachment to be missing, got %v", info.Missing)
+		}
+	}
+
+	if caps.VersionAtLeast(1, 1, 9) || !caps.VersionAtLeast(1, 1) || caps.VersionString() != "1.1.8" {
+		t.Errorf("unexpected version checks for %s", caps.VersionString())
+	}
+
+	// Sources without detection support everything
+	var all *Capabilities
+	if !all.Has(CapWorkflow) || !all.VersionAtLeast(9) {
+		t.Error("nil capabilities should support everything")
+	}
+}
@@ -0,0 +1,44 @@
+package trac
+
+import (
+	"context"
+	"fmt"
+)
+
+// Component represents a Trac component with its default owner
+type Component struct {
+	Name        string `json:"name"`
+	Owner       string `json:"owner,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// GetComponentNames retrieves the names of all components in Trac.
+func (c *Client) GetComponentNames(ctx context.Context) ([]string, error) {
+	var resp []string
+
+	err := c.rpc.CallContext(ctx, "ticket.component.getAll", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// GetComponentByName retrieves a component by its name.
+func (c *Client) GetComponentByName(ctx context.Context, name string) (*Component, error) {
+	var resp map[string]any
+	err := c.rpc.CallContext(ctx, "ticket.component.get", []any{name}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call component.get: %w", err)
+	}
+
+	component := &Component{Name: name}
+	if owner, ok := resp["owner"].(string); ok {
+		component.Owner = owner
+	}
+	if desc, ok := resp["description"].(string); ok {
+		component.Description = desc
+	}
+
+	return component, nil
+}
@@ -0,0 +1,598 @@
+package trac
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Env reads a Trac environment directly from its SQLite database and attachment files,
+// for installations without the XML-RPC plugin or when only a backup is left. It
+// returns the same data as the XML-RPC client and needs no network access.
+type Env struct {
+	dir            string
+	db             *sql.DB
+	attachmentsDir string
+	customFields   []TicketField
+	workflow       []WorkflowAction
+}
+
+// ticketColumns are the core fields stored in the ticket table
+var ticketColumns = []string{
+	"type", "component", "severity", "priority", "owner", "reporter", "cc", "version",
+	"milestone", "status", "resolution", "summary", "description", "keywords",
+}
+
+// OpenEnv opens the Trac environment in dir, which contains db/trac.db and the
+// attachments in files/attachments (Trac 1.0 and later) or attachments (older releases)
+func OpenEnv(dir string) (*Env, error) {
+	dbPath := filepath.Join(dir, "db", "trac.db")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open Trac database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Trac database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to open Trac database: %w", err)
+	}
+
+	env := &Env{dir: dir, db: db, attachmentsDir: filepath.Join(dir, "files", "attachments")}
+	if _, err := os.Stat(env.attachmentsDir); err != nil {
+		env.attachmentsDir = filepath.Join(dir, "attachments")
+	}
+
+	ini, err := readIni(filepath.Join(dir, "conf", "trac.ini"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to read trac.ini: %w", err)
+	}
+	env.customFields = customFieldsFromIni(ini["ticket-custom"])
+	env.workflow = workflowFromIni(ini["ticket-workflow"])
+
+	slog.Debug("Opened Trac environment", "dir", dir, "attachments", env.attachmentsDir, "customFields", len(env.customFields))
+	return env, nil
+}
+
+// Close closes the database of the environment
+func (e *Env) Close() error {
+	return e.db.Close()
+}
+
+// GetAllTicketIDs returns the IDs of all tickets matching a Trac query. Conditions of the
+// form field=value, field!=value and alternatives separated by | are supported.
+func (e *Env) GetAllTicketIDs(ctx context.Context, query string) ([]int, error) {
+	where, args, err := ticketQueryWhere(query)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := e.db.QueryContext(ctx, `SELECT id FROM ticket t`+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tickets: %w", err)
+	}
+	return scanInts(rows)
+}
+
+// GetRecentTicketChanges returns the IDs of all tickets changed since the given time
+func (e *Env) GetRecentTicketChanges(ctx context.Context, since time.Time) ([]int, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT id FROM ticket WHERE changetime >= ? ORDER BY id`, since.UnixMicro())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent ticket changes: %w", err)
+	}
+	return scanInts(rows)
+}
+
+// GetTicket reads a ticket with its custom fields, attachments and change log
+func (e *Env) GetTicket(ctx context.Context, id int) (*Ticket, error) {
+	var created, changed int64
+	values := make([]sql.NullString, len(ticketColumns))
+	dest := []any{&created, &changed}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+
+	err := e.db.QueryRowContext(ctx, `SELECT time, changetime, `+strings.Join(ticketColumns, ", ")+` FROM ticket WHERE id = ?`, id).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("ticket %d does not exist", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ticket %d: %w", id, err)
+	}
+
+	attributes := make(map[string]any, len(ticketColumns)+len(e.customFields))
+	for i, column := range ticketColumns {
+		attributes[column] = values[i].String
+	}
+	for _, field := range e.customFields {
+		attributes[field.Name] = ""
+	}
+
+	rows, err := e.db.QueryContext(ctx, `SELECT name, value FROM ticket_custom WHERE ticket = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom fields of ticket %d: %w", id, err)
+	}
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		attributes[name] = value.String
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	attachments, err := e.ListAttachments(ctx, ResourceTicket, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments for ticket %d: %w", id, err)
+	}
+
+	history, comments, err := e.GetTicketHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket history for %d: %w", id, err)
+	}
+
+	return &Ticket{
+		ID:          int64(id),
+		TimeCreated: fromTracTime(created),
+		TimeChanged: fromTracTime(changed),
+		Attributes:  attributes,
+		Attachments: attachments,
+		History:     history,
+		Comments:    comments,
+	}, nil
+}
+
+// GetTicketHistory reads the change log of a ticket, returning history and comment entries separately
+func (e *Env) GetTicketHistory(ctx context.Context, id int) ([]ChangeLogEntry, []ChangeLogEntry, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT time, author, field, oldvalue, newvalue FROM ticket_change
+		WHERE ticket = ? AND field IN ('description', 'status', 'resolution', 'comment') ORDER BY time, rowid`, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read change log of ticket %d: %w", id, err)
+	}
+
+	var history, comments []ChangeLogEntry
+	for rows.Next() {
+		var changeTime int64
+		var author, field string
+		var oldValue, newValue sql.NullString
+		if err := rows.Scan(&changeTime, &author, &field, &oldValue, &newValue); err != nil {
+			_ = rows.Close()
+			return nil, nil, err
+		}
+
+		entry := ChangeLogEntry{
+			Time:      fromTracTime(changeTime),
+			Author:    author,
+			Field:     field,
+			OldValue:  nullStringPtr(oldValue),
+			NewValue:  nullStringPtr(newValue),
+			Permanent: 1,
+		}
+		if field == "comment" {
+			comments = append(comments, entry)
+		} else {
+			history = append(history, entry)
+		}
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, nil, err
+	}
+
+	return history, comments, nil
+}
+
+// GetTicketFields returns the standard ticket fields with their options and the custom fields of trac.ini
+func (e *Env) GetTicketFields(ctx context.Context) ([]TicketField, error) {
+	options := func(query string, args ...any) ([]string, error) {
+		rows, err := e.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read field options: %w", err)
+		}
+		return scanStrings(rows)
+	}
+
+	enum := `SELECT name FROM enum WHERE type = ? ORDER BY CAST(value AS INTEGER), name`
+	selects := []struct {
+		name, label, query string
+		args               []any
+	}{
+		{"type", "Type", enum, []any{"ticket_type"}},
+		{"priority", "Priority", enum, []any{"priority"}},
+		{"severity", "Severity", enum, []any{"severity"}},
+		{"resolution", "Resolution", enum, []any{"resolution"}},
+		{"component", "Component", `SELECT name FROM component ORDER BY name`, nil},
+		{"milestone", "Milestone", `SELECT name FROM milestone ORDER BY COALESCE(NULLIF(completed, 0), 9223372036854775807), COALESCE(NULLIF(due, 0), 9223372036854775807), name`, nil},
+		{"version", "Version", `SELECT name FROM version ORDER BY COALESCE(time, 0) DESC, name`, nil},
+	}
+
+	fields := []TicketField{{Label: "Summary", Name: "summary", InputType: "text"}, {Label: "Reporter", Name: "reporter", InputType: "text"}}
+	for _, s := range selects {
+		values, err := options(s.query, s.args...)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, TicketField{Label: s.label, Name: s.name, InputType: "select", Options: values, Optional: s.name != "type" && s.name != "priority"})
+	}
+	fields = append(fields,
+		TicketField{Label: "Owner", Name: "owner", InputType: "text"},
+		TicketField{Label: "Keywords", Name: "keywords", InputType: "text"},
+		TicketField{Label: "Cc", Name: "cc", InputType: "text"},
+		TicketField{Label: "Description", Name: "description", InputType: "textarea"},
+	)
+
+	return append(fields, e.customFields...), nil
+}
+
+// GetMilestoneNames returns the names of all milestones
+func (e *Env) GetMilestoneNames(ctx context.Context) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT name FROM milestone ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list milestones: %w", err)
+	}
+	return scanStrings(rows)
+}
+
+// GetMilestoneByName reads a milestone by its name
+func (e *Env) GetMilestoneByName(ctx context.Context, name string) (*Milestone, error) {
+	var due, completed sql.NullInt64
+	var description sql.NullString
+	err := e.db.QueryRowContext(ctx, `SELECT due, completed, description FROM milestone WHERE name = ?`, name).Scan(&due, &completed, &description)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read milestone %q: %w", name, err)
+	}
+
+	m := &Milestone{Name: name, Description: &description.String}
+	if due.Int64 != 0 {
+		t := fromTracTime(due.Int64)
+		m.DueDate = &t
+	}
+	if completed.Int64 != 0 {
+		t := fromTracTime(completed.Int64)
+		m.CompletedDate = &t
+	}
+	return m, nil
+}
+
+// GetComponentNames returns the names of all components
+func (e *Env) GetComponentNames(ctx context.Context) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT name FROM component ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list components: %w", err)
+	}
+	return scanStrings(rows)
+}
+
+// GetComponentByName reads a component by its name
+func (e *Env) GetComponentByName(ctx context.Context, name string) (*Component, error) {
+	var owner, description sql.NullString
+	err := e.db.QueryRowContext(ctx, `SELECT owner, description FROM component WHERE name = ?`, name).Scan(&owner, &description)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read component %q: %w", name, err)
+	}
+	return &Component{Name: name, Owner: owner.String, Description: description.String}, nil
+}
+
+// GetWorkflow returns the workflow of trac.ini, or the basic workflow of Trac if none
+// is configured, with the statuses of all tickets
+func (e *Env) GetWorkflow(ctx context.Context) (*Workflow, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT DISTINCT status FROM ticket WHERE COALESCE(status, '') != '' ORDER BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ticket statuses: %w", err)
+	}
+	statuses, err := scanStrings(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	w := NewWorkflow(e.workflow)
+	for _, status := range statuses {
+		w.addStatus(status)
+	}
+	return w, nil
+}
+
+// GetWikiPageNames returns the names of all wiki pages
+func (e *Env) GetWikiPageNames(ctx context.Context) ([]string, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT DISTINCT name FROM wiki ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wiki pages: %w", err)
+	}
+	return scanStrings(rows)
+}
+
+// GetWikiPageInfo reads the metadata of the latest version of a wiki page
+func (e *Env) GetWikiPageInfo(ctx context.Context, pageName string) (*WikiPage, error) {
+	return e.wikiPageInfo(ctx, pageName, `SELECT version, time, author, comment FROM wiki WHERE name = ? ORDER BY version DESC LIMIT 1`, pageName)
+}
+
+// GetWikiPageInfoVersion reads the metadata of a specific version of a wiki page
+func (e *Env) GetWikiPageInfoVersion(ctx context.Context, pageName string, version int64) (*WikiPage, error) {
+	return e.wikiPageInfo(ctx, pageName, `SELECT version, time, author, comment FROM wiki WHERE name = ? AND version = ?`, pageName, version)
+}
+
+func (e *Env) wikiPageInfo(ctx context.Context, pageName, query string, args ...any) (*WikiPage, error) {
+	page := &WikiPage{Name: pageName}
+	var modified int64
+	var author, comment sql.NullString
+	if err := e.db.QueryRowContext(ctx, query, args...).Scan(&page.Version, &modified, &author, &comment); err != nil {
+		return nil, fmt.Errorf("failed to read wiki page %q: %w", pageName, err)
+	}
+	page.Author = author.String
+	page.Comment = &comment.String
+	page.LastModified = fromTracTime(modified)
+
+	attachments, err := e.ListAttachments(ctx, ResourceWiki, pageName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments for wiki page %s: %w", pageName, err)
+	}
+	page.Attachments = attachments
+	return page, nil
+}
+
+// GetWikiPageVersion reads the raw Trac markup of a specific version of a wiki page
+func (e *Env) GetWikiPageVersion(ctx context.Context, pageName string, version int64) (*string, error) {
+	var text sql.NullString
+	err := e.db.QueryRowContext(ctx, `SELECT text FROM wiki WHERE name = ? AND version = ?`, pageName, version).Scan(&text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wiki page %q version %d: %w", pageName, version, err)
+	}
+	return &text.String, nil
+}
+
+// ListAttachments reads the attachments of a ticket or wiki page
+func (e *Env) ListAttachments(ctx context.Context, resType ResourceType, id any) ([]Attachment, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT filename, description, size, time, author FROM attachment
+		WHERE type = ? AND id = ? ORDER BY time, filename`, string(resType), fmt.Sprint(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+
+	var attachments []Attachment
+	for rows.Next() {
+		var att Attachment
+		var description, author sql.NullString
+		var size, attTime sql.NullInt64
+		if err := rows.Scan(&att.Filename, &description, &size, &attTime, &author); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		att.Description = description.String
+		att.Author = author.String
+		att.Size = size.Int64
+		att.Time = fromTracTime(attTime.Int64)
+		attachments = append(attachments, att)
+	}
+	return attachments, closeRows(rows)
+}
+
+// DownloadAttachment copies an attachment file of the environment to w
+func (e *Env) DownloadAttachment(ctx context.Context, resType ResourceType, id any, filename string, w io.Writer) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	f, err := e.openAttachment(resType, fmt.Sprint(id), filename)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return io.Copy(w, f)
+}
+
+// openAttachment opens an attachment file. Trac 1.0 and later store them under the SHA-1
+// of the parent ID and filename, older releases under their URL encoded names.
+func (e *Env) openAttachment(resType ResourceType, id, filename string) (*os.File, error) {
+	parent := sha1Hex(id)
+	hashed := filepath.Join(e.attachmentsDir, string(resType), parent[:3], parent, sha1Hex(filename)+filepath.Ext(filename))
+	f, err := os.Open(hashed)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return f, err
+	}
+
+	legacy := filepath.Join(e.attachmentsDir, string(resType), url.PathEscape(id), url.PathEscape(filename))
+	if f, err := os.Open(legacy); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("attachment %q of %s %s not found in %s", filename, resType, id, e.attachmentsDir)
+}
+
+// GetUsers reads the name and email address of all authenticated users from the session attributes
+func (e *Env) GetUsers(ctx context.Context) ([]User, error) {
+	rows, err := e.db.QueryContext(ctx, `SELECT sid, name, value FROM session_attribute
+		WHERE authenticated = 1 AND name IN ('name', 'email') ORDER BY sid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session attributes: %w", err)
+	}
+
+	var users []User
+	for rows.Next() {
+		var sid, name string
+		var value sql.NullString
+		if err := rows.Scan(&sid, &name, &value); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		if len(users) == 0 || users[len(users)-1].Username != sid {
+			users = append(users, User{Username: sid})
+		}
+		if name == "name" {
+			users[len(users)-1].Name = value.String
+		} else {
+			users[len(users)-1].Email = value.String
+		}
+	}
+	return users, closeRows(rows)
+}
+
+// ticketQueryWhere translates the conditions of a Trac ticket query into SQL
+func ticketQueryWhere(query string) (string, []any, error) {
+	parsed, err := parseTicketQuery(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var conditions []string
+	var args []any
+	for _, cond := range parsed {
+		column := "COALESCE((SELECT value FROM ticket_custom c WHERE c.ticket = t.id AND c.name = ?), '')"
+		if cond.Field == "id" || slices.Contains(ticketColumns, cond.Field) {
+			column = "COALESCE(CAST(t." + cond.Field + " AS TEXT), '')"
+		} else {
+			args = append(args, cond.Field)
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cond.Values)), ", ")
+		for _, v := range cond.Values {
+			args = append(args, v)
+		}
+
+		operator := " IN "
+		if cond.Negate {
+			operator = " NOT IN "
+		}
+		conditions = append(conditions, column+operator+"("+placeholders+")")
+	}
+
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+// customFieldsFromIni reads the custom ticket fields of the [ticket-custom] section of trac.ini
+func customFieldsFromIni(section map[string]string) []TicketField {
+	var fields []TicketField
+	for key, inputType := range section {
+		if strings.Contains(key, ".") {
+			continue
+		}
+
+		field := TicketField{Name: key, InputType: inputType, Label: section[key+".label"], Custom: true, Optional: true}
+		if field.Label == "" {
+			field.Label = strings.ToUpper(key[:1]) + key[1:]
+		}
+		if options := section[key+".options"]; options != "" {
+			field.Options = strings.Split(options, "|")
+		}
+		field.Format = section[key+".format"]
+		field.Order, _ = strconv.Atoi(section[key+".order"])
+		fields = append(fields, field)
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].Order != fields[j].Order {
+			return fields[i].Order < fields[j].Order
+		}
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+// readIni parses a Trac configuration file into its sections
+func readIni(path string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if sections[name] == nil {
+				sections[name] = make(map[string]string)
+			}
+			current = sections[name]
+		case current != nil:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return sections, nil
+}
+
+// fromTracTime converts a timestamp of the Trac database. Trac 0.12 and later store
+// microseconds since the epoch, older releases seconds.
+func fromTracTime(value int64) time.Time {
+	if value > 1e11 || value < -1e11 {
+		return time.UnixMicro(value).UTC()
+	}
+	return time.Unix(value, 0).UTC()
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func scanInts(rows *sql.Rows) ([]int, error) {
+	var values []int
+	for rows.Next() {
+		var value int
+		if err := rows.Scan(&value); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, closeRows(rows)
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, closeRows(rows)
+}
+
+// closeRows closes rows and returns the first error of the iteration or of closing
+func closeRows(rows *sql.Rows) error {
+	err := rows.Err()
+	if closeErr := rows.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,187 @@
+package trac
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// envSchema is the subset of the Trac 1.x database schema read by Env
+const envSchema = `
+CREATE TABLE ticket (id INTEGER PRIMARY KEY, type TEXT, time INTEGER, changetime INTEGER, component TEXT,
+	severity TEXT, priority TEXT, owner TEXT, reporter TEXT, cc TEXT, version TEXT, milestone TEXT,
+	status TEXT, resolution TEXT, summary TEXT, description TEXT, keywords TEXT);
+CREATE TABLE ticket_custom (ticket INTEGER, name TEXT, value TEXT, UNIQUE (ticket, name));
+CREATE TABLE ticket_change (ticket INTEGER, time INTEGER, author TEXT, field TEXT, oldvalue TEXT, newvalue TEXT);
+CREATE TABLE milestone (name TEXT PRIMARY KEY, due INTEGER, completed INTEGER, description TEXT);
+CREATE TABLE component (name TEXT PRIMARY KEY, owner TEXT, description TEXT);
+CREATE TABLE version (name TEXT PRIMARY KEY, time INTEGER, description TEXT);
+CREATE TABLE enum (type TEXT, name TEXT, value TEXT, UNIQUE (type, name));
+CREATE TABLE wiki (name TEXT, version INTEGER, time INTEGER, author TEXT, text TEXT, comment TEXT, readonly INTEGER);
+CREATE TABLE attachment (type TEXT, id TEXT, filename TEXT, size INTEGER, time INTEGER, description TEXT, author TEXT);
+CREATE TABLE session_attribute (sid TEXT, authenticated INTEGER, name TEXT, value TEXT);
+
+INSERT INTO ticket VALUES (1, 'defect', 1577934245000000, 1577937845000000, 'core', 'major', 'high', 'bob', 'alice',
+	'', '', '1.0', 'closed', 'fixed', 'Crash on start', 'It crashes', 'crash');
+INSERT INTO ticket VALUES (2, 'task', 1577934245000000, 1577934245000000, 'ui', '', 'low', '', 'carol',
+	'', '', '', 'new', '', 'Polish', '', '');
+INSERT INTO ticket_custom VALUES (1, 'customer', 'ACME');
+INSERT INTO ticket_change VALUES (1, 1577935000000000, 'bob', 'comment', '1', 'Fixed in r10');
+INSERT INTO ticket_change VALUES (1, 1577935000000000, 'bob', 'status', 'new', 'closed');
+INSERT INTO ticket_change VALUES (1, 1577936000000000, 'alice', 'description', 'It crashes', 'It still crashes');
+INSERT INTO milestone VALUES ('1.0', 1580515200000000, 0, 'First release');
+INSERT INTO component VALUES ('core', 'bob', ''), ('ui', '', '');
+INSERT INTO enum VALUES ('priority', 'high', '1'), ('priority', 'low', '2'), ('ticket_type', 'defect', '1'), ('ticket_type', 'task', '2');
+INSERT INTO wiki VALUES ('WikiStart', 1, 1577934245000000, 'alice', '= Welcome =', 'created', 0);
+INSERT INTO wiki VALUES ('WikiStart', 2, 1577937845000000, 'bob', '= Welcome! =', '', 0);
+INSERT INTO attachment VALUES ('ticket', '1', 'trace.txt', 5, 1577934245000000, 'Stack trace', 'alice');
+INSERT INTO session_attribute VALUES ('alice', 1, 'name', 'Alice A.'), ('alice', 1, 'email', 'alice@example.com'),
+	('anon123', 0, 'email', 'anon@example.com');
+`
+
+func newTestEnv(t *testing.T) *Env {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "db"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, "db", "trac.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(envSchema); err != nil {
+		t.Fatalf("failed to create fixture database: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	parent, file := sha1Hex("1"), sha1Hex("trace.txt")
+	attachmentDir := filepath.Join(dir, "files", "attachments", "ticket", parent[:3], parent)
+	if err := os.MkdirAll(attachmentDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(attachmentDir, file+".txt"), []byte("trace"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "conf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ini := "[ticket-custom]\ncustomer = text\ncustomer.label = Customer\n"
+	if err := os.WriteFile(filepath.Join(dir, "conf", "trac.ini"), []byte(ini), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := OpenEnv(dir)
+	if err != nil {
+		t.Fatalf("OpenEnv returned error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = env.Close()
+	})
+	return env
+}
+
+func TestEnv_Tickets(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	for query, want := range map[string][]int{
+		"max=0":                      {1, 2},
+		"status!=closed":             {2},
+		"component=core|ui&max=0":    {1, 2},
+		"customer=ACME":              {1},
+		"status!=closed&priority=hi": nil,
+	} {
+		ids, err := env.GetAllTicketIDs(ctx, query)
+		if err != nil {
+			t.Fatalf("GetAllTicketIDs(%q) returned error: %v", query, err)
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("GetAllTicketIDs(%q) = %v, want %v", query, ids, want)
+		}
+	}
+
+	ticket, err := env.GetTicket(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetTicket returned error: %v", err)
+	}
+	if ticket.Attributes["summary"] != "Crash on start" || ticket.Attributes["customer"] != "ACME" {
+		t.Errorf("unexpected attributes %v", ticket.Attributes)
+	}
+	if want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC); !ticket.TimeCreated.Equal(want) {
+		t.Errorf("expected creation time %v, got %v", want, ticket.TimeCreated)
+	}
+	if len(ticket.Comments) != 1 || *ticket.Comments[0].NewValue != "Fixed in r10" {
+		t.Errorf("unexpected comments %+v", ticket.Comments)
+	}
+	if len(ticket.History) != 2 || ticket.History[0].Field != "status" || *ticket.History[1].OldValue != "It crashes" {
+		t.Errorf("unexpected history %+v", ticket.History)
+	}
+	if len(ticket.Attachments) != 1 || ticket.Attachments[0].Size != 5 {
+		t.Fatalf("unexpected attachments %+v", ticket.Attachments)
+	}
+
+	var buf bytes.Buffer
+	n, err := env.DownloadAttachment(ctx, ResourceTicket, 1, "trace.txt", &buf)
+	if err != nil || n != 5 || buf.String() != "trace" {
+		t.Errorf("DownloadAttachment = %d %q, %v", n, buf.String(), err)
+	}
+
+	recent, err := env.GetRecentTicketChanges(ctx, time.Date(2020, 1, 2, 3, 30, 0, 0, time.UTC))
+	if err != nil || !reflect.DeepEqual(recent, []int{1}) {
+		t.Errorf("GetRecentTicketChanges = %v, %v", recent, err)
+	}
+}
+
+func TestEnv_MilestonesWikiUsers(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	milestone, err := env.GetMilestoneByName(ctx, "1.0")
+	if err != nil {
+		t.Fatalf("GetMilestoneByName returned error: %v", err)
+	}
+	if milestone.DueDate == nil || milestone.CompletedDate != nil || *milestone.Description != "First release" {
+		t.Errorf("unexpected milestone %+v", milestone)
+	}
+
+	page, err := env.GetWikiPageInfo(ctx, "WikiStart")
+	if err != nil || page.Version != 2 || page.Author != "bob" {
+		t.Fatalf("GetWikiPageInfo = %+v, %v", page, err)
+	}
+	text, err := env.GetWikiPageVersion(ctx, "WikiStart", 1)
+	if err != nil || *text != "= Welcome =" {
+		t.Errorf("GetWikiPageVersion = %v, %v", text, err)
+	}
+
+	fields, err := env.GetTicketFields(ctx)
+	if err != nil {
+		t.Fatalf("GetTicketFields returned error: %v", err)
+	}
+	byName := make(map[string]TicketField)
+	for _, f := range fields {
+		byName[f.Name] = f
+	}
+	if !reflect.DeepEqual(byName["component"].Options, []string{"core", "ui"}) || !reflect.DeepEqual(byName["priority"].Options, []string{"high", "low"}) {
+		t.Errorf("unexpected field options %+v %+v", byName["component"], byName["priority"])
+	}
+	if f := byName["customer"]; !f.Custom || f.Label != "Customer" {
+		t.Errorf("unexpected custom field %+v", f)
+	}
+
+	users, err := env.GetUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetUsers returned error: %v", err)
+	}
+	if want := []User{{Username: "alice", Name: "Alice A.", Email: "alice@example.com"}}; !reflect.DeepEqual(users, want) {
+		t.Errorf("GetUsers = %+v, want %+v", users, want)
+	}
+}
@@ -0,0 +1,392 @@
+package trac
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory is a Trac source held in memory, for tests and fixtures. It returns the
+// same data as the XML-RPC client for the tickets, wiki pages, milestones and
+// attachments added to it and is safe for concurrent use.
+type Memory struct {
+	mu          sync.RWMutex
+	tickets     map[int]*Ticket
+	fields      []TicketField
+	milestones  map[string]*Milestone
+	components  map[string]*Component
+	workflow    []WorkflowAction
+	wiki        map[string][]memoryWikiVersion
+	attachments map[memoryAttachmentKey][]byte
+	users       []User
+}
+
+type memoryWikiVersion struct {
+	info WikiPage
+	text string
+}
+
+type memoryAttachmentKey struct {
+	resType  ResourceType
+	id       string
+	filename string
+}
+
+// NewMemory returns an empty in-memory source
+func NewMemory() *Memory {
+	return &Memory{
+		tickets:     make(map[int]*Ticket),
+		milestones:  make(map[string]*Milestone),
+		components:  make(map[string]*Component),
+		wiki:        make(map[string][]memoryWikiVersion),
+		attachments: make(map[memoryAttachmentKey][]byte),
+	}
+}
+
+// AddTicket adds or replaces a ticket. Its attachments are listed without content
+// unless they are added with AddAttachment.
+func (m *Memory) AddTicket(t Ticket) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tickets[int(t.ID)] = &t
+}
+
+// SetTicketFields sets the ticket fields returned by GetTicketFields
+func (m *Memory) SetTicketFields(fields []TicketField) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fields = fields
+}
+
+// AddMilestone adds or replaces a milestone
+func (m *Memory) AddMilestone(milestone Milestone) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.milestones[milestone.Name] = &milestone
+}
+
+// AddComponent adds or replaces a component
+func (m *Memory) AddComponent(component Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components[component.Name] = &component
+}
+
+// SetWorkflow sets the workflow actions, the basic workflow of Trac is used until it is set
+func (m *Memory) SetWorkflow(actions []WorkflowAction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.workflow = actions
+}
+
+// AddWikiVersion adds the next version of a wiki page, its version number is assigned
+func (m *Memory) AddWikiVersion(name, author, comment, text string, modified time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	versions := m.wiki[name]
+	m.wiki[name] = append(versions, memoryWikiVersion{
+		info: WikiPage{Name: name, Author: author, Comment: &comment, LastModified: modified, Version: int64(len(versions) + 1)},
+		text: text,
+	})
+}
+
+// AddAttachment stores the content of an attachment. Wiki attachments are also listed
+// with their page, ticket attachments must be part of the ticket.
+func (m *Memory) AddAttachment(resType ResourceType, id any, att Attachment, content []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := memoryAttachmentKey{resType, fmt.Sprint(id), att.Filename}
+	m.attachments[key] = content
+	if resType == ResourceWiki {
+		versions := m.wiki[key.id]
+		for i := range versions {
+			versions[i].info.Attachments = append(versions[i].info.Attachments, att)
+		}
+	}
+}
+
+// AddUser adds the name and email address of a user
+func (m *Memory) AddUser(user User) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users = append(m.users, user)
+}
+
+// GetAllTicketIDs returns the IDs of all tickets matching a Trac query in ascending order
+func (m *Memory) GetAllTicketIDs(ctx context.Context, query string) ([]int, error) {
+	conditions, err := parseTicketQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ticketIDs(func(t *Ticket) bool {
+		for _, cond := range conditions {
+			value := fmt.Sprint(t.ID)
+			if cond.Field != "id" {
+				value, _ = t.Attributes[cond.Field].(string)
+			}
+			if !cond.matches(value) {
+				return false
+			}
+		}
+		return true
+	}), nil
+}
+
+// GetRecentTicketChanges returns the IDs of all tickets changed since the given time
+func (m *Memory) GetRecentTicketChanges(ctx context.Context, since time.Time) ([]int, error) {
+	return m.ticketIDs(func(t *Ticket) bool {
+		return !t.TimeChanged.Before(since)
+	}), nil
+}
+
+func (m *Memory) ticketIDs(match func(*Ticket) bool) []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []int
+	for id, t := range m.tickets {
+		if match(t) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// GetTicket returns a copy of a ticket
+func (m *Memory) GetTicket(ctx context.Context, id int) (*Ticket, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tickets[id]
+	if !ok {
+		return nil, fmt.Errorf("ticket %d does not exist", id)
+	}
+
+	ticket := *t
+	ticket.Attributes = make(map[string]any, len(t.Attributes))
+	for k, v := range t.Attributes {
+		ticket.Attributes[k] = v
+	}
+	return &ticket, nil
+}
+
+// GetTicketHistory returns the history and comment entries of a ticket's change log
+func (m *Memory) GetTicketHistory(ctx context.Context, id int) ([]ChangeLogEntry, []ChangeLogEntry, error) {
+	t, err := m.GetTicket(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t.History, t.Comments, nil
+}
+
+// GetTicketFields returns the ticket fields set with SetTicketFields
+func (m *Memory) GetTicketFields(ctx context.Context) ([]TicketField, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.fields, nil
+}
+
+// GetMilestoneNames returns the names of all milestones
+func (m *Memory) GetMilestoneNames(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.milestones))
+	for name := range m.milestones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetMilestoneByName returns a copy of a milestone
+func (m *Memory) GetMilestoneByName(ctx context.Context, name string) (*Milestone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	milestone, ok := m.milestones[name]
+	if !ok {
+		return nil, fmt.Errorf("milestone %q does not exist", name)
+	}
+	copied := *milestone
+	return &copied, nil
+}
+
+// GetComponentNames returns the names of all components
+func (m *Memory) GetComponentNames(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.components))
+	for name := range m.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetComponentByName returns a copy of a component
+func (m *Memory) GetComponentByName(ctx context.Context, name string) (*Component, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	component, ok := m.components[name]
+	if !ok {
+		return nil, fmt.Errorf("component %q does not exist", name)
+	}
+	copied := *component
+	return &copied, nil
+}
+
+// GetWorkflow returns the workflow with the statuses of all tickets
+func (m *Memory) GetWorkflow(ctx context.Context) (*Workflow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w := m.currentWorkflow()
+	ids := make([]int, 0, len(m.tickets))
+	for id := range m.tickets {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if status, ok := m.tickets[id].Attributes["status"].(string); ok {
+			w.addStatus(status)
+		}
+	}
+	return w, nil
+}
+
+// GetTicketActions returns the actions of the workflow available in the status of a ticket
+func (m *Memory) GetTicketActions(ctx context.Context, id int) ([]TicketAction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tickets[id]
+	if !ok {
+		return nil, fmt.Errorf("ticket %d does not exist", id)
+	}
+	status, _ := t.Attributes["status"].(string)
+
+	var actions []TicketAction
+	for _, action := range m.currentWorkflow().ActionsFor(status) {
+		ticketAction := TicketAction{Name: action.Name, Label: action.Name}
+		if action.To != AnyStatus {
+			ticketAction.NextStatus = action.To
+			ticketAction.Hints = fmt.Sprintf("Next status will be '%s'.", action.To)
+		}
+		actions = append(actions, ticketAction)
+	}
+	return actions, nil
+}
+
+// currentWorkflow returns the workflow set with SetWorkflow or the basic workflow
+func (m *Memory) currentWorkflow() *Workflow {
+	if m.workflow == nil {
+		return NewWorkflow(defaultWorkflow)
+	}
+	return NewWorkflow(m.workflow)
+}
+
+// GetWikiPageNames returns the names of all wiki pages
+func (m *Memory) GetWikiPageNames(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.wiki))
+	for name := range m.wiki {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetWikiPageInfo returns the metadata of the latest version of a wiki page
+func (m *Memory) GetWikiPageInfo(ctx context.Context, pageName string) (*WikiPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := m.wiki[pageName]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("wiki page %q does not exist", pageName)
+	}
+	info := versions[len(versions)-1].info
+	return &info, nil
+}
+
+// GetWikiPageInfoVersion returns the metadata of a specific version of a wiki page
+func (m *Memory) GetWikiPageInfoVersion(ctx context.Context, pageName string, version int64) (*WikiPage, error) {
+	v, err := m.wikiVersion(pageName, version)
+	if err != nil {
+		return nil, err
+	}
+	return &v.info, nil
+}
+
+// GetWikiPageVersion returns the raw Trac markup of a specific version of a wiki page
+func (m *Memory) GetWikiPageVersion(ctx context.Context, pageName string, version int64) (*string, error) {
+	v, err := m.wikiVersion(pageName, version)
+	if err != nil {
+		return nil, err
+	}
+	return &v.text, nil
+}
+
+func (m *Memory) wikiVersion(pageName string, version int64) (memoryWikiVersion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := m.wiki[pageName]
+	if version < 1 || version > int64(len(versions)) {
+		return memoryWikiVersion{}, fmt.Errorf("wiki page %q has no version %d", pageName, version)
+	}
+	return versions[version-1], nil
+}
+
+// ListAttachments returns the attachments of a ticket or wiki page
+func (m *Memory) ListAttachments(ctx context.Context, resType ResourceType, id any) ([]Attachment, error) {
+	switch resType {
+	case ResourceTicket:
+		ticketID, ok := id.(int)
+		if !ok {
+			return nil, fmt.Errorf("unexpected ticket ID type: %T", id)
+		}
+		t, err := m.GetTicket(ctx, ticketID)
+		if err != nil {
+			return nil, err
+		}
+		return t.Attachments, nil
+	case ResourceWiki:
+		page, err := m.GetWikiPageInfo(ctx, fmt.Sprint(id))
+		if err != nil {
+			return nil, err
+		}
+		return page.Attachments, nil
+	default:
+		return nil, fmt.Errorf("unsupported resource type: %s", resType)
+	}
+}
+
+// DownloadAttachment writes the content of an attachment added with AddAttachment to w
+func (m *Memory) DownloadAttachment(ctx context.Context, resType ResourceType, id any, filename string, w io.Writer) (int64, error) {
+	m.mu.RLock()
+	content, ok := m.attachments[memoryAttachmentKey{resType, fmt.Sprint(id), filename}]
+	m.mu.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("attachment %q of %s %v does not exist", filename, resType, id)
+	}
+	return io.Copy(w, bytes.NewReader(content))
+}
+
+// GetUsers returns the users added with AddUser
+func (m *Memory) GetUsers(ctx context.Context) ([]User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.users, nil
+}
@@ -1,6 +1,7 @@
 package trac
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -16,10 +17,10 @@ type Milestone struct {
 }
 
 // GetMilestoneNames retrieves the names of all milestones in Trac.
-func (c *Client) GetMilestoneNames() ([]string, error) {
+func (c *Client) GetMilestoneNames(ctx context.Context) ([]string, error) {
 	var resp []string
 
-	err := c.rpc.Call("ticket.milestone.getAll", nil, &resp)
+	err := c.rpc.CallContext(ctx, "ticket.milestone.getAll", nil, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -28,9 +29,9 @@ func (c *Client) GetMilestoneNames() ([]string, error) {
 }
 
 // GetMilestoneByName retrieves a milestone by its name.
-func (c *Client) GetMilestoneByName(name string) (*Milestone, error) {
+func (c *Client) GetMilestoneByName(ctx context.Context, name string) (*Milestone, error) {
 	var resp map[string]any
-	err := c.rpc.Call("ticket.milestone.get", []any{name}, &resp)
+	err := c.rpc.CallContext(ctx, "ticket.milestone.get", []any{name}, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call milestone.get: %w", err)
 	}
@@ -0,0 +1,46 @@
+package trac
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ticketCondition is one condition of a Trac ticket query, e.g. status!=closed|invalid
+type ticketCondition struct {
+	Field  string
+	Values []string
+	Negate bool
+}
+
+// matches reports whether a ticket field with the given value satisfies the condition
+func (c ticketCondition) matches(value string) bool {
+	return slices.Contains(c.Values, value) != c.Negate
+}
+
+// parseTicketQuery parses the conditions of a Trac ticket query for the sources that
+// evaluate queries themselves. Options such as max and order are ignored.
+func parseTicketQuery(query string) ([]ticketCondition, error) {
+	var conditions []ticketCondition
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+
+		negate := false
+		name, value, ok := strings.Cut(part, "!=")
+		if ok {
+			negate = true
+		} else if name, value, ok = strings.Cut(part, "="); !ok {
+			return nil, fmt.Errorf("unsupported ticket query condition %q", part)
+		}
+
+		switch name {
+		case "max", "order", "desc", "col", "page":
+			continue
+		}
+
+		conditions = append(conditions, ticketCondition{Field: name, Values: strings.Split(value, "|"), Negate: negate})
+	}
+	return conditions, nil
+}
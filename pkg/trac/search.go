@@ -1,6 +1,7 @@
 package trac
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -10,10 +11,10 @@ type SearchFilter struct {
 	Description string
 }
 
-func (c *Client) GetSearchFilters() ([]SearchFilter, error) {
+func (c *Client) GetSearchFilters(ctx context.Context) ([]SearchFilter, error) {
 	var resp [][]string
 
-	err := c.rpc.Call("search.getSearchFilters", nil, &resp)
+	err := c.rpc.CallContext(ctx, "search.getSearchFilters", nil, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -39,11 +40,11 @@ type SearchResult struct {
 	Excerpt string
 }
 
-func (c *Client) Search(query string) ([]SearchResult, error) {
+func (c *Client) Search(ctx context.Context, query string) ([]SearchResult, error) {
 
 	var resp [][]any
 
-	err := c.rpc.Call("search.performSearch", []any{query}, &resp)
+	err := c.rpc.CallContext(ctx, "search.performSearch", []any{query}, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
@@ -1,6 +1,7 @@
 package trac
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -14,24 +15,35 @@ type Ticket struct {
 	TimeChanged time.Time
 	Attributes  map[string]any
 	Attachments []Attachment
-	History     []ChangeLogEntry
-	Comments    []ChangeLogEntry
+	// History holds the changes of the description, status and resolution
+	History  []ChangeLogEntry
+	Comments []ChangeLogEntry
 }
 
 // GetAllTicketIDs queries Trac for all matching ticket IDs
-func (c *Client) GetAllTicketIDs(query string) ([]int, error) {
+func (c *Client) GetAllTicketIDs(ctx context.Context, query string) ([]int, error) {
 	var result []int
-	err := c.rpc.Call("ticket.query", []any{query}, &result)
+	err := c.rpc.CallContext(ctx, "ticket.query", []any{query}, &result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tickets: %w", err)
 	}
 	return result, nil
 }
 
+// GetRecentTicketChanges returns the IDs of all tickets changed since the given time
+func (c *Client) GetRecentTicketChanges(ctx context.Context, since time.Time) ([]int, error) {
+	var result []int
+	err := c.rpc.CallContext(ctx, "ticket.getRecentChanges", []any{since.UTC()}, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent ticket changes: %w", err)
+	}
+	return result, nil
+}
+
 // GetTicket fetches full ticket data by ID
-func (c *Client) GetTicket(id int) (*Ticket, error) {
+func (c *Client) GetTicket(ctx context.Context, id int) (*Ticket, error) {
 	var resp []any
-	err := c.rpc.Call("ticket.get", []any{id}, &resp)
+	err := c.rpc.CallContext(ctx, "ticket.get", []any{id}, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("ticket.get call failed: %w", err)
 	}
@@ -56,18 +68,20 @@ func (c *Client) GetTicket(id int) (*Ticket, error) {
 		return nil, fmt.Errorf("unexpected attributes type: %T", resp[3])
 	}
 
-	if desc, ok := attributes["description"].(string); ok {
-		attributes["description"] = utils.TracToMarkdown(desc)
-	}
-
-	attachments, err := ListAttachments(c, ResourceTicket, id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get attachments for ticket %d: %w", id, err)
+	var attachments []Attachment
+	if c.caps.Has(CapTicketAttachments) {
+		attachments, err = c.ListAttachments(ctx, ResourceTicket, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get attachments for ticket %d: %w", id, err)
+		}
 	}
 
-	descriptions, comments, err := c.GetTicketHistory(id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get ticket history for %d: %w", id, err)
+	var history, comments []ChangeLogEntry
+	if c.caps.Has(CapTicketChangeLog) {
+		history, comments, err = c.GetTicketHistory(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ticket history for %d: %w", id, err)
+		}
 	}
 
 	return &Ticket{
@@ -76,11 +90,20 @@ func (c *Client) GetTicket(id int) (*Ticket, error) {
 		TimeChanged: timeChanged,
 		Attributes:  attributes,
 		Attachments: attachments,
-		History:     descriptions,
+		History:     history,
 		Comments:    comments,
 	}, nil
 }
 
+// IsHistoryField reports whether changes of a ticket field are kept in the ticket history
+func IsHistoryField(field string) bool {
+	switch field {
+	case "description", "status", "resolution":
+		return true
+	}
+	return false
+}
+
 // ChangeLogEntry represents a single entry in the ticket change log
 type ChangeLogEntry struct {
 	Time      time.Time
@@ -92,15 +115,15 @@ type ChangeLogEntry struct {
 }
 
 // GetTicketHistory retrieves the change log for a specific ticket ID,
-// returning description and comment entries separately.
-func (c *Client) GetTicketHistory(id int) ([]ChangeLogEntry, []ChangeLogEntry, error) {
+// returning the history entries and the comment entries separately.
+func (c *Client) GetTicketHistory(ctx context.Context, id int) ([]ChangeLogEntry, []ChangeLogEntry, error) {
 	var resp []any
-	err := c.rpc.Call("ticket.changeLog", []any{id}, &resp)
+	err := c.rpc.CallContext(ctx, "ticket.changeLog", []any{id}, &resp)
 	if err != nil {
 		return nil, nil, fmt.Errorf("ticket.changeLog call failed: %w", err)
 	}
 
-	var descriptions []ChangeLogEntry
+	var history []ChangeLogEntry
 	var comments []ChangeLogEntry
 
 	for i, item := range resp {
@@ -113,11 +136,11 @@ func (c *Client) GetTicketHistory(id int) ([]ChangeLogEntry, []ChangeLogEntry, e
 
 		var oldValue, newValue *string
 		if fields[3] != nil {
-			val := utils.TracToMarkdown(fmt.Sprintf("%v", fields[3]))
+			val := fmt.Sprintf("%v", fields[3])
 			oldValue = &val
 		}
 		if fields[4] != nil {
-			val := utils.TracToMarkdown(fmt.Sprintf("%v", fields[4]))
+			val := fmt.Sprintf("%v", fields[4])
 			newValue = &val
 		}
 
@@ -130,15 +153,15 @@ func (c *Client) GetTicketHistory(id int) ([]ChangeLogEntry, []ChangeLogEntry, e
 			Permanent: fields[5].(int64),
 		}
 
-		switch entry.Field {
-		case "description":
-			descriptions = append(descriptions, entry)
-		case "comment":
+		switch {
+		case IsHistoryField(entry.Field):
+			history = append(history, entry)
+		case entry.Field == "comment":
 			comments = append(comments, entry)
 		}
 	}
 
-	return descriptions, comments, nil
+	return history, comments, nil
 }
 
 type TicketField struct {
@@ -153,9 +176,9 @@ type TicketField struct {
 	Custom     bool     `json:"custom,omitempty"`
 }
 
-func (c *Client) GetTicketFields() ([]TicketField, error) {
+func (c *Client) GetTicketFields(ctx context.Context) ([]TicketField, error) {
 	var rawFields []map[string]any
-	if err := c.rpc.Call("ticket.getTicketFields", nil, &rawFields); err != nil {
+	if err := c.rpc.CallContext(ctx, "ticket.getTicketFields", nil, &rawFields); err != nil {
 		return nil, fmt.Errorf("failed to get ticket fields: %w", err)
 	}
 
@@ -1 +1,8 @@
 package trac
+
+// User is a known user of a Trac environment with the name and email address from their preferences
+type User struct {
+	Username string `json:"username"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
@@ -0,0 +1,730 @@
+package trac
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Web reads a Trac 1.x instance through the pages every Trac serves without plugins:
+// ticket queries as CSV, tickets as tab separated values and RSS, raw attachments and
+// wiki text, and the HTML of attachment lists, wiki histories and the roadmap.
+//
+// Comments and milestone descriptions are only available rendered as HTML and are
+// converted to plain text, and the history of ticket descriptions is not available.
+type Web struct {
+	baseURL string
+	http    *http.Client
+
+	mu      sync.Mutex
+	history map[string][]WikiPage
+}
+
+// standardFields are the ticket fields every Trac has, the remaining columns are custom fields
+var standardFields = map[string]string{
+	"summary": "text", "reporter": "text", "owner": "text", "description": "textarea",
+	"type": "select", "status": "radio", "priority": "select", "milestone": "select",
+	"component": "select", "version": "select", "severity": "select", "resolution": "radio",
+	"keywords": "text", "cc": "text",
+}
+
+// NewWebClient creates a source reading the Trac instance at baseURL. A nil transport uses http.DefaultTransport.
+func NewWebClient(baseURL string, transport http.RoundTripper) *Web {
+	slog.Debug("Creating Trac web client", "url", baseURL)
+	return &Web{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Transport: transport},
+		history: make(map[string][]WikiPage),
+	}
+}
+
+// get requests a page of the Trac instance. Path segments must already be escaped.
+func (w *Web) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	target := w.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, &HTTPError{Path: path, StatusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// getText requests a page and returns its body
+func (w *Web) getText(ctx context.Context, path string, query url.Values) (string, error) {
+	resp, err := w.get(ctx, path, query)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// getTable requests a CSV or tab separated page and returns its rows keyed by the header
+func (w *Web) getTable(ctx context.Context, path string, query url.Values, comma rune) ([]map[string]string, error) {
+	text, err := w.getText(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(text, "\ufeff")))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// HTTPError is returned for pages that do not answer with 200 OK
+type HTTPError struct {
+	Path       string
+	StatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("request for %s failed: %d %s", e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// isNotFound reports whether err is a 404 response
+func isNotFound(err error) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
+// GetAllTicketIDs returns the IDs of all tickets matching a Trac query
+func (w *Web) GetAllTicketIDs(ctx context.Context, query string) ([]int, error) {
+	conditions, err := parseTicketQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{"format": {"csv"}, "col": {"id"}, "max": {"0"}, "order": {"id"}}
+	for _, cond := range conditions {
+		for _, v := range cond.Values {
+			if cond.Negate {
+				v = "!" + v
+			}
+			values.Add(cond.Field, v)
+		}
+	}
+	return w.queryIDs(ctx, values)
+}
+
+// GetRecentTicketChanges returns the IDs of all tickets changed since the given time
+func (w *Web) GetRecentTicketChanges(ctx context.Context, since time.Time) ([]int, error) {
+	return w.queryIDs(ctx, url.Values{
+		"format":     {"csv"},
+		"col":        {"id"},
+		"max":        {"0"},
+		"order":      {"id"},
+		"changetime": {since.UTC().Format(time.RFC3339) + ".."},
+	})
+}
+
+func (w *Web) queryIDs(ctx context.Context, values url.Values) ([]int, error) {
+	rows, err := w.getTable(ctx, "/query", values, ',')
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tickets: %w", err)
+	}
+
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		id, err := strconv.Atoi(strings.TrimPrefix(row["id"], "#"))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected ticket ID %q in query result", row["id"])
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetTicket reads a ticket from its tab separated export, the change times from the
+// ticket query and comments from the RSS feed
+func (w *Web) GetTicket(ctx context.Context, id int) (*Ticket, error) {
+	path := fmt.Sprintf("/ticket/%d", id)
+	rows, err := w.getTable(ctx, path, url.Values{"format": {"tab"}}, '\t')
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket %d: %w", id, err)
+	}
+	if len(rows) != 1 {
+		return nil, fmt.Errorf("unexpected export of ticket %d with %d rows", id, len(rows))
+	}
+
+	attributes := make(map[string]any, len(rows[0]))
+	for name, value := range rows[0] {
+		attributes[name] = value
+	}
+	delete(attributes, "id")
+
+	// The ticket export leaves out the times of older Trac releases, the query has them
+	created, changed := rows[0]["time"], rows[0]["changetime"]
+	delete(attributes, "time")
+	delete(attributes, "changetime")
+	if created == "" || changed == "" {
+		times, err := w.getTable(ctx, "/query", url.Values{"format": {"csv"}, "id": {strconv.Itoa(id)}, "col": {"id", "time", "changetime"}}, ',')
+		if err != nil {
+			return nil, fmt.Errorf("failed to get times of ticket %d: %w", id, err)
+		}
+		if len(times) == 1 {
+			created, changed = times[0]["time"], times[0]["changetime"]
+		}
+	}
+
+	ticket := &Ticket{ID: int64(id), Attributes: attributes}
+	if ticket.TimeCreated, err = parseWebTime(created); err != nil {
+		return nil, fmt.Errorf("failed to parse created time of ticket %d: %w", id, err)
+	}
+	if ticket.TimeChanged, err = parseWebTime(changed); err != nil {
+		return nil, fmt.Errorf("failed to parse changed time of ticket %d: %w", id, err)
+	}
+
+	if ticket.Attachments, err = w.ListAttachments(ctx, ResourceTicket, id); err != nil {
+		return nil, fmt.Errorf("failed to get attachments for ticket %d: %w", id, err)
+	}
+	if ticket.History, ticket.Comments, err = w.GetTicketHistory(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to get ticket history for %d: %w", id, err)
+	}
+	return ticket, nil
+}
+
+// rssFeed is the part of a Trac ticket RSS feed holding the changes
+type rssFeed struct {
+	Items []struct {
+		Link        string `xml:"link"`
+		Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Author      string `xml:"author"`
+		PubDate     string `xml:"pubDate"`
+		Description string `xml:"description"`
+	} `xml:"channel>item"`
+}
+
+// commentLink matches the comment number in the link of a change
+var commentLink = regexp.MustCompile(`#comment:(\d+)$`)
+
+// GetTicketHistory reads the comments and the status and resolution changes of a ticket
+// from its RSS feed. The feed does not contain earlier descriptions, so the history
+// holds no description changes.
+func (w *Web) GetTicketHistory(ctx context.Context, id int) ([]ChangeLogEntry, []ChangeLogEntry, error) {
+	text, err := w.getText(ctx, fmt.Sprintf("/ticket/%d", id), url.Values{"format": {"rss"}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var feed rssFeed
+	if err := xml.Unmarshal([]byte(text), &feed); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse RSS feed of ticket %d: %w", id, err)
+	}
+
+	var history, comments []ChangeLogEntry
+	for _, item := range feed.Items {
+		match := commentLink.FindStringSubmatch(item.Link)
+		if match == nil {
+			continue
+		}
+
+		changeTime, err := parseWebTime(item.PubDate)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse time of comment %s: %w", match[1], err)
+		}
+
+		author := item.Creator
+		if author == "" {
+			author = item.Author
+		}
+		history = append(history, fieldChanges(item.Description, changeTime, author)...)
+
+		number, comment := match[1], htmlToText(stripFieldChanges(item.Description))
+		comments = append(comments, ChangeLogEntry{
+			Time:      changeTime,
+			Author:    author,
+			Field:     "comment",
+			OldValue:  &number,
+			NewValue:  &comment,
+			Permanent: 1,
+		})
+	}
+
+	for _, entries := range [][]ChangeLogEntry{history, comments} {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Time.Before(entries[j].Time)
+		})
+	}
+	return history, comments, nil
+}
+
+// GetTicketFields returns the fields of the tickets. Trac does not publish the field
+// definitions, so the fields are the columns of the ticket export and the options of
+// select fields are the values used by the tickets.
+func (w *Web) GetTicketFields(ctx context.Context) ([]TicketField, error) {
+	ids, err := w.GetAllTicketIDs(ctx, "max=0")
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := w.getTable(ctx, fmt.Sprintf("/ticket/%d", ids[0]), url.Values{"format": {"tab"}}, '\t')
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket %d: %w", ids[0], err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	var fields []TicketField
+	var selects []string
+	for name := range rows[0] {
+		switch name {
+		case "id", "time", "changetime":
+			continue
+		}
+		inputType, standard := standardFields[name]
+		if !standard {
+			inputType = "text"
+		}
+		if inputType == "select" || inputType == "radio" {
+			selects = append(selects, name)
+		}
+		fields = append(fields, TicketField{Name: name, Label: strings.ToUpper(name[:1]) + name[1:], InputType: inputType, Custom: !standard})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].Custom != fields[j].Custom {
+			return !fields[i].Custom
+		}
+		return fields[i].Name < fields[j].Name
+	})
+
+	if len(selects) > 0 {
+		used, err := w.getTable(ctx, "/query", url.Values{"format": {"csv"}, "max": {"0"}, "col": selects}, ',')
+		if err != nil {
+			return nil, fmt.Errorf("failed to query field values: %w", err)
+		}
+		for i := range fields {
+			seen := make(map[string]bool)
+			for _, row := range used {
+				if value := row[fields[i].Name]; value != "" && !seen[value] {
+					seen[value] = true
+					fields[i].Options = append(fields[i].Options, value)
+				}
+			}
+			sort.Strings(fields[i].Options)
+		}
+	}
+	return fields, nil
+}
+
+// milestoneLink and wikiLink match the links to milestones and wiki pages
+var (
+	milestoneLink = regexp.MustCompile(`<a\s[^>]*href="[^"]*/milestone/([^"?#]+)"`)
+	wikiLink      = regexp.MustCompile(`<a\s([^>]*)>`)
+	wikiHref      = regexp.MustCompile(`href="[^"]*/wiki/([^"?#]+)"`)
+)
+
+// GetMilestoneNames returns the names of all milestones on the roadmap
+func (w *Web) GetMilestoneNames(ctx context.Context) ([]string, error) {
+	page, err := w.getText(ctx, "/roadmap", url.Values{"show": {"all"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roadmap: %w", err)
+	}
+	return uniqueLinks(milestoneLink.FindAllStringSubmatch(page, -1)), nil
+}
+
+// milestoneDate matches the paragraph with the due or completion date of a milestone
+var milestoneDate = regexp.MustCompile(`(?s)<p class="date">(.*?)</p>`)
+
+// GetMilestoneByName reads a milestone from its page
+func (w *Web) GetMilestoneByName(ctx context.Context, name string) (*Milestone, error) {
+	page, err := w.getText(ctx, "/milestone/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get milestone %q: %w", name, err)
+	}
+
+	m := &Milestone{Name: name}
+	if description := htmlToText(divContent(page, `class="description"`)); description != "" {
+		m.Description = &description
+	}
+
+	if match := milestoneDate.FindStringSubmatch(page); match != nil {
+		if t, ok := timelineTime(match[1]); ok {
+			if strings.Contains(match[1], "Completed") {
+				m.CompletedDate = &t
+			} else {
+				m.DueDate = &t
+			}
+		}
+	}
+	return m, nil
+}
+
+// GetWikiPageNames returns the names of all wiki pages in the title index
+func (w *Web) GetWikiPageNames(ctx context.Context) ([]string, error) {
+	page, err := w.getText(ctx, "/wiki/TitleIndex", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get title index: %w", err)
+	}
+
+	// Only the index itself, links in the navigation and to missing pages are left out
+	index := divContent(page, `class="titleindex"`)
+	if index == "" {
+		index = divContent(page, `id="wikipage"`)
+	}
+
+	var links [][]string
+	for _, tag := range wikiLink.FindAllStringSubmatch(index, -1) {
+		if strings.Contains(tag[1], "missing") {
+			continue
+		}
+		if href := wikiHref.FindStringSubmatch(tag[1]); href != nil {
+			links = append(links, href)
+		}
+	}
+	return uniqueLinks(links), nil
+}
+
+// GetWikiPageInfo returns the metadata of the latest version of a wiki page
+func (w *Web) GetWikiPageInfo(ctx context.Context, pageName string) (*WikiPage, error) {
+	history, err := w.wikiHistory(ctx, pageName)
+	if err != nil {
+		return nil, err
+	}
+	return w.withWikiAttachments(ctx, history[len(history)-1])
+}
+
+// GetWikiPageInfoVersion returns the metadata of a specific version of a wiki page
+func (w *Web) GetWikiPageInfoVersion(ctx context.Context, pageName string, version int64) (*WikiPage, error) {
+	history, err := w.wikiHistory(ctx, pageName)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range history {
+		if info.Version == version {
+			return w.withWikiAttachments(ctx, info)
+		}
+	}
+	return nil, fmt.Errorf("wiki page %q has no version %d", pageName, version)
+}
+
+func (w *Web) withWikiAttachments(ctx context.Context, info WikiPage) (*WikiPage, error) {
+	attachments, err := w.ListAttachments(ctx, ResourceWiki, info.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments for wiki page %s: %w", info.Name, err)
+	}
+	info.Attachments = attachments
+	return &info, nil
+}
+
+// GetWikiPageVersion reads the raw Trac markup of a specific version of a wiki page
+func (w *Web) GetWikiPageVersion(ctx context.Context, pageName string, version int64) (*string, error) {
+	text, err := w.getText(ctx, "/wiki/"+escapePath(pageName), url.Values{"format": {"txt"}, "version": {strconv.FormatInt(version, 10)}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wiki page %q version %d: %w", pageName, version, err)
+	}
+	return &text, nil
+}
+
+// historyRow and the following expressions match a version in the history of a wiki page
+var (
+	historyRow     = regexp.MustCompile(`(?s)<tr[\s>].*?</tr>`)
+	historyVersion = regexp.MustCompile(`(?s)<td class="version">.*?>\s*(\d+)\s*<`)
+	historyAuthor  = regexp.MustCompile(`(?s)<td class="author">(.*?)</td>`)
+	historyComment = regexp.MustCompile(`(?s)<td class="comment">(.*?)</td>`)
+)
+
+// wikiHistory reads the versions of a wiki page in ascending order. The history is
+// requested once per page.
+func (w *Web) wikiHistory(ctx context.Context, pageName string) ([]WikiPage, error) {
+	w.mu.Lock()
+	history, ok := w.history[pageName]
+	w.mu.Unlock()
+	if ok {
+		return history, nil
+	}
+
+	page, err := w.getText(ctx, "/wiki/"+escapePath(pageName), url.Values{"action": {"history"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history of wiki page %q: %w", pageName, err)
+	}
+
+	for _, row := range historyRow.FindAllString(page, -1) {
+		match := historyVersion.FindStringSubmatch(row)
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+
+		info := WikiPage{Name: pageName, Version: version}
+		info.LastModified, _ = timelineTime(row)
+		if author := historyAuthor.FindStringSubmatch(row); author != nil {
+			info.Author = htmlToText(author[1])
+		}
+		if comment := historyComment.FindStringSubmatch(row); comment != nil {
+			text := htmlToText(comment[1])
+			info.Comment = &text
+		}
+		history = append(history, info)
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("no versions found in the history of wiki page %q", pageName)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Version < history[j].Version
+	})
+
+	w.mu.Lock()
+	w.history[pageName] = history
+	w.mu.Unlock()
+	return history, nil
+}
+
+// attachmentEntry and the following expressions match an attachment in an attachment list
+var (
+	attachmentEntry   = regexp.MustCompile(`(?s)<dt>(.*?)</dt>\s*(?:<dd>(.*?)</dd>)?`)
+	attachmentRawLink = regexp.MustCompile(`href="[^"]*/raw-attachment/[^"]*/([^"/]+)"`)
+	attachmentLink    = regexp.MustCompile(`href="[^"]*/attachment/[^"]*/([^"/]+)"`)
+	attachmentSize    = regexp.MustCompile(`title="([\d,]+) bytes"`)
+	attachmentAuthor  = regexp.MustCompile(`(?s)added by (.*?)\s*(?:<a class="timeline"|$)`)
+	timelineFrom      = regexp.MustCompile(`/timeline\?from=([^&"]+)`)
+	blockEnd          = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|pre|h\d|tr|dd|dt|blockquote)>`)
+	htmlTag           = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLines        = regexp.MustCompile(`\n{3,}`)
+	fieldChangeList   = regexp.MustCompile(`(?s)^\s*<ul>.*?</ul>`)
+	fieldChangeItem   = regexp.MustCompile(`(?s)<li>(.*?)</li>`)
+	fieldChange       = regexp.MustCompile(`(?is)^(status|resolution)\s+(?:changed from\s+(.*?)\s+to\s+(.*)|set to\s+(.*)|(.*?)\s*deleted)$`)
+	divStartOrEnd     = regexp.MustCompile(`<div[\s>]|</div>`)
+)
+
+// ListAttachments reads the attachment list of a ticket or wiki page
+func (w *Web) ListAttachments(ctx context.Context, resType ResourceType, id any) ([]Attachment, error) {
+	path := fmt.Sprintf("/attachment/%s/%s/", resType, escapePath(fmt.Sprint(id)))
+	page, err := w.getText(ctx, path, nil)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources without attachments have no list
+	start := strings.Index(page, `<dl class="attachments">`)
+	if start < 0 {
+		return nil, nil
+	}
+	list := page[start:]
+	if end := strings.Index(list, "</dl>"); end >= 0 {
+		list = list[:end]
+	}
+
+	var attachments []Attachment
+	for _, entry := range attachmentEntry.FindAllStringSubmatch(list, -1) {
+		link := attachmentRawLink.FindStringSubmatch(entry[1])
+		if link == nil {
+			link = attachmentLink.FindStringSubmatch(entry[1])
+		}
+		if link == nil {
+			continue
+		}
+		filename, err := url.PathUnescape(link[1])
+		if err != nil {
+			filename = link[1]
+		}
+
+		att := Attachment{Filename: html.UnescapeString(filename), Description: htmlToText(entry[2])}
+		if size := attachmentSize.FindStringSubmatch(entry[1]); size != nil {
+			att.Size, _ = strconv.ParseInt(strings.ReplaceAll(size[1], ",", ""), 10, 64)
+		}
+		if author := attachmentAuthor.FindStringSubmatch(entry[1]); author != nil {
+			att.Author = htmlToText(author[1])
+		}
+		att.Time, _ = timelineTime(entry[1])
+		attachments = append(attachments, att)
+	}
+	return attachments, nil
+}
+
+// DownloadAttachment streams the raw content of an attachment to w
+func (w *Web) DownloadAttachment(ctx context.Context, resType ResourceType, id any, filename string, dst io.Writer) (int64, error) {
+	path := fmt.Sprintf("/raw-attachment/%s/%s/%s", resType, escapePath(fmt.Sprint(id)), url.PathEscape(filename))
+	resp, err := w.get(ctx, path, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return io.Copy(dst, resp.Body)
+}
+
+// escapePath escapes the segments of a hierarchical name such as a wiki page "Sub/Page"
+func escapePath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uniqueLinks returns the unescaped, distinct first submatches in order of appearance
+func uniqueLinks(matches [][]string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, match := range matches {
+		name, err := url.PathUnescape(html.UnescapeString(match[1]))
+		if err != nil || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// timelineTime returns the time of the first timeline link in s, Trac links dates to the timeline
+func timelineTime(s string) (time.Time, bool) {
+	match := timelineFrom.FindStringSubmatch(s)
+	if match == nil {
+		return time.Time{}, false
+	}
+	value, err := url.QueryUnescape(html.UnescapeString(match[1]))
+	if err != nil {
+		return time.Time{}, false
+	}
+	t, err := parseWebTime(value)
+	return t, err == nil
+}
+
+// webTimeLayouts are the formats Trac uses for times in exports, feeds and links
+var webTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// parseWebTime parses a time of a Trac page, times without a zone are taken as UTC
+func parseWebTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range webTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time format %q", s)
+}
+
+// stripFieldChanges removes the list of changed fields at the start of a change in the RSS feed
+func stripFieldChanges(description string) string {
+	return fieldChangeList.ReplaceAllString(description, "")
+}
+
+// fieldChanges returns the status and resolution changes in the list of changed fields
+// of a change in the RSS feed
+func fieldChanges(description string, changeTime time.Time, author string) []ChangeLogEntry {
+	var entries []ChangeLogEntry
+	for _, item := range fieldChangeItem.FindAllStringSubmatch(fieldChangeList.FindString(description), -1) {
+		m := fieldChange.FindStringSubmatch(htmlToText(item[1]))
+		if m == nil {
+			continue
+		}
+		// Changed from old to new, set to new or old deleted
+		oldValue, newValue := m[2]+m[5], m[3]+m[4]
+		entries = append(entries, ChangeLogEntry{
+			Time:      changeTime,
+			Author:    author,
+			Field:     strings.ToLower(m[1]),
+			OldValue:  &oldValue,
+			NewValue:  &newValue,
+			Permanent: 1,
+		})
+	}
+	return entries
+}
+
+// htmlToText converts rendered HTML to plain text, keeping line breaks between blocks
+func htmlToText(s string) string {
+	s = blockEnd.ReplaceAllString(s, "\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = blankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// divContent returns the content of the first div whose opening tag contains attr,
+// or an empty string if there is none
+func divContent(page, attr string) string {
+	start := strings.Index(page, attr)
+	if start < 0 {
+		return ""
+	}
+	open := strings.LastIndex(page[:start], "<div")
+	tagEnd := strings.Index(page[start:], ">")
+	if open < 0 || tagEnd < 0 {
+		return ""
+	}
+	content := page[start+tagEnd+1:]
+
+	depth := 1
+	for _, loc := range divStartOrEnd.FindAllStringIndex(content, -1) {
+		if strings.HasPrefix(content[loc[0]:], "</") {
+			depth--
+		} else {
+			depth++
+		}
+		if depth == 0 {
+			return content[:loc[0]]
+		}
+	}
+	return content
+}
@@ -0,0 +1,139 @@
+package trac
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// webPages are responses of a Trac 1.4 instance, keyed by path and query
+var webPages = map[string]string{
+	"/query?col=id&format=csv&max=0&order=id":                  "\ufeffid\r\n1\r\n2\r\n",
+	"/query?col=id&format=csv&max=0&order=id&status=%21closed": "id\r\n2\r\n",
+	"/ticket/1?format=tab": "id\tsummary\treporter\towner\tdescription\ttype\tstatus\tpriority\tmilestone\tcomponent\tresolution\tcustomer\r\n" +
+		"1\tCrash on start\talice\tbob\tIt crashes\tdefect\tclosed\thigh\t1.0\tcore\tfixed\tACME\r\n",
+	"/query?col=id&col=time&col=changetime&format=csv&id=1": "id,time,changetime\r\n1,2020-01-02T03:04:05Z,2020-01-02T04:04:05Z\r\n",
+	"/ticket/1?format=rss": `<?xml version="1.0"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+<item>
+<dc:creator>bob</dc:creator>
+<pubDate>Thu, 02 Jan 2020 03:30:00 GMT</pubDate>
+<link>http://trac.example.com/ticket/1#comment:1</link>
+<description>&lt;ul&gt;&lt;li&gt;&lt;strong&gt;status&lt;/strong&gt; changed from new to closed&lt;/li&gt;&lt;li&gt;&lt;strong&gt;resolution&lt;/strong&gt; set to &lt;em&gt;fixed&lt;/em&gt;&lt;/li&gt;&lt;/ul&gt;&lt;p&gt;Fixed in &lt;a href="/changeset/10"&gt;r10&lt;/a&gt; &amp;amp; released.&lt;/p&gt;</description>
+</item>
+<item>
+<dc:creator>alice</dc:creator>
+<pubDate>Thu, 02 Jan 2020 03:20:00 GMT</pubDate>
+<link>http://trac.example.com/ticket/1</link>
+<description>Ticket created</description>
+</item>
+</channel>
+</rss>`,
+	"/attachment/ticket/1/": `<div id="content"><dl class="attachments">
+<dt><a href="/attachment/ticket/1/trace%20log.txt" title="View attachment">trace log.txt</a><a class="trac-rawlink" href="/raw-attachment/ticket/1/trace%20log.txt" title="Download"></a>
+ (<span title="5 bytes">5 bytes</span>) - added by <span class="trac-author">alice</span> <a class="timeline" href="/timeline?from=2020-01-02T03%3A04%3A05Z&amp;precision=second" title="See timeline">6 years ago</a>.</dt>
+<dd>Stack trace</dd>
+</dl></div>`,
+	"/raw-attachment/ticket/1/trace%20log.txt": "trace",
+	"/roadmap?show=all": `<div class="milestone"><h2><a href="/milestone/1.0">Milestone: 1.0</a></h2></div>
+<div class="milestone"><h2><a href="/milestone/2.0%20beta">Milestone: 2.0 beta</a></h2></div>`,
+	"/milestone/1.0": `<div class="info"><p class="date">Completed <a class="timeline" href="/timeline?from=2020-02-01T00%3A00%3A00Z&amp;precision=second">5 years ago</a></p></div>
+<div class="description"><p>First <div class="code">release</div></p></div><div>footer</div>`,
+	"/wiki/TitleIndex": `<div class="mainnav"><a href="/wiki">Wiki</a></div><div class="wikipage searchable"><div class="titleindex"><ul>
+<li><a href="/wiki/Sub/Page">Sub/Page</a></li><li><a href="/wiki/WikiStart">WikiStart</a></li><li><a class="missing wiki" href="/wiki/Nope">Nope?</a></li></ul></div></div>`,
+	"/wiki/Sub/Page?action=history": `<table id="fieldhist"><tbody>
+<tr class="odd"><td class="version"><a href="/wiki/Sub/Page?version=2" title="View this version">2</a></td>
+<td class="date"><a class="timeline" href="/timeline?from=2020-01-03T00%3A00%3A00Z&amp;precision=second">x</a></td>
+<td class="author"><span class="trac-author">bob</span></td><td class="comment">fix &lt;typo&gt;</td></tr>
+<tr class="even"><td class="version"><a href="/wiki/Sub/Page?version=1" title="View this version">1</a></td>
+<td class="date"><a class="timeline" href="/timeline?from=2020-01-02T00%3A00%3A00Z&amp;precision=second">x</a></td>
+<td class="author">alice</td><td class="comment"></td></tr>
+</tbody></table>`,
+	"/wiki/Sub/Page?format=txt&version=1": "= Page =",
+}
+
+func TestWeb_Source(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.EscapedPath()
+		if r.URL.RawQuery != "" {
+			key += "?" + r.URL.Query().Encode()
+		}
+		page, ok := webPages[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	web := NewWebClient(server.URL+"/", nil)
+	ctx := context.Background()
+
+	for query, want := range map[string][]int{"max=0": {1, 2}, "max=0&status!=closed": {2}} {
+		ids, err := web.GetAllTicketIDs(ctx, query)
+		if err != nil || !reflect.DeepEqual(ids, want) {
+			t.Errorf("GetAllTicketIDs(%q) = %v, %v, want %v", query, ids, err, want)
+		}
+	}
+
+	ticket, err := web.GetTicket(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetTicket returned error: %v", err)
+	}
+	if ticket.Attributes["summary"] != "Crash on start" || ticket.Attributes["customer"] != "ACME" {
+		t.Errorf("unexpected attributes %v", ticket.Attributes)
+	}
+	if want := time.Date(2020, 1, 2, 4, 4, 5, 0, time.UTC); !ticket.TimeChanged.Equal(want) {
+		t.Errorf("expected change time %v, got %v", want, ticket.TimeChanged)
+	}
+	if len(ticket.Comments) != 1 || *ticket.Comments[0].OldValue != "1" || *ticket.Comments[0].NewValue != "Fixed in r10 & released." || ticket.Comments[0].Author != "bob" {
+		t.Errorf("unexpected comments %+v", ticket.Comments)
+	}
+	if len(ticket.History) != 2 || *ticket.History[0].NewValue != "closed" || ticket.History[1].Field != "resolution" || *ticket.History[1].NewValue != "fixed" {
+		t.Errorf("unexpected history %+v", ticket.History)
+	}
+	want := []Attachment{{Filename: "trace log.txt", Description: "Stack trace", Size: 5, Author: "alice", Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}}
+	if !reflect.DeepEqual(ticket.Attachments, want) {
+		t.Errorf("unexpected attachments %+v", ticket.Attachments)
+	}
+
+	var buf bytes.Buffer
+	if n, err := web.DownloadAttachment(ctx, ResourceTicket, 1, "trace log.txt", &buf); err != nil || n != 5 || buf.String() != "trace" {
+		t.Errorf("DownloadAttachment = %d %q, %v", n, buf.String(), err)
+	}
+
+	milestones, err := web.GetMilestoneNames(ctx)
+	if err != nil || !reflect.DeepEqual(milestones, []string{"1.0", "2.0 beta"}) {
+		t.Errorf("GetMilestoneNames = %v, %v", milestones, err)
+	}
+	milestone, err := web.GetMilestoneByName(ctx, "1.0")
+	if err != nil {
+		t.Fatalf("GetMilestoneByName returned error: %v", err)
+	}
+	if milestone.CompletedDate == nil || milestone.DueDate != nil || milestone.Description == nil || *milestone.Description != "First release" {
+		t.Errorf("unexpected milestone %+v", milestone)
+	}
+
+	pages, err := web.GetWikiPageNames(ctx)
+	if err != nil || !reflect.DeepEqual(pages, []string{"Sub/Page", "WikiStart"}) {
+		t.Errorf("GetWikiPageNames = %v, %v", pages, err)
+	}
+	info, err := web.GetWikiPageInfo(ctx, "Sub/Page")
+	if err != nil || info.Version != 2 || info.Author != "bob" || *info.Comment != "fix <typo>" {
+		t.Errorf("GetWikiPageInfo = %+v, %v", info, err)
+	}
+	first, err := web.GetWikiPageInfoVersion(ctx, "Sub/Page", 1)
+	if err != nil || first.Author != "alice" || !first.LastModified.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("GetWikiPageInfoVersion = %+v, %v", first, err)
+	}
+	text, err := web.GetWikiPageVersion(ctx, "Sub/Page", 1)
+	if err != nil || *text != "= Page =" {
+		t.Errorf("GetWikiPageVersion = %v, %v", text, err)
+	}
+}
@@ -1,6 +1,7 @@
 package trac
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,9 +9,9 @@ import (
 )
 
 // GetWikiPageNames retrieves the names of all wiki pages
-func (c *Client) GetWikiPageNames() ([]string, error) {
+func (c *Client) GetWikiPageNames(ctx context.Context) ([]string, error) {
 	var result []string
-	err := c.rpc.Call("wiki.getAllPages", nil, &result)
+	err := c.rpc.CallContext(ctx, "wiki.getAllPages", nil, &result)
 	if err != nil {
 		return nil, err
 	}
@@ -27,74 +28,73 @@ type WikiPage struct {
 	Attachments  []Attachment `json:"attachments,omitempty"`
 }
 
-// GetWikiPage retrieves the content of a wiki page
-func (c *Client) GetWikiPage(pageName string, version int) (*string, error) {
+// GetWikiPage retrieves the raw Trac markup of a wiki page
+func (c *Client) GetWikiPage(ctx context.Context, pageName string, version int) (*string, error) {
 	var content string
-	err := c.rpc.Call("wiki.getPage", pageName, &content)
+	err := c.rpc.CallContext(ctx, "wiki.getPage", pageName, &content)
 	if err != nil {
 		return nil, err
 	}
 
-	content = utils.TracToMarkdown(content)
-
 	return &content, nil
 }
 
 // GetWikiPageInfo retrieves metadata for a wiki page
-func (c *Client) GetWikiPageInfo(pageName string) (*WikiPage, error) {
+func (c *Client) GetWikiPageInfo(ctx context.Context, pageName string) (*WikiPage, error) {
 	var raw map[string]any
-	err := c.rpc.Call("wiki.getPageInfo", pageName, &raw)
+	err := c.rpc.CallContext(ctx, "wiki.getPageInfo", pageName, &raw)
 	if err != nil {
 		return nil, err
 	}
-	return c.decodeWikiPage(raw)
+	return c.decodeWikiPage(ctx, raw)
 }
 
 // GetWikiPageInfoVersion retrieves metadata for a specific version of a wiki page
-func (c *Client) GetWikiPageInfoVersion(pageName string, version int64) (*WikiPage, error) {
+func (c *Client) GetWikiPageInfoVersion(ctx context.Context, pageName string, version int64) (*WikiPage, error) {
 	var raw map[string]any
-	err := c.rpc.Call("wiki.getPageInfo", []any{pageName, version}, &raw)
+	err := c.rpc.CallContext(ctx, "wiki.getPageInfo", []any{pageName, version}, &raw)
 	if err != nil {
 		return nil, err
 	}
-	return c.decodeWikiPage(raw)
+	return c.decodeWikiPage(ctx, raw)
 }
 
-// GetWikiPageVersion retrieves a specific version of a wiki page
-func (c *Client) GetWikiPageVersion(pageName string, version int64) (*string, error) {
+// GetWikiPageVersion retrieves the raw Trac markup of a specific version of a wiki page
+func (c *Client) GetWikiPageVersion(ctx context.Context, pageName string, version int64) (*string, error) {
 	var content string
-	err := c.rpc.Call("wiki.getPage", []any{pageName, version}, &content)
+	err := c.rpc.CallContext(ctx, "wiki.getPage", []any{pageName, version}, &content)
 	if err != nil {
 		return nil, err
 	}
 
-	content = utils.TracToMarkdown(content)
-
 	return &content, nil
 }
 
 // decodeWikiPage converts raw map data from the RPC response into a WikiPage struct
-func (c *Client) decodeWikiPage(raw map[string]any) (*WikiPage, error) {
+func (c *Client) decodeWikiPage(ctx context.Context, raw map[string]any) (*WikiPage, error) {
 	page := &WikiPage{
 		Author:  utils.GetString(raw["author"]),
 		Name:    utils.GetString(raw["name"]),
 		Version: utils.GetInt64(raw["version"]),
 	}
 
-	if comment, ok := raw["comment"].(string); ok {
-		page.Comment = &comment
+	// An empty comment is decoded as nil, Trac itself has no null comments
+	if comment, ok := raw["comment"]; ok {
+		text := utils.GetString(comment)
+		page.Comment = &text
 	}
 
 	if lm, ok := raw["lastModified"].(time.Time); ok {
 		page.LastModified = lm
 	}
 
-	attachments, err := ListAttachments(c, ResourceWiki, page.Name)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get attachments for ticket %s: %w", page.Name, err)
+	if c.caps.Has(CapWikiAttachments) {
+		attachments, err := c.ListAttachments(ctx, ResourceWiki, page.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get attachments for ticket %s: %w", page.Name, err)
+		}
+		page.Attachments = attachments
 	}
 
-	page.Attachments = attachments
-
 	return page, nil
 }
@@ -0,0 +1,251 @@
+package trac
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bnidev/trac2gitlab/internal/utils"
+)
+
+// AnyStatus stands for every status in the From of an action, as To it keeps the status
+const AnyStatus = "*"
+
+// StatusNew and StatusClosed are the statuses every Trac workflow starts and ends with
+const (
+	StatusNew    = "new"
+	StatusClosed = "closed"
+)
+
+// Workflow is the ticket workflow of a Trac environment
+type Workflow struct {
+	Statuses []string         `json:"statuses"`
+	Actions  []WorkflowAction `json:"actions"`
+}
+
+// WorkflowAction moves tickets in any of the From statuses to the To status
+type WorkflowAction struct {
+	Name string   `json:"name"`
+	From []string `json:"from"`
+	To   string   `json:"to"`
+}
+
+// TicketAction is an action offered on a ticket by ticket.getActions
+type TicketAction struct {
+	Name  string
+	Label string
+	Hints string
+	// NextStatus is the status the action moves the ticket to, empty if it keeps the status
+	NextStatus string
+}
+
+// defaultWorkflow is the basic workflow of Trac, used when trac.ini does not configure one
+var defaultWorkflow = []WorkflowAction{
+	{Name: "leave", From: []string{AnyStatus}, To: AnyStatus},
+	{Name: "resolve", From: []string{"new", "assigned", "accepted", "reopened"}, To: StatusClosed},
+	{Name: "reassign", From: []string{"new", "assigned", "accepted", "reopened"}, To: "assigned"},
+	{Name: "accept", From: []string{"new", "assigned", "accepted", "reopened"}, To: "accepted"},
+	{Name: "reopen", From: []string{StatusClosed}, To: "reopened"},
+}
+
+// NewWorkflow returns the workflow of the actions with all statuses they use, "new" first
+func NewWorkflow(actions []WorkflowAction) *Workflow {
+	w := &Workflow{Statuses: []string{StatusNew}, Actions: actions}
+	for _, action := range actions {
+		for _, status := range append(slices.Clone(action.From), action.To) {
+			w.addStatus(status)
+		}
+	}
+	return w
+}
+
+// addStatus adds a status that is not known yet
+func (w *Workflow) addStatus(status string) {
+	if status != "" && status != AnyStatus && !slices.Contains(w.Statuses, status) {
+		w.Statuses = append(w.Statuses, status)
+	}
+}
+
+// addTransition adds the From status to an action with the same name and target, or a new action
+func (w *Workflow) addTransition(name, from, to string) {
+	for i := range w.Actions {
+		if w.Actions[i].Name == name && w.Actions[i].To == to {
+			if !slices.Contains(w.Actions[i].From, from) {
+				w.Actions[i].From = append(w.Actions[i].From, from)
+			}
+			return
+		}
+	}
+	w.Actions = append(w.Actions, WorkflowAction{Name: name, From: []string{from}, To: to})
+}
+
+// ActionsFor returns the actions available in a status
+func (w *Workflow) ActionsFor(status string) []WorkflowAction {
+	var actions []WorkflowAction
+	for _, action := range w.Actions {
+		if slices.Contains(action.From, status) || slices.Contains(action.From, AnyStatus) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// Order returns the statuses in workflow order: "new" first, then the statuses in the
+// order the actions reach them, statuses only reachable by reopening a closed ticket
+// after them and "closed" last
+func (w *Workflow) Order() []string {
+	var order, queue []string
+	visited := make(map[string]bool)
+	visit := func(status string) {
+		if status != "" && status != AnyStatus && !visited[status] {
+			visited[status] = true
+			order = append(order, status)
+			queue = append(queue, status)
+		}
+	}
+	walk := func(skipClosed bool) {
+		for len(queue) > 0 {
+			status := queue[0]
+			queue = queue[1:]
+			if skipClosed && status == StatusClosed {
+				continue
+			}
+			for _, action := range w.ActionsFor(status) {
+				visit(action.To)
+			}
+		}
+	}
+
+	// Breadth first from "new", actions of closed tickets are followed last
+	start := StatusNew
+	if !slices.Contains(w.Statuses, StatusNew) && len(w.Statuses) > 0 {
+		start = w.Statuses[0]
+	}
+	visit(start)
+	walk(true)
+	if visited[StatusClosed] {
+		queue = []string{StatusClosed}
+		walk(false)
+	}
+	for _, status := range w.Statuses {
+		visit(status)
+	}
+
+	if i := slices.Index(order, StatusClosed); i >= 0 {
+		order = append(slices.Delete(order, i, i+1), StatusClosed)
+	}
+	return order
+}
+
+// nextStatusPattern finds the target status in the hints of an action, e.g. "Next status
+// will be 'accepted'." or, from Trac 1.2 on, with the status in a span
+var nextStatusPattern = regexp.MustCompile(`Next status will be\s+(?:['"]|<[^>]*>)*([^'"<\s]+?)(?:['"]|<|\.?\s*$)`)
+
+// GetTicketActions retrieves the workflow actions available on a ticket.
+func (c *Client) GetTicketActions(ctx context.Context, id int) ([]TicketAction, error) {
+	var resp [][]any
+	if err := c.rpc.CallContext(ctx, "ticket.getActions", []any{id}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get actions of ticket %d: %w", id, err)
+	}
+
+	actions := make([]TicketAction, 0, len(resp))
+	for _, raw := range resp {
+		if len(raw) < 3 {
+			continue
+		}
+		action := TicketAction{
+			Name:  utils.GetString(raw[0]),
+			Label: utils.GetString(raw[1]),
+			Hints: utils.GetString(raw[2]),
+		}
+		if m := nextStatusPattern.FindStringSubmatch(action.Hints); m != nil {
+			action.NextStatus = m[1]
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// GetWorkflow reads the workflow from the status options of the ticket fields and the
+// actions offered on one ticket of every status. Statuses without tickets contribute
+// no actions.
+func (c *Client) GetWorkflow(ctx context.Context) (*Workflow, error) {
+	fields, err := c.GetTicketFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workflow{}
+	for _, field := range fields {
+		if field.Name == "status" {
+			for _, status := range field.Options {
+				w.addStatus(status)
+			}
+		}
+	}
+
+	for _, status := range w.Statuses {
+		ids, err := c.GetAllTicketIDs(ctx, "status="+status+"&max=1")
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		actions, err := c.GetTicketActions(ctx, ids[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, action := range actions {
+			to := action.NextStatus
+			if to == "" {
+				to = AnyStatus
+			}
+			w.addTransition(action.Name, status, to)
+			w.addStatus(to)
+		}
+	}
+	return w, nil
+}
+
+// workflowFromIni reads the actions of the [ticket-workflow] section of trac.ini,
+// ordered like Trac by their default value and name
+func workflowFromIni(section map[string]string) []WorkflowAction {
+	if len(section) == 0 {
+		return defaultWorkflow
+	}
+
+	var actions []WorkflowAction
+	defaults := make(map[string]int)
+	for key, value := range section {
+		if strings.Contains(key, ".") {
+			continue
+		}
+		from, to, ok := strings.Cut(value, "->")
+		if !ok {
+			continue
+		}
+
+		action := WorkflowAction{Name: key, To: strings.TrimSpace(to)}
+		for _, status := range strings.Split(from, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				action.From = append(action.From, status)
+			}
+		}
+		defaults[key], _ = strconv.Atoi(section[key+".default"])
+		actions = append(actions, action)
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		if defaults[actions[i].Name] != defaults[actions[j].Name] {
+			return defaults[actions[i].Name] > defaults[actions[j].Name]
+		}
+		return actions[i].Name < actions[j].Name
+	})
+	return actions
+}
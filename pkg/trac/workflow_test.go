@@ -0,0 +1,49 @@
+package trac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWorkflow_Order(t *testing.T) {
+	actions := workflowFromIni(map[string]string{
+		"leave":            "* -> *",
+		"accept":           "new,assigned,accepted,reopened -> accepted",
+		"reassign":         "new,assigned,accepted,reopened -> assigned",
+		"reassign.default": "1",
+		"test":             "accepted -> testing",
+		"resolve":          "new,assigned,accepted,reopened,testing -> closed",
+		"reopen":           "closed -> reopened",
+	})
+	if actions[0].Name != "reassign" {
+		t.Errorf("expected actions with a default first, got %v", actions)
+	}
+
+	want := []string{"new", "assigned", "accepted", "testing", "reopened", "closed"}
+	if got := NewWorkflow(actions).Order(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Order() = %v, want %v", got, want)
+	}
+
+	// Statuses without actions keep their order before closed
+	statuses := &Workflow{Statuses: []string{"accepted", "closed", "new", "review"}}
+	if got := statuses.Order(); !reflect.DeepEqual(got, []string{"new", "accepted", "review", "closed"}) {
+		t.Errorf("Order() without actions = %v", got)
+	}
+}
+
+func TestNextStatusPattern(t *testing.T) {
+	for hints, want := range map[string]string{
+		"Next status will be 'accepted'.": "accepted",
+		`The owner will be changed. Next status will be <span class="trac-field-new">testing</span>.`: "testing",
+		"Next status will be closed":            "closed",
+		"The ticket will remain with no owner.": "",
+	} {
+		got := ""
+		if m := nextStatusPattern.FindStringSubmatch(hints); m != nil {
+			got = m[1]
+		}
+		if got != want {
+			t.Errorf("next status of %q = %q, want %q", hints, got, want)
+		}
+	}
+}
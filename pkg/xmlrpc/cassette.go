@@ -0,0 +1,386 @@
+package xmlrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Interaction is a request and its response stored in a cassette
+type Interaction struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Call is the XML-RPC method of the request, for reading the cassette
+	Call        string `json:"call,omitempty"`
+	Request     string `json:"request,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Response    string `json:"response,omitempty"`
+	// ResponseBinary holds responses that are not valid UTF-8, such as raw attachments
+	ResponseBinary []byte `json:"response_binary,omitempty"`
+	// ResponseFile is the file holding a response larger than payloadLimit, relative to the cassette
+	ResponseFile string `json:"response_file,omitempty"`
+}
+
+// payloadLimit is the largest response stored in the cassette itself. Larger responses,
+// such as attachments, are written to files in a directory next to the cassette.
+const payloadLimit = 256 << 10
+
+// secretParams are query parameters whose values are removed from recorded URLs
+var secretParams = []string{"password", "passwd", "token", "private_token", "access_token", "api_key", "key", "secret"}
+
+// methodName matches the method of an XML-RPC request
+var methodName = regexp.MustCompile(`<methodName>([^<]*)</methodName>`)
+
+// Recorder is a transport that writes every request and its response to a cassette
+// file, one JSON interaction per line. Credentials in the URL are removed, headers
+// such as Authorization and Cookie are never recorded. Responses are passed through
+// as they are read and recorded once read completely.
+type Recorder struct {
+	base http.RoundTripper
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+	// payloadDir holds the responses larger than payloadLimit, created when the first is recorded
+	payloadDir string
+	payloads   int
+}
+
+// NewRecorder creates the cassette at path and records the traffic sent through base.
+// A nil base uses http.DefaultTransport.
+func NewRecorder(path string, base http.RoundTripper) (*Recorder, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cassette: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	return &Recorder{base: base, file: f, enc: enc, payloadDir: path + ".payloads"}, nil
+}
+
+// RoundTrip sends the request and records it with the response. The request body is
+// an XML-RPC call that identifies the interaction on replay, so it is kept whole.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &recordingBody{
+		body:     resp.Body,
+		recorder: r,
+		interaction: Interaction{
+			Method:      req.Method,
+			URL:         scrubURL(req.URL),
+			Call:        callName(body),
+			Request:     string(body),
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+		},
+	}
+	return resp, nil
+}
+
+// record writes an interaction to the cassette
+func (r *Recorder) record(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(interaction); err != nil {
+		return fmt.Errorf("failed to record interaction: %w", err)
+	}
+	return nil
+}
+
+// createPayload creates the file for a large response and returns it with its name
+// relative to the cassette
+func (r *Recorder) createPayload() (*os.File, string, error) {
+	r.mu.Lock()
+	r.payloads++
+	n := r.payloads
+	r.mu.Unlock()
+
+	if err := os.MkdirAll(r.payloadDir, 0755); err != nil {
+		return nil, "", fmt.Errorf("failed to create payload directory: %w", err)
+	}
+	name := fmt.Sprintf("%06d.bin", n)
+	f, err := os.Create(filepath.Join(r.payloadDir, name))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create payload file: %w", err)
+	}
+	return f, path.Join(filepath.Base(r.payloadDir), name), nil
+}
+
+// Close closes the cassette file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// recordingBody passes a response body through and records the interaction once the
+// body is read completely. Bodies closed early are read to the end, so the cassette
+// holds complete responses.
+type recordingBody struct {
+	body        io.ReadCloser
+	recorder    *Recorder
+	interaction Interaction
+	buf         bytes.Buffer
+	payload     *os.File
+	done        bool
+	err         error
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 && b.err == nil {
+		b.err = b.write(p[:n])
+	}
+	if errors.Is(err, io.EOF) {
+		if recordErr := b.finish(); recordErr != nil {
+			return n, recordErr
+		}
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	if !b.done {
+		if _, err := io.Copy(io.Discard, b); err != nil && b.err == nil {
+			b.err = err
+		}
+	}
+	closeErr := b.body.Close()
+	if b.err != nil {
+		return b.err
+	}
+	return closeErr
+}
+
+// write keeps a chunk of the response, in memory until it exceeds payloadLimit
+func (b *recordingBody) write(p []byte) error {
+	if b.payload == nil && b.buf.Len()+len(p) > payloadLimit {
+		f, name, err := b.recorder.createPayload()
+		if err != nil {
+			return err
+		}
+		b.payload = f
+		b.interaction.ResponseFile = name
+		if _, err := b.buf.WriteTo(f); err != nil {
+			return fmt.Errorf("failed to write payload file: %w", err)
+		}
+	}
+	if b.payload != nil {
+		if _, err := b.payload.Write(p); err != nil {
+			return fmt.Errorf("failed to write payload file: %w", err)
+		}
+		return nil
+	}
+	b.buf.Write(p)
+	return nil
+}
+
+// finish records the interaction once the whole response was read
+func (b *recordingBody) finish() error {
+	if b.done {
+		return b.err
+	}
+	b.done = true
+
+	if b.payload != nil {
+		if err := b.payload.Close(); err != nil && b.err == nil {
+			b.err = fmt.Errorf("failed to close payload file: %w", err)
+		}
+	} else if utf8.Valid(b.buf.Bytes()) {
+		b.interaction.Response = b.buf.String()
+	} else {
+		b.interaction.ResponseBinary = bytes.Clone(b.buf.Bytes())
+	}
+	if b.err != nil {
+		return b.err
+	}
+	b.err = b.recorder.record(b.interaction)
+	return b.err
+}
+
+// Player is a transport that answers requests from a cassette without network access.
+// Identical requests get the recorded responses in order, the last one is repeated.
+type Player struct {
+	mu        sync.Mutex
+	responses map[string][]Interaction
+	// dir is the directory of the cassette, response files are relative to it
+	dir string
+}
+
+// LoadCassette reads a cassette written by a Recorder
+func LoadCassette(path string) (*Player, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	p := &Player{responses: make(map[string][]Interaction), dir: filepath.Dir(path)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<30)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette line %d: %w", line, err)
+		}
+		key, err := interactionKey(interaction.Method, interaction.URL, interaction.Request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in cassette line %d: %w", line, err)
+		}
+		p.responses[key] = append(p.responses[key], interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	return p, nil
+}
+
+// ErrNotRecorded is returned for requests the cassette has no response for
+var ErrNotRecorded = errors.New("request not recorded in cassette")
+
+// RoundTrip answers the request with its recorded response
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+	}
+
+	key, err := interactionKey(req.Method, scrubURL(req.URL), string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	queue := p.responses[key]
+	if len(queue) == 0 {
+		p.mu.Unlock()
+		if call := callName(body); call != "" {
+			return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, call, req.URL.Path)
+		}
+		return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, req.URL.RequestURI())
+	}
+	interaction := queue[0]
+	if len(queue) > 1 {
+		p.responses[key] = queue[1:]
+	}
+	p.mu.Unlock()
+
+	respBody, length, err := p.responseBody(interaction)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header)
+	if interaction.ContentType != "" {
+		header.Set("Content-Type", interaction.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          respBody,
+		ContentLength: length,
+		Request:       req,
+	}, nil
+}
+
+// responseBody opens the recorded response, large responses are streamed from their file
+func (p *Player) responseBody(interaction Interaction) (io.ReadCloser, int64, error) {
+	if interaction.ResponseFile != "" {
+		f, err := os.Open(filepath.Join(p.dir, filepath.FromSlash(interaction.ResponseFile)))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open recorded response: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, 0, fmt.Errorf("failed to open recorded response: %w", err)
+		}
+		return f, info.Size(), nil
+	}
+
+	respBody := interaction.ResponseBinary
+	if respBody == nil {
+		respBody = []byte(interaction.Response)
+	}
+	return io.NopCloser(bytes.NewReader(respBody)), int64(len(respBody)), nil
+}
+
+// interactionKey identifies a request independent of the host it was sent to
+func interactionKey(method, rawURL, body string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return method + " " + u.RequestURI() + "\n" + body, nil
+}
+
+// scrubURL returns the URL without user information and with the values of secret query parameters removed
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+	scrubbed.User = nil
+
+	query := scrubbed.Query()
+	changed := false
+	for name := range query {
+		for _, secret := range secretParams {
+			if strings.EqualFold(name, secret) {
+				query.Set(name, "REDACTED")
+				changed = true
+			}
+		}
+	}
+	if changed {
+		scrubbed.RawQuery = query.Encode()
+	}
+	return scrubbed.String()
+}
+
+// callName returns the XML-RPC method of a request body, or an empty string
+func callName(body []byte) string {
+	if match := methodName.FindSubmatch(body); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><methodResponse><params><param><value><string>` +
+			strings.Repeat("x", calls) + `</string></value></param></params></methodResponse>`))
+	}))
+	defer server.Close()
+
+	cassette := filepath.Join(t.TempDir(), "trac.jsonl")
+	recorder, err := NewRecorder(cassette, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+
+	url := strings.Replace(server.URL, "http://", "http://admin:hunter2@", 1) + "/login/rpc?token=abc"
+	client, err := NewClient(url, recorder)
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	ctx := context.Background()
+	var first, second string
+	if err := client.CallContext(ctx, "wiki.getPage", []any{"WikiStart"}, &first); err != nil {
+		t.Fatalf("recorded call failed: %v", err)
+	}
+	if err := client.CallContext(ctx, "wiki.getPage", []any{"WikiStart"}, &second); err != nil {
+		t.Fatalf("recorded call failed: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) || bytes.Contains(data, []byte("abc")) {
+		t.Errorf("cassette contains credentials:\n%s", data)
+	}
+
+	// Replay needs no server, identical calls get their responses in order
+	server.Close()
+	player, err := LoadCassette(cassette)
+	if err != nil {
+		t.Fatalf("LoadCassette returned error: %v", err)
+	}
+	client, err = NewClient("http://elsewhere.example.com/login/rpc?token=xyz", player)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{first, second, second} {
+		var got string
+		if err := client.CallContext(ctx, "wiki.getPage", []any{"WikiStart"}, &got); err != nil {
+			t.Fatalf("replayed call failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected replayed %q, got %q", want, got)
+		}
+	}
+
+	var missing string
+	err = client.CallContext(ctx, "wiki.getPage", []any{"Other"}, &missing)
+	if err == nil || !strings.Contains(err.Error(), "wiki.getPage") {
+		t.Errorf("expected an error naming the unrecorded call, got %v", err)
+	}
+	if _, err := player.RoundTrip(httptest.NewRequest(http.MethodGet, "/nope", nil)); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded, got %v", err)
+	}
+}
+
+func TestCassette_LargeResponse(t *testing.T) {
+	attachment := bytes.Repeat([]byte{0xff, 0x00, 0x7f}, payloadLimit)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(attachment)
+	}))
+	defer server.Close()
+
+	cassette := filepath.Join(t.TempDir(), "trac.jsonl")
+	recorder, err := NewRecorder(cassette, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+
+	get := func(transport http.RoundTripper) []byte {
+		t.Helper()
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL + "/raw-attachment/ticket/1/core.bin")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		return data
+	}
+
+	if got := get(recorder); !bytes.Equal(got, attachment) {
+		t.Fatalf("recorded response changed, got %d bytes", len(got))
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	// The payload is kept next to the cassette instead of in it
+	info, err := os.Stat(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > payloadLimit {
+		t.Errorf("cassette holds the payload, %d bytes", info.Size())
+	}
+
+	player, err := LoadCassette(cassette)
+	if err != nil {
+		t.Fatalf("LoadCassette returned error: %v", err)
+	}
+	if got := get(player); !bytes.Equal(got, attachment) {
+		t.Errorf("replayed response differs, got %d bytes", len(got))
+	}
+}
@@ -1,13 +1,19 @@
 package xmlrpc
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"net/http/cookiejar"
 
 	"github.com/kolo/xmlrpc"
 )
 
 type Client struct {
 	*xmlrpc.Client
+	url  string
+	http *http.Client
 }
 
 // NewClient creates a new XML-RPC client for a given endpoint URL.
@@ -21,5 +27,46 @@ func NewClient(url string, transport http.RoundTripper) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{client}, nil
+	// Cookies of the session are kept like the embedded client does
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{Client: client, url: url, http: &http.Client{Transport: transport, Jar: jar}}, nil
+}
+
+// CallContext invokes the named method and decodes its reply. The request is sent with
+// ctx, so cancelling ctx aborts it and frees its connection and transport slot.
+func (c *Client) CallContext(ctx context.Context, serviceMethod string, args any, reply any) error {
+	req, err := xmlrpc.NewRequest(c.url, serviceMethod, args)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("request error: bad status code - %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	response := xmlrpc.Response(body)
+	if err := response.Err(); err != nil {
+		return err
+	}
+	if reply == nil {
+		return nil
+	}
+	return response.Unmarshal(reply)
 }
@@ -2,10 +2,16 @@ package xmlrpc
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewClient_BasicCallBehavior(t *testing.T) {
@@ -62,3 +68,159 @@ func TestNewClient_InvalidURL(t *testing.T) {
 		t.Fatalf("expected error for invalid URL, got nil")
 	}
 }
+
+func TestThrottledTransport_LimitsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/xml")
+		if _, err := w.Write([]byte(`<?xml version="1.0"?><methodResponse><params><param><value><int>1</int></value></param></params></methodResponse>`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, NewThrottledTransport(nil, 2, nil))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result int
+			if err := client.Call("sample.method", nil, &result); err != nil {
+				t.Errorf("client.Call returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", maxInFlight)
+	}
+}
+
+func TestClient_CallContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var result int
+	err = client.CallContext(ctx, "sample.method", nil, &result)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestClient_CallContextReleasesConnection(t *testing.T) {
+	stalled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("slow.method")) {
+			close(stalled)
+			// Stalls until the client gives up on the request
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><methodResponse><params><param><value><int>7</int></value></param></params></methodResponse>`))
+	}))
+	defer server.Close()
+
+	// A single slot, a call still holding it would block the next one
+	client, err := NewClient(server.URL, NewThrottledTransport(nil, 1, nil))
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stalled
+		cancel()
+	}()
+	var result int
+	if err := client.CallContext(ctx, "slow.method", nil, &result); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+
+	next, cancelNext := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelNext()
+	if err := client.CallContext(next, "fast.method", nil, &result); err != nil || result != 7 {
+		t.Errorf("call after cancellation = %d, %v; the slot was not released", result, err)
+	}
+}
+
+func TestClient_CallBase64(t *testing.T) {
+	content := bytes.Repeat([]byte("core dump \x00\x01\x02"), 10000)
+	encoded := base64.StdEncoding.EncodeToString(content)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/xml")
+
+		if bytes.Contains(body, []byte("missing.bin")) {
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><methodResponse><fault><value><struct>` +
+				`<member><name>faultCode</name><value><int>404</int></value></member>` +
+				`<member><name>faultString</name><value><string>not found</string></value></member>` +
+				`</struct></value></fault></methodResponse>`))
+			return
+		}
+
+		// Wrap the base64 text like Python's xmlrpclib does
+		var wrapped strings.Builder
+		for i := 0; i < len(encoded); i += 76 {
+			wrapped.WriteString(encoded[i:min(i+76, len(encoded))])
+			wrapped.WriteString("\n")
+		}
+		_, _ = w.Write([]byte(`<?xml version="1.0"?><methodResponse><params><param><value><base64>` + "\n" +
+			wrapped.String() + `</base64></value></param></params></methodResponse>`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, nil)
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+
+	var out bytes.Buffer
+	n, err := client.CallBase64(context.Background(), "ticket.getAttachment", []any{1, "core"}, &out)
+	if err != nil {
+		t.Fatalf("CallBase64 returned error: %v", err)
+	}
+	if n != int64(len(content)) || !bytes.Equal(out.Bytes(), content) {
+		t.Errorf("expected %d decoded bytes, got %d", len(content), n)
+	}
+
+	_, err = client.CallBase64(context.Background(), "ticket.getAttachment", []any{1, "missing.bin"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected fault error, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/kolo/xmlrpc"
+)
+
+// Base64 is a value encoded as base64 in a response
+type Base64 = xmlrpc.Base64
+
+// callParams matches the parameters of an XML-RPC request
+var callParams = regexp.MustCompile(`(?s)<params>(.*)</params>`)
+
+// paramTags matches the tags around each parameter
+var paramTags = regexp.MustCompile(`</?param>`)
+
+// ParseMethodCall decodes the method name and parameters of an XML-RPC request, for servers
+func ParseMethodCall(body []byte) (string, []any, error) {
+	name := methodName.FindSubmatch(body)
+	if name == nil {
+		return "", nil, fmt.Errorf("request has no method name")
+	}
+
+	params := callParams.FindSubmatch(body)
+	if params == nil {
+		return string(name[1]), nil, nil
+	}
+
+	// The parameters are decoded as the elements of an array
+	var doc bytes.Buffer
+	doc.WriteString("<value><array><data>")
+	doc.Write(paramTags.ReplaceAll(params[1], nil))
+	doc.WriteString("</data></array></value>")
+
+	var args []any
+	if err := xmlrpc.Response(doc.Bytes()).Unmarshal(&args); err != nil {
+		return "", nil, fmt.Errorf("failed to decode parameters of %s: %w", name[1], err)
+	}
+	return string(name[1]), args, nil
+}
+
+// EncodeResponse encodes the result of a method as an XML-RPC response
+func EncodeResponse(result any) ([]byte, error) {
+	call, err := xmlrpc.EncodeMethodCall("response", result)
+	if err != nil {
+		return nil, err
+	}
+
+	params := callParams.Find(call)
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><methodResponse>`)
+	b.Write(params)
+	b.WriteString("</methodResponse>")
+	return b.Bytes(), nil
+}
+
+// EncodeFault encodes an XML-RPC fault response
+func EncodeFault(code int, message string) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><methodResponse><fault><value><struct>`)
+	b.WriteString(`<member><name>faultCode</name><value><int>` + strconv.Itoa(code) + `</int></value></member>`)
+	b.WriteString(`<member><name>faultString</name><value><string>`)
+	_ = xml.EscapeText(&b, []byte(message))
+	b.WriteString(`</string></value></member></struct></value></fault></methodResponse>`)
+	return b.Bytes()
+}
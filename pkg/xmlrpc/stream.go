@@ -0,0 +1,138 @@
+package xmlrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kolo/xmlrpc"
+)
+
+// maxFaultSize bounds the part of a response buffered to decode a fault
+const maxFaultSize = 1 << 20
+
+// CallBase64 invokes a method that returns a single base64 value and streams the decoded
+// content to w, so the response is never held in memory. It returns the number of bytes written.
+func (c *Client) CallBase64(ctx context.Context, serviceMethod string, args []any, w io.Writer) (int64, error) {
+	req, err := xmlrpc.NewRequest(c.url, serviceMethod, args)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("request error: bad status code - %d", resp.StatusCode)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	var head bytes.Buffer
+	for {
+		tag, err := nextTag(body, &head)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		switch tag {
+		case "base64/":
+			return 0, nil
+		case "base64":
+			text := &base64Text{r: body}
+			n, err := io.Copy(w, base64.NewDecoder(base64.StdEncoding, text))
+			if err != nil {
+				return n, fmt.Errorf("failed to decode base64 response: %w", err)
+			}
+			return n, nil
+		case "fault":
+			if _, err := io.Copy(&head, io.LimitReader(body, maxFaultSize)); err != nil {
+				return 0, fmt.Errorf("failed to read fault: %w", err)
+			}
+			if err := xmlrpc.Response(head.Bytes()).Err(); err != nil {
+				return 0, err
+			}
+			return 0, errors.New("failed to decode fault response")
+		case "/params", "/methodResponse":
+			return 0, errors.New("response contains no base64 value")
+		}
+	}
+}
+
+// nextTag reads up to and including the next XML tag and returns its name. Everything
+// read is appended to head, which only ever holds the short envelope of a response.
+func nextTag(r *bufio.Reader, head *bytes.Buffer) (string, error) {
+	if _, err := r.ReadBytes('<'); err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	head.WriteByte('<')
+
+	tag, err := r.ReadString('>')
+	if err != nil {
+		return "", err
+	}
+	head.WriteString(tag)
+	if head.Len() > maxFaultSize {
+		return "", errors.New("response envelope too large")
+	}
+
+	name := strings.TrimSpace(strings.TrimSuffix(tag, ">"))
+	selfClosing := strings.HasSuffix(name, "/")
+	if fields := strings.Fields(strings.TrimSuffix(name, "/")); len(fields) > 0 {
+		name = fields[0]
+	}
+	if selfClosing {
+		name += "/"
+	}
+	return name, nil
+}
+
+// base64Text reads the text content of an element up to the next tag, skipping
+// whitespace and character references that encode whitespace
+type base64Text struct {
+	r    *bufio.Reader
+	done bool
+}
+
+func (t *base64Text) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && !t.done {
+		b, err := t.r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, err
+		}
+
+		switch b {
+		case '<':
+			t.done = true
+		case '&':
+			if _, err := t.r.ReadBytes(';'); err != nil {
+				return n, err
+			}
+		case ' ', '\t', '\r', '\n':
+		default:
+			p[n] = b
+			n++
+		}
+	}
+
+	if n == 0 && t.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
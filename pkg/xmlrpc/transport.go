@@ -0,0 +1,78 @@
+package xmlrpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Limiter limits the rate of requests, it is implemented by golang.org/x/time/rate.Limiter.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// ThrottledTransport limits the rate and the number of concurrent requests to an endpoint.
+// A request holds its slot until the response body is closed.
+type ThrottledTransport struct {
+	Base    http.RoundTripper
+	Limiter Limiter
+	slots   chan struct{}
+}
+
+// NewThrottledTransport creates a transport allowing at most maxConcurrent requests in flight
+// and, if limiter is not nil, at the rate allowed by the limiter. A maxConcurrent of 0 means no limit.
+func NewThrottledTransport(base http.RoundTripper, maxConcurrent int, limiter Limiter) *ThrottledTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t := &ThrottledTransport{Base: base, Limiter: limiter}
+	if maxConcurrent > 0 {
+		t.slots = make(chan struct{}, maxConcurrent)
+	}
+	return t
+}
+
+// RoundTrip waits for the rate limiter and a free slot before sending the request.
+func (t *ThrottledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if t.Limiter != nil {
+		if err := t.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	release := func() {}
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		var once sync.Once
+		release = func() { once.Do(func() { <-t.slots }) }
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody frees the slot of a request once its response body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}